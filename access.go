@@ -46,6 +46,7 @@ type ProjectAccessInfo struct {
 	CanUpload     bool                         `json:"can_upload"`
 	CanAdd        bool                         `json:"can_add"`
 	ConfigVisible bool                         `json:"config_visible"`
+	Groups        map[string]GroupInfo         `json:"groups,omitempty"`
 }
 
 // ListAccessRightsOptions specifies the parameters to the AccessService.ListAccessRights.
@@ -0,0 +1,286 @@
+package accesspolicy_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/go-gerrit"
+	"github.com/andygrunwald/go-gerrit/accesspolicy"
+	"github.com/andygrunwald/go-gerrit/gerrittest"
+)
+
+const testPolicy = `
+projects:
+  my/project:
+    sections:
+      refs/heads/*:
+        permissions:
+          label-Code-Review:
+            label: Code-Review
+            rules:
+              - group: Maintainers
+                min: -2
+                max: 2
+              - group: Registered Users
+                min: -1
+                max: 1
+          submit:
+            rules:
+              - group: Maintainers
+`
+
+// setup starts a fake server with the project my/project and the group Maintainers.
+// The live access rights of the project differ from testPolicy.
+func setup(t *testing.T) (*gerrittest.Server, *accesspolicy.Syncer, string) {
+	server := gerrittest.NewServer()
+	server.AddProject(gerrit.ProjectInfo{Name: "my/project"})
+	server.AddAccount(gerrit.AccountInfo{Name: "Administrator", Username: "admin"}, "admin-secret")
+	maintainers := server.AddGroup(gerrit.GroupInfo{Name: "Maintainers"}).ID
+
+	server.SetProjectAccess("my/project", map[string]gerrit.AccessSectionInfo{
+		"refs/heads/*": {
+			Permissions: map[string]gerrit.PermissionInfo{
+				"label-Code-Review": {
+					Label: "Code-Review",
+					Rules: map[string]gerrit.PermissionRuleInfo{
+						maintainers:               {Action: "ALLOW", Min: -1, Max: 1},
+						"global:Registered-Users": {Action: "ALLOW", Min: -1, Max: 1},
+					},
+				},
+				"push": {
+					Rules: map[string]gerrit.PermissionRuleInfo{
+						"global:Registered-Users": {Action: "ALLOW"},
+					},
+				},
+			},
+		},
+		"refs/heads/stable": {
+			Permissions: map[string]gerrit.PermissionInfo{
+				"read": {
+					Rules: map[string]gerrit.PermissionRuleInfo{
+						maintainers: {Action: "ALLOW"},
+					},
+				},
+			},
+		},
+	})
+
+	client := server.Client()
+	client.Authentication.SetBasicAuth("admin", "admin-secret")
+	return server, accesspolicy.NewSyncer(client), maintainers
+}
+
+func TestParse(t *testing.T) {
+	policy, err := accesspolicy.Parse([]byte(testPolicy), accesspolicy.FormatYAML)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := accesspolicy.Parse(data, accesspolicy.FormatJSON)
+	if err != nil {
+		t.Fatalf("Parse of JSON returned error: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, policy) {
+		t.Errorf("JSON policy %+v, want %+v", fromJSON, policy)
+	}
+
+	invalid := []string{
+		"projects:\n  p:\n    sektions: {}\n",
+		"projects:\n  p:\n    sections:\n      heads/*: {}\n",
+		"projects:\n  p:\n    sections:\n      refs/*:\n        permissions:\n          read:\n            rules:\n              - group: a\n                action: PERMIT\n",
+		"projects:\n  p:\n    sections:\n      refs/*:\n        permissions:\n          read:\n            rules:\n              - group: a\n              - group: a\n",
+	}
+	for _, data := range invalid {
+		if _, err := accesspolicy.Parse([]byte(data), accesspolicy.FormatYAML); err == nil {
+			t.Errorf("Parse of %q returned no error", data)
+		}
+	}
+}
+
+func TestLoadFiles_Duplicate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accesspolicy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a := filepath.Join(dir, "a.yaml")
+	b := filepath.Join(dir, "b.json")
+	ioutil.WriteFile(a, []byte(testPolicy), 0644)
+	ioutil.WriteFile(b, []byte(`{"projects":{"my/project":{"sections":{}}}}`), 0644)
+
+	if _, err := accesspolicy.LoadFiles(a); err != nil {
+		t.Errorf("LoadFiles returned error: %v", err)
+	}
+	if _, err := accesspolicy.LoadFiles(a, b); err == nil {
+		t.Error("LoadFiles with a project in two files returned no error")
+	}
+}
+
+func TestSyncer_Plan(t *testing.T) {
+	server, syncer, maintainers := setup(t)
+	defer server.Close()
+
+	policy, err := accesspolicy.Parse([]byte(testPolicy), accesspolicy.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := syncer.Plan(policy)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := plan.Print(buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `Project my/project:
+  ~ refs/heads/* label-Code-Review: ALLOW Maintainers -1..+1 => ALLOW Maintainers -2..+2
+  - refs/heads/* push
+  + refs/heads/* submit: ALLOW Maintainers
+  - refs/heads/stable
+
+Plan: 1 to add, 1 to change, 2 to remove.
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Plan printed\n%s\nwant\n%s", got, want)
+	}
+
+	input := plan.Projects[0].Input
+	wantInput := gerrit.ProjectAccessInput{
+		Remove: map[string]gerrit.AccessSectionInput{
+			"refs/heads/*": {
+				Permissions: map[string]gerrit.PermissionInput{
+					"label-Code-Review": {
+						Rules: map[string]gerrit.PermissionRuleInput{
+							maintainers: {},
+						},
+					},
+					"push": {},
+				},
+			},
+			"refs/heads/stable": {},
+		},
+		Add: map[string]gerrit.AccessSectionInput{
+			"refs/heads/*": {
+				Permissions: map[string]gerrit.PermissionInput{
+					// Only the rule is updated, so the attributes of the permission are not sent.
+					"label-Code-Review": {
+						Rules: map[string]gerrit.PermissionRuleInput{
+							maintainers: {Action: "ALLOW", Min: gerrit.Int(-2), Max: gerrit.Int(2)},
+						},
+					},
+					"submit": {
+						Rules: map[string]gerrit.PermissionRuleInput{
+							maintainers: {Action: "ALLOW"},
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(input, wantInput) {
+		t.Errorf("Plan input %+v, want %+v", input, wantInput)
+	}
+}
+
+func TestSyncer_Apply(t *testing.T) {
+	server, syncer, _ := setup(t)
+	defer server.Close()
+
+	policy, _ := accesspolicy.Parse([]byte(testPolicy), accesspolicy.FormatYAML)
+	plan, err := syncer.Plan(policy)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	results, err := syncer.Apply(plan, accesspolicy.ApplyReview)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Change == nil || results[0].Change.Branch != "refs/meta/config" {
+		t.Fatalf("Apply returned %+v, want a change for refs/meta/config", results)
+	}
+	if got := results[0].Change.Subject; got != accesspolicy.DefaultMessage {
+		t.Errorf("Access change subject %q, want the default message", got)
+	}
+
+	syncer.Message = "Apply policy"
+	results, err = syncer.Apply(plan, accesspolicy.ApplyReview)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if got := results[0].Change.Subject; got != "Apply policy" {
+		t.Errorf("Access change subject %q, want %q", got, "Apply policy")
+	}
+
+	if _, err := syncer.Apply(plan, accesspolicy.ApplyDirect); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	applied, err := syncer.Plan(policy)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	if applied.HasChanges() {
+		t.Errorf("Plan after Apply has changes: %+v", applied)
+	}
+
+	// The revision changed, so the plan is stale now.
+	if _, err := syncer.Apply(plan, accesspolicy.ApplyDirect); err == nil {
+		t.Error("Apply of a stale plan returned no error")
+	}
+}
+
+func TestSyncer_Plan_NoChanges(t *testing.T) {
+	server, syncer, maintainers := setup(t)
+	defer server.Close()
+
+	policy, _ := accesspolicy.Parse([]byte(testPolicy), accesspolicy.FormatYAML)
+	server.SetProjectAccess("my/project", map[string]gerrit.AccessSectionInfo{
+		"refs/heads/*": {
+			Permissions: map[string]gerrit.PermissionInfo{
+				"label-Code-Review": {
+					Label: "Code-Review",
+					Rules: map[string]gerrit.PermissionRuleInfo{
+						maintainers:               {Action: "ALLOW", Min: -2, Max: 2},
+						"global:Registered-Users": {Action: "ALLOW", Min: -1, Max: 1},
+					},
+				},
+				"submit": {
+					Rules: map[string]gerrit.PermissionRuleInfo{
+						maintainers: {Action: "ALLOW"},
+					},
+				},
+			},
+		},
+	})
+
+	plan, err := syncer.Plan(policy)
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	if plan.HasChanges() {
+		t.Errorf("Plan has changes: %+v", plan)
+	}
+}
+
+func TestSyncer_Plan_UnknownGroup(t *testing.T) {
+	server, syncer, _ := setup(t)
+	defer server.Close()
+
+	policy, _ := accesspolicy.Parse([]byte(strings.Replace(testPolicy, "group: Maintainers", "group: Unknown", -1)), accesspolicy.FormatYAML)
+	if _, err := syncer.Plan(policy); err == nil {
+		t.Error("Plan with an unknown group returned no error")
+	}
+}
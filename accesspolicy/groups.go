@@ -0,0 +1,70 @@
+package accesspolicy
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// GroupResolver resolves group names and UUIDs used in a policy.
+type GroupResolver interface {
+	// ResolveGroup returns the UUID and the name of the group with the given name or UUID.
+	ResolveGroup(group string) (uuid, name string, err error)
+}
+
+// systemGroups are the groups that exist on every Gerrit server, but can't be retrieved via the groups API.
+var systemGroups = map[string]string{
+	"global:Anonymous-Users":  "Anonymous Users",
+	"global:Registered-Users": "Registered Users",
+	"global:Project-Owners":   "Project Owners",
+	"global:Change-Owner":     "Change Owner",
+}
+
+// clientGroupResolver resolves groups via the GroupsService and caches the results.
+type clientGroupResolver struct {
+	client *gerrit.Client
+	cache  map[string][2]string
+}
+
+// NewGroupResolver returns a GroupResolver that looks up groups on the Gerrit server of client.
+// System groups like "Registered Users" are resolved without a request.
+// Results are cached, so a resolver should not be used for longer than a single sync.
+func NewGroupResolver(client *gerrit.Client) GroupResolver {
+	return &clientGroupResolver{
+		client: client,
+		cache:  map[string][2]string{},
+	}
+}
+
+func (r *clientGroupResolver) ResolveGroup(group string) (string, string, error) {
+	if cached, ok := r.cache[group]; ok {
+		return cached[0], cached[1], nil
+	}
+
+	for uuid, name := range systemGroups {
+		if group == uuid || group == name {
+			r.cache[group] = [2]string{uuid, name}
+			return uuid, name, nil
+		}
+	}
+
+	// Other groups of group backends, e.g. "ldap:cn=...", can't be looked up by name.
+	if i := strings.Index(group, ":"); i > 0 && !strings.Contains(group[:i], " ") {
+		r.cache[group] = [2]string{group, group}
+		return group, group, nil
+	}
+
+	info, _, err := r.client.Groups.GetGroup(url.PathEscape(group))
+	if err != nil {
+		return "", "", fmt.Errorf("group %q: %v", group, err)
+	}
+	// Gerrit returns URL encoded UUIDs, but access rules are keyed by the plain UUID.
+	uuid, err := url.QueryUnescape(info.ID)
+	if err != nil {
+		uuid = info.ID
+	}
+	r.cache[group] = [2]string{uuid, info.Name}
+	return uuid, info.Name, nil
+}
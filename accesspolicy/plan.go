@@ -0,0 +1,395 @@
+package accesspolicy
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// ChangeType is the kind of a planned change.
+type ChangeType int
+
+const (
+	// Add adds a section, permission or rule.
+	Add ChangeType = iota
+	// Update changes a permission or rule in place.
+	Update
+	// Remove removes a section, permission or rule.
+	Remove
+)
+
+// String returns the symbol of the change type as used in printed plans.
+func (t ChangeType) String() string {
+	switch t {
+	case Add:
+		return "+"
+	case Update:
+		return "~"
+	case Remove:
+		return "-"
+	}
+	return "?"
+}
+
+// Change is a single difference between the policy and the live access rights.
+// Depending on which fields are set, it is about a whole section, a whole permission or a single rule.
+type Change struct {
+	Type ChangeType
+
+	// Ref is the ref pattern of the access section.
+	Ref string
+	// Permission is empty if the change is about the whole section.
+	Permission string
+	// GroupUUID is empty if the change is about the whole permission or section.
+	GroupUUID string
+	// GroupName is the name of the group of GroupUUID.
+	GroupName string
+
+	// Old is the live rule for rule updates and removals.
+	Old *gerrit.PermissionRuleInfo
+	// New is the desired rule for rule additions and updates.
+	New *gerrit.PermissionRuleInfo
+
+	// Detail describes changed permission attributes, e.g. "exclusive: false => true".
+	Detail string
+}
+
+// String formats the change as a single line of a plan.
+func (c Change) String() string {
+	s := c.Type.String() + " " + c.Ref
+	if c.Permission != "" {
+		s += " " + c.Permission
+	}
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	if c.GroupUUID == "" {
+		return s
+	}
+
+	group := c.GroupName
+	if group == "" {
+		group = c.GroupUUID
+	}
+	switch c.Type {
+	case Add:
+		return s + ": " + formatRule(group, c.New)
+	case Remove:
+		return s + ": " + formatRule(group, c.Old)
+	}
+	return s + ": " + formatRule(group, c.Old) + " => " + formatRule(group, c.New)
+}
+
+// formatRule formats a rule as e.g. "ALLOW Maintainers -2..+2".
+func formatRule(group string, rule *gerrit.PermissionRuleInfo) string {
+	s := rule.Action + " "
+	if rule.Force {
+		s += "force "
+	}
+	s += group
+	if rule.Min != 0 || rule.Max != 0 {
+		s += fmt.Sprintf(" %+d..%+d", rule.Min, rule.Max)
+	}
+	return s
+}
+
+// ProjectPlan are the planned changes of a single project.
+type ProjectPlan struct {
+	Project string
+	// Revision is the revision of refs/meta/config the plan was computed against.
+	Revision string
+	// Changes lists all differences in a stable order.
+	Changes []Change
+	// Input is the request that applies Changes.
+	Input gerrit.ProjectAccessInput
+}
+
+// Plan lists the changes that are needed to bring the server in line with a policy.
+type Plan struct {
+	// Projects contains the plans of all projects of the policy, sorted by name.
+	Projects []ProjectPlan
+}
+
+// HasChanges reports if the live access rights drifted from the policy.
+func (p *Plan) HasChanges() bool {
+	for _, project := range p.Projects {
+		if len(project.Changes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary returns the number of planned additions, updates and removals.
+func (p *Plan) Summary() (add, update, remove int) {
+	for _, project := range p.Projects {
+		for _, c := range project.Changes {
+			switch c.Type {
+			case Add:
+				add++
+			case Update:
+				update++
+			case Remove:
+				remove++
+			}
+		}
+	}
+	return add, update, remove
+}
+
+// Print writes the plan in a human readable form to w:
+//
+//	Project my/project:
+//	  + refs/heads/* submit: ALLOW Maintainers
+//	  ~ refs/heads/* label-Code-Review: ALLOW Maintainers -1..+1 => ALLOW Maintainers -2..+2
+//	  - refs/heads/stable
+//
+//	Plan: 1 to add, 1 to change, 1 to remove.
+func (p *Plan) Print(w io.Writer) error {
+	if !p.HasChanges() {
+		_, err := fmt.Fprintln(w, "No changes. The access rights match the policy.")
+		return err
+	}
+
+	var b strings.Builder
+	for _, project := range p.Projects {
+		if len(project.Changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "Project %s:\n", project.Project)
+		for _, c := range project.Changes {
+			fmt.Fprintf(&b, "  %s\n", c)
+		}
+		b.WriteString("\n")
+	}
+	add, update, remove := p.Summary()
+	fmt.Fprintf(&b, "Plan: %d to add, %d to change, %d to remove.\n", add, update, remove)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// desiredPermission is a PermissionPolicy with resolved groups.
+type desiredPermission struct {
+	label     string
+	exclusive bool
+	rules     map[string]gerrit.PermissionRuleInfo
+}
+
+// normalizeRule fills in the defaults Gerrit applies to a rule.
+func normalizeRule(rule gerrit.PermissionRuleInfo) gerrit.PermissionRuleInfo {
+	if rule.Action == "" {
+		rule.Action = "ALLOW"
+	}
+	return rule
+}
+
+// diffProject computes the plan for a single project.
+// names maps group UUIDs to names for printing.
+func diffProject(project string, live *gerrit.ProjectAccessInfo, desired map[string]map[string]desiredPermission, names map[string]string) ProjectPlan {
+	plan := ProjectPlan{
+		Project:  project,
+		Revision: live.Revision,
+	}
+	input := &plan.Input
+
+	refs := map[string]bool{}
+	for ref := range live.Local {
+		refs[ref] = true
+	}
+	for ref := range desired {
+		refs[ref] = true
+	}
+
+	for _, ref := range sortedKeys(refs) {
+		liveSection, inLive := live.Local[ref]
+		desiredSection, inDesired := desired[ref]
+
+		if !inDesired {
+			plan.Changes = append(plan.Changes, Change{Type: Remove, Ref: ref})
+			if input.Remove == nil {
+				input.Remove = map[string]gerrit.AccessSectionInput{}
+			}
+			input.Remove[ref] = gerrit.AccessSectionInput{}
+			continue
+		}
+
+		permissions := map[string]bool{}
+		if inLive {
+			for name := range liveSection.Permissions {
+				permissions[name] = true
+			}
+		}
+		for name := range desiredSection {
+			permissions[name] = true
+		}
+
+		for _, name := range sortedKeys(permissions) {
+			livePermission, permissionInLive := liveSection.Permissions[name]
+			desiredPermission, permissionInDesired := desiredSection[name]
+
+			switch {
+			case !permissionInDesired:
+				plan.Changes = append(plan.Changes, Change{Type: Remove, Ref: ref, Permission: name})
+				setRemovePermission(input, ref, name, nil)
+
+			case !permissionInLive:
+				for _, uuid := range sortedRuleKeys(desiredPermission.rules) {
+					rule := desiredPermission.rules[uuid]
+					plan.Changes = append(plan.Changes, Change{Type: Add, Ref: ref, Permission: name, GroupUUID: uuid, GroupName: names[uuid], New: &rule})
+				}
+				setAddPermission(input, ref, name, &desiredPermission, desiredPermission.rules)
+
+			default:
+				diffPermission(&plan, ref, name, livePermission, desiredPermission, names)
+			}
+		}
+	}
+
+	return plan
+}
+
+// diffPermission computes the changes of a permission that exists in the policy and on the server.
+func diffPermission(plan *ProjectPlan, ref, name string, live gerrit.PermissionInfo, desired desiredPermission, names map[string]string) {
+	input := &plan.Input
+
+	var details []string
+	if live.Exclusive != desired.exclusive {
+		details = append(details, fmt.Sprintf("exclusive: %t => %t", live.Exclusive, desired.exclusive))
+	}
+	if live.Label != desired.label {
+		details = append(details, fmt.Sprintf("label: %q => %q", live.Label, desired.label))
+	}
+	attributesChanged := len(details) > 0
+	if attributesChanged {
+		plan.Changes = append(plan.Changes, Change{Type: Update, Ref: ref, Permission: name, Detail: strings.Join(details, ", ")})
+	}
+
+	groups := map[string]bool{}
+	for uuid := range live.Rules {
+		groups[uuid] = true
+	}
+	for uuid := range desired.rules {
+		groups[uuid] = true
+	}
+
+	removed := map[string]gerrit.PermissionRuleInfo{}
+	added := map[string]gerrit.PermissionRuleInfo{}
+	for _, uuid := range sortedKeys(groups) {
+		liveRule, ruleInLive := live.Rules[uuid]
+		desiredRule, ruleInDesired := desired.rules[uuid]
+		liveRule = normalizeRule(liveRule)
+
+		switch {
+		case !ruleInDesired:
+			plan.Changes = append(plan.Changes, Change{Type: Remove, Ref: ref, Permission: name, GroupUUID: uuid, GroupName: names[uuid], Old: &liveRule})
+			removed[uuid] = liveRule
+		case !ruleInLive:
+			plan.Changes = append(plan.Changes, Change{Type: Add, Ref: ref, Permission: name, GroupUUID: uuid, GroupName: names[uuid], New: &desiredRule})
+			added[uuid] = desiredRule
+		case liveRule != desiredRule:
+			plan.Changes = append(plan.Changes, Change{Type: Update, Ref: ref, Permission: name, GroupUUID: uuid, GroupName: names[uuid], Old: &liveRule, New: &desiredRule})
+			// Removals are applied before additions,
+			// so an updated rule is removed and added again.
+			removed[uuid] = liveRule
+			added[uuid] = desiredRule
+		}
+	}
+
+	if attributesChanged {
+		// Permission attributes can't be changed in place.
+		// The whole permission is removed and added again with all desired rules.
+		setRemovePermission(input, ref, name, nil)
+		setAddPermission(input, ref, name, &desired, desired.rules)
+		return
+	}
+	if len(removed) > 0 {
+		setRemovePermission(input, ref, name, removed)
+	}
+	if len(added) > 0 {
+		// The permission stays, so only the rules are sent and its attributes are left as they are.
+		setAddPermission(input, ref, name, nil, added)
+	}
+}
+
+// setRemovePermission removes rules of a permission.
+// If rules is nil, the whole permission is removed.
+func setRemovePermission(input *gerrit.ProjectAccessInput, ref, name string, rules map[string]gerrit.PermissionRuleInfo) {
+	if input.Remove == nil {
+		input.Remove = map[string]gerrit.AccessSectionInput{}
+	}
+	section := input.Remove[ref]
+	if section.Permissions == nil {
+		section.Permissions = map[string]gerrit.PermissionInput{}
+	}
+	permission := gerrit.PermissionInput{}
+	if rules != nil {
+		// Gerrit removes rules by group, so the rules themselves are not sent.
+		permission.Rules = map[string]gerrit.PermissionRuleInput{}
+		for uuid := range rules {
+			permission.Rules[uuid] = gerrit.PermissionRuleInput{}
+		}
+	}
+	section.Permissions[name] = permission
+	input.Remove[ref] = section
+}
+
+// setAddPermission adds rules to a permission.
+// If desired is set, the permission is created with its label and exclusive flag.
+// Otherwise only the rules are added to the existing permission.
+func setAddPermission(input *gerrit.ProjectAccessInput, ref, name string, desired *desiredPermission, rules map[string]gerrit.PermissionRuleInfo) {
+	if input.Add == nil {
+		input.Add = map[string]gerrit.AccessSectionInput{}
+	}
+	section := input.Add[ref]
+	if section.Permissions == nil {
+		section.Permissions = map[string]gerrit.PermissionInput{}
+	}
+	permission := gerrit.PermissionInput{Rules: map[string]gerrit.PermissionRuleInput{}}
+	if desired != nil {
+		permission.Label = desired.label
+		if desired.exclusive {
+			permission.Exclusive = gerrit.Bool(true)
+		}
+	}
+	for uuid, rule := range rules {
+		permission.Rules[uuid] = ruleInput(rule)
+	}
+	section.Permissions[name] = permission
+	input.Add[ref] = section
+}
+
+// ruleInput converts a rule to the input Gerrit expects.
+// Force and the range are only sent if they are set, so the server defaults apply otherwise.
+func ruleInput(rule gerrit.PermissionRuleInfo) gerrit.PermissionRuleInput {
+	input := gerrit.PermissionRuleInput{Action: rule.Action}
+	if rule.Force {
+		input.Force = gerrit.Bool(true)
+	}
+	if rule.Min != 0 || rule.Max != 0 {
+		input.Min = gerrit.Int(rule.Min)
+		input.Max = gerrit.Int(rule.Max)
+	}
+	return input
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedRuleKeys(m map[string]gerrit.PermissionRuleInfo) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,203 @@
+// Package accesspolicy synchronizes the access rights of Gerrit projects with a declarative policy.
+//
+// A policy describes the desired local access sections of a set of projects.
+// It is loaded from YAML or JSON files:
+//
+//	projects:
+//	  my/project:
+//	    sections:
+//	      refs/heads/*:
+//	        permissions:
+//	          label-Code-Review:
+//	            label: Code-Review
+//	            rules:
+//	              - group: Maintainers
+//	                min: -2
+//	                max: 2
+//	          submit:
+//	            rules:
+//	              - group: Maintainers
+//
+// Groups can be referenced by name or UUID.
+// The Syncer compares the policy against the live access rights of the server
+// and computes a Plan that lists all sections, permissions and rules to add, change or remove.
+// A Plan can be printed, used for drift detection, and applied either directly
+// or as access changes for review:
+//
+//	syncer := accesspolicy.NewSyncer(client)
+//	plan, err := syncer.Plan(policy)
+//	plan.Print(os.Stdout)
+//	if plan.HasChanges() {
+//		results, err := syncer.Apply(plan, accesspolicy.ApplyReview)
+//	}
+//
+// The policy is authoritative for the projects it lists:
+// Local sections, permissions and rules of these projects that are not part of the policy are removed.
+// Projects that are not listed are left alone.
+package accesspolicy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Policy is the desired access state of a set of projects.
+type Policy struct {
+	// Projects maps project names to their desired access rights.
+	Projects map[string]ProjectPolicy `json:"projects" yaml:"projects"`
+}
+
+// ProjectPolicy describes the desired local access sections of a project.
+type ProjectPolicy struct {
+	// Sections maps refs or ref patterns, e.g. "refs/heads/*", to the permissions on them.
+	Sections map[string]SectionPolicy `json:"sections" yaml:"sections"`
+}
+
+// SectionPolicy describes the desired permissions of an access section.
+type SectionPolicy struct {
+	// Permissions maps permission names, e.g. "submit" or "label-Code-Review", to permissions.
+	Permissions map[string]PermissionPolicy `json:"permissions" yaml:"permissions"`
+}
+
+// PermissionPolicy describes a desired permission.
+type PermissionPolicy struct {
+	// Label is the name of the label for label permissions.
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+	// Exclusive makes the permission exclusive for the ref.
+	Exclusive bool `json:"exclusive,omitempty" yaml:"exclusive,omitempty"`
+	// Rules assign the permission to groups.
+	Rules []RulePolicy `json:"rules" yaml:"rules"`
+}
+
+// RulePolicy assigns a permission to a group.
+type RulePolicy struct {
+	// Group is the name or UUID of the group.
+	Group string `json:"group" yaml:"group"`
+	// Action is one of ALLOW, DENY, BLOCK, INTERACTIVE or BATCH.
+	// If empty, ALLOW is used.
+	Action string `json:"action,omitempty" yaml:"action,omitempty"`
+	// Force is the force flag of the rule, e.g. for force push.
+	Force bool `json:"force,omitempty" yaml:"force,omitempty"`
+	// Min is the lowest label value that can be voted for label permissions.
+	Min int `json:"min,omitempty" yaml:"min,omitempty"`
+	// Max is the highest label value that can be voted for label permissions.
+	Max int `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// Format is the encoding of a policy file.
+type Format int
+
+const (
+	// FormatYAML is a YAML encoded policy.
+	FormatYAML Format = iota
+	// FormatJSON is a JSON encoded policy.
+	FormatJSON
+)
+
+// Parse decodes a policy from data.
+// Unknown fields are rejected, so typos in a policy don't go unnoticed.
+func Parse(data []byte, format Format) (*Policy, error) {
+	p := new(Policy)
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(p); err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		if err := yaml.UnmarshalStrict(data, p); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown policy format %d", format)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// LoadFiles loads and merges the policies in paths.
+// The format is derived from the file extension: ".json" is JSON, everything else YAML.
+// A project must not be defined in more than one file.
+func LoadFiles(paths ...string) (*Policy, error) {
+	merged := &Policy{Projects: map[string]ProjectPolicy{}}
+	definedIn := map[string]string{}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		format := FormatYAML
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			format = FormatJSON
+		}
+
+		p, err := Parse(data, format)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		for name, project := range p.Projects {
+			if other, ok := definedIn[name]; ok {
+				return nil, fmt.Errorf("%s: project %q is already defined in %s", path, name, other)
+			}
+			definedIn[name] = path
+			merged.Projects[name] = project
+		}
+	}
+
+	return merged, nil
+}
+
+// validActions are the actions a permission rule can have.
+var validActions = map[string]bool{
+	"ALLOW":       true,
+	"DENY":        true,
+	"BLOCK":       true,
+	"INTERACTIVE": true,
+	"BATCH":       true,
+}
+
+// Validate checks the policy for obvious mistakes that Gerrit would reject or silently ignore.
+func (p *Policy) Validate() error {
+	for name, project := range p.Projects {
+		if name == "" {
+			return fmt.Errorf("project without name")
+		}
+		for ref, section := range project.Sections {
+			if !strings.HasPrefix(ref, "refs/") && !strings.HasPrefix(ref, "^refs/") && ref != "GLOBAL_CAPABILITIES" {
+				return fmt.Errorf("project %s: section %q is not a ref", name, ref)
+			}
+			for permission, perm := range section.Permissions {
+				seen := map[string]bool{}
+				for _, rule := range perm.Rules {
+					if rule.Group == "" {
+						return fmt.Errorf("project %s: %s %s: rule without group", name, ref, permission)
+					}
+					if seen[rule.Group] {
+						return fmt.Errorf("project %s: %s %s: group %q has more than one rule", name, ref, permission, rule.Group)
+					}
+					seen[rule.Group] = true
+					if rule.Action != "" && !validActions[rule.Action] {
+						return fmt.Errorf("project %s: %s %s: invalid action %q", name, ref, permission, rule.Action)
+					}
+					if rule.Min > rule.Max {
+						return fmt.Errorf("project %s: %s %s: min %d is greater than max %d", name, ref, permission, rule.Min, rule.Max)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
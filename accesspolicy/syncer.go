@@ -0,0 +1,169 @@
+package accesspolicy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// DefaultMessage is the commit message used for access changes if Syncer.Message is empty.
+const DefaultMessage = "Sync access rights with policy"
+
+// ApplyMode defines how a plan is applied.
+type ApplyMode int
+
+const (
+	// ApplyDirect updates the access rights directly via ProjectsService.SetAccess.
+	ApplyDirect ApplyMode = iota
+	// ApplyReview creates a change for review per project via ProjectsService.CreateAccessChange.
+	ApplyReview
+)
+
+// ApplyResult is the outcome of applying the plan of a single project.
+type ApplyResult struct {
+	Project string
+	// Access is the new access of the project in ApplyDirect mode.
+	Access *gerrit.ProjectAccessInfo
+	// Change is the created access change in ApplyReview mode.
+	Change *gerrit.ChangeInfo
+}
+
+// Syncer plans and applies policies against a Gerrit server.
+type Syncer struct {
+	// Groups resolves the groups of a policy.
+	// NewSyncer sets it to a resolver that asks the server.
+	Groups GroupResolver
+
+	// Message is the commit message of access changes.
+	// If empty, DefaultMessage is used.
+	Message string
+
+	client *gerrit.Client
+}
+
+// NewSyncer returns a new Syncer for the Gerrit server of client.
+func NewSyncer(client *gerrit.Client) *Syncer {
+	return &Syncer{
+		Groups: NewGroupResolver(client),
+		client: client,
+	}
+}
+
+// Plan compares the policy against the live access rights of the server.
+// It fetches the access rights of every project of the policy, but changes nothing.
+func (s *Syncer) Plan(policy *Policy) (*Plan, error) {
+	projects := make([]string, 0, len(policy.Projects))
+	for name := range policy.Projects {
+		projects = append(projects, name)
+	}
+	sort.Strings(projects)
+
+	plan := new(Plan)
+	for _, name := range projects {
+		desired, names, err := s.resolve(policy.Projects[name])
+		if err != nil {
+			return nil, fmt.Errorf("project %s: %v", name, err)
+		}
+
+		live, _, err := s.client.Projects.GetAccess(name)
+		if err != nil {
+			return nil, fmt.Errorf("project %s: %v", name, err)
+		}
+		for uuid, group := range live.Groups {
+			if _, ok := names[uuid]; !ok && group.Name != "" {
+				names[uuid] = group.Name
+			}
+		}
+
+		plan.Projects = append(plan.Projects, diffProject(name, live, desired, names))
+	}
+
+	return plan, nil
+}
+
+// resolve resolves the groups of a project policy.
+// It returns the desired permissions by ref and permission name and the names of all groups by UUID.
+func (s *Syncer) resolve(project ProjectPolicy) (map[string]map[string]desiredPermission, map[string]string, error) {
+	names := map[string]string{}
+	desired := map[string]map[string]desiredPermission{}
+
+	for ref, section := range project.Sections {
+		permissions := map[string]desiredPermission{}
+		for name, permission := range section.Permissions {
+			d := desiredPermission{
+				label:     permission.Label,
+				exclusive: permission.Exclusive,
+				rules:     map[string]gerrit.PermissionRuleInfo{},
+			}
+			for _, rule := range permission.Rules {
+				uuid, groupName, err := s.Groups.ResolveGroup(rule.Group)
+				if err != nil {
+					return nil, nil, err
+				}
+				if _, ok := d.rules[uuid]; ok {
+					return nil, nil, fmt.Errorf("%s %s: group %q has more than one rule", ref, name, groupName)
+				}
+				names[uuid] = groupName
+				d.rules[uuid] = normalizeRule(gerrit.PermissionRuleInfo{
+					Action: rule.Action,
+					Force:  rule.Force,
+					Min:    rule.Min,
+					Max:    rule.Max,
+				})
+			}
+			permissions[name] = d
+		}
+		desired[ref] = permissions
+	}
+
+	return desired, names, nil
+}
+
+// Apply applies the plan to the server.
+// Projects without changes are skipped.
+//
+// Before a project is changed, its access rights are checked to still be at the revision of the plan.
+// If they changed in the meantime, Apply stops with an error and the plan has to be computed again.
+// Apply also stops at the first project that fails;
+// the results of the projects applied so far are returned together with the error.
+func (s *Syncer) Apply(plan *Plan, mode ApplyMode) ([]ApplyResult, error) {
+	message := s.Message
+	if message == "" {
+		message = DefaultMessage
+	}
+
+	var results []ApplyResult
+	for _, project := range plan.Projects {
+		if len(project.Changes) == 0 {
+			continue
+		}
+
+		current, _, err := s.client.Projects.GetAccess(project.Project)
+		if err != nil {
+			return results, fmt.Errorf("project %s: %v", project.Project, err)
+		}
+		if current.Revision != project.Revision {
+			return results, fmt.Errorf("project %s: access rights changed since the plan was computed (revision %s, planned against %s)", project.Project, current.Revision, project.Revision)
+		}
+
+		input := project.Input
+		input.Message = message
+
+		result := ApplyResult{Project: project.Project}
+		switch mode {
+		case ApplyDirect:
+			result.Access, _, err = s.client.Projects.SetAccess(project.Project, &input)
+		case ApplyReview:
+			result.Change, _, err = s.client.Projects.CreateAccessChange(project.Project, &input)
+		default:
+			err = fmt.Errorf("unknown apply mode %d", mode)
+		}
+		if err != nil {
+			return results, fmt.Errorf("project %s: %v", project.Project, err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
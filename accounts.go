@@ -12,13 +12,93 @@ type AccountsService struct {
 }
 
 // AccountInfo entity contains information about an account.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#account-info
 type AccountInfo struct {
-	AccountID int    `json:"_account_id"`
-	Name      string `json:"name,omitempty"`
-	Email     string `json:"email,omitempty"`
-	Username  string `json:"username,omitempty"`
+	AccountID   int    `json:"_account_id"`
+	Name        string `json:"name,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	Email       string `json:"email,omitempty"`
+	// SecondaryEmails are only set if the ALL_EMAILS option was requested and the caller may see them.
+	SecondaryEmails []string     `json:"secondary_emails,omitempty"`
+	Username        string       `json:"username,omitempty"`
+	Avatars         []AvatarInfo `json:"avatars,omitempty"`
+	Status          string       `json:"status,omitempty"`
+	Inactive        bool         `json:"inactive,omitempty"`
+	Tags            []string     `json:"tags,omitempty"`
+	// MoreAccounts is set on the last account of a query result if the result was limited and more accounts exist.
+	MoreAccounts bool `json:"_more_accounts,omitempty"`
+}
+
+// AvatarInfo entity contains information about an avatar image of an account.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#avatar-info
+type AvatarInfo struct {
+	URL    string `json:"url"`
+	Height int    `json:"height"`
+	Width  int    `json:"width,omitempty"`
+}
+
+// Additional fields of QueryAccountOptions.
+const (
+	// AccountOptionDetails includes the full name, preferred email, username and avatars.
+	AccountOptionDetails = "DETAILS"
+	// AccountOptionAllEmails includes all registered emails as secondary_emails.
+	AccountOptionAllEmails = "ALL_EMAILS"
+)
+
+// AccountQuery is an account search expression, e.g. "is:active email:example.com",
+// for QueryAccountOptions.Query.
+// Account queries support other operators than change queries, so they have their own type.
+// It can be written by hand or composed with AccountQueryOperator and AccountQueryAnd,
+// which quote and group like the ChangeQuery helpers.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/user-search-accounts.html
+type AccountQuery string
+
+// Common account queries.
+const (
+	AccountQueryIsActive   AccountQuery = "is:active"
+	AccountQueryIsInactive AccountQuery = "is:inactive"
+)
+
+// AccountQueryOperator returns the account query operator:value, e.g. AccountQueryOperator("email", "example.com").
+// The value is quoted if it contains spaces, quotes or parentheses.
+func AccountQueryOperator(operator, value string) AccountQuery {
+	return AccountQuery(QueryOperator(operator, value))
+}
+
+// AccountQueryAnd returns an account query that matches if all queries match.
+// Empty queries are skipped.
+func AccountQueryAnd(queries ...AccountQuery) AccountQuery {
+	changeQueries := make([]ChangeQuery, len(queries))
+	for i, q := range queries {
+		changeQueries[i] = ChangeQuery(q)
+	}
+	return AccountQuery(joinQueries(" ", changeQueries))
+}
+
+// String returns the query as string.
+func (q AccountQuery) String() string {
+	return string(q)
+}
+
+// QueryAccountOptions specifies the parameters for AccountsService.QueryAccounts.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#query-account
+type QueryAccountOptions struct {
+	QueryOptions
+
+	// The S or start query parameter can be supplied to skip a number of accounts from the list.
+	Start int `url:"start,omitempty"`
+
+	// AdditionalFields like AccountOptionDetails or AccountOptionAllEmails.
+	AdditionalFields []string `url:"o,omitempty"`
 }
 
+// defaultAccountPageSize is the page size of QueryAllAccounts if no limit is set.
+const defaultAccountPageSize = 100
+
 // SSHKeyInfo entity contains information about an SSH key of a user.
 type SSHKeyInfo struct {
 	Seq          int    `json:"seq"`
@@ -58,6 +138,25 @@ type GpgKeyInfo struct {
 	Fingerprint string   `json:"fingerprint,omitempty"`
 	UserIDs     []string `json:"user_ids,omitempty"`
 	Key         string   `json:"key,omitempty"`
+	// Status is the result of the server-side checks on the key: BAD, OK or TRUSTED.
+	// It is only set for the keys of the calling user.
+	Status   string   `json:"status,omitempty"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Status values of a GpgKeyInfo.
+const (
+	GpgKeyStatusBad     = "BAD"
+	GpgKeyStatusOK      = "OK"
+	GpgKeyStatusTrusted = "TRUSTED"
+)
+
+// PushCertificateInfo entity contains information about a signed push certificate.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#push-certificate-info
+type PushCertificateInfo struct {
+	Certificate string     `json:"certificate"`
+	Key         GpgKeyInfo `json:"key"`
 }
 
 // EmailInput entity contains information for registering a new email address.
@@ -204,6 +303,55 @@ type PreferencesInput struct {
 	URLAliases                string            `json:"url_aliases,omitempty"`
 }
 
+// AccountExternalIDInfo entity contains information for an external id of an account.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#account-external-id-info
+type AccountExternalIDInfo struct {
+	Identity     string `json:"identity"`
+	EmailAddress string `json:"email_address,omitempty"`
+	Trusted      bool   `json:"trusted,omitempty"`
+	CanDelete    bool   `json:"can_delete,omitempty"`
+}
+
+// ProjectWatchInfo entity contains information about a project watch for a user.
+// Filter is a change query, e.g. "branch:master"; an empty filter watches all changes of the project.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#project-watch-info
+type ProjectWatchInfo struct {
+	Project                string `json:"project"`
+	Filter                 string `json:"filter,omitempty"`
+	NotifyNewChanges       bool   `json:"notify_new_changes,omitempty"`
+	NotifyNewPatchSets     bool   `json:"notify_new_patch_sets,omitempty"`
+	NotifyAllComments      bool   `json:"notify_all_comments,omitempty"`
+	NotifySubmittedChanges bool   `json:"notify_submitted_changes,omitempty"`
+	NotifyAbandonedChanges bool   `json:"notify_abandoned_changes,omitempty"`
+}
+
+// ContributorAgreementInfo entity contains information about a contributor agreement.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#contributor-agreement-info
+type ContributorAgreementInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+	// AutoVerifyGroup is only set if the caller is administrator.
+	AutoVerifyGroup *GroupInfo `json:"auto_verify_group,omitempty"`
+}
+
+// ContributorAgreementInput entity contains information about a new contributor agreement.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#contributor-agreement-input
+type ContributorAgreementInput struct {
+	Name string `json:"name"`
+}
+
+// AccountStatusInput entity contains information for setting a status for an account.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#account-status-input
+type AccountStatusInput struct {
+	Status string `json:"status,omitempty"`
+}
+
 // CapabilityOptions specifies the parameters to filter for capabilities.
 //
 // Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#list-account-capabilities
@@ -506,6 +654,72 @@ func (s *AccountsService) GetStarredChanges(accountID string) (*[]ChangeInfo, *R
 	return v, resp, err
 }
 
+// QueryAccounts queries accounts visible to the caller.
+// The query string must be provided by opt.Query, e.g. "is:active email:example.com".
+// Only one query is supported per request.
+// Accounts can't be searched by group membership, use GroupsService.ListGroupMembers instead.
+//
+// If the result was limited by opt.Limit and more accounts exist,
+// MoreAccounts is set on the last account of the result.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#query-account
+func (s *AccountsService) QueryAccounts(opt *QueryAccountOptions) (*[]AccountInfo, *Response, error) {
+	if opt == nil || len(opt.Query) != 1 {
+		return nil, nil, fmt.Errorf("exactly one account query is required")
+	}
+
+	u, err := addOptions("accounts/", opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new([]AccountInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// QueryAllAccounts returns all accounts matching the query by paging through QueryAccounts.
+// opt.Limit is used as page size (100 if not set) and opt.Start as offset of the first page.
+// MoreAccounts is not set on the returned accounts.
+//
+// The returned Response is the one of the last request.
+func (s *AccountsService) QueryAllAccounts(opt *QueryAccountOptions) (*[]AccountInfo, *Response, error) {
+	pageOpt := QueryAccountOptions{}
+	if opt != nil {
+		pageOpt = *opt
+	}
+	if pageOpt.Limit <= 0 {
+		pageOpt.Limit = defaultAccountPageSize
+	}
+
+	accounts := []AccountInfo{}
+	for {
+		page, resp, err := s.QueryAccounts(&pageOpt)
+		if err != nil {
+			return nil, resp, err
+		}
+
+		more := len(*page) > 0 && (*page)[len(*page)-1].MoreAccounts
+		for _, a := range *page {
+			a.MoreAccounts = false
+			accounts = append(accounts, a)
+		}
+		if !more {
+			return &accounts, resp, nil
+		}
+		pageOpt.Start += len(*page)
+	}
+}
+
 // SuggestAccount suggests users for a given query q and result limit n.
 // If result limit is not passed, then the default 10 is used.
 // Returns a list of matching AccountInfo entities.
@@ -760,6 +974,7 @@ func (s *AccountsService) GetAvatarChangeURL(accountID string) (string, *Respons
 // Each new GPG key is provided in ASCII armored format, and must contain a self-signed certification matching a registered email or other identity of the user.
 //
 // As a response, the modified GPG keys are returned as a map of GpgKeyInfo entities, keyed by ID. Deleted keys are represented by an empty object.
+// To check the keys locally before they are uploaded, use AddGPGKey.
 //
 // Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#add-delete-gpg-keys
 func (s *AccountsService) AddGPGKeys(accountID string, input *GpgKeysInput) (*map[string]GpgKeyInfo, *Response, error) {
@@ -860,8 +1075,163 @@ func (s *AccountsService) UnstarChange(accountID, changeID string) (*Response, e
 	return s.client.DeleteRequest(u, nil)
 }
 
+// ListExternalIDs retrieves the external ids of a user account.
+// Only external ids belonging to the caller may be requested, unless the caller has the "Modify Account" capability.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#get-account-external-ids
+func (s *AccountsService) ListExternalIDs(accountID string) (*[]AccountExternalIDInfo, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/external.ids", accountID)
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new([]AccountExternalIDInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// DeleteExternalIDs deletes a list of external ids for a user account.
+// The list of external ids to delete is the list of identities, e.g. "username:john" or "mailto:john@example.com".
+// Only external ids belonging to the caller may be deleted, unless the caller has the "Modify Account" capability.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#delete-account-external-ids
+func (s *AccountsService) DeleteExternalIDs(accountID string, identities []string) (*Response, error) {
+	u := fmt.Sprintf("accounts/%s/external.ids:delete", accountID)
+	return s.client.Call("POST", u, identities, nil)
+}
+
+// GetWatchedProjects retrieves all projects a user is watching.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#get-watched-projects
+func (s *AccountsService) GetWatchedProjects(accountID string) (*[]ProjectWatchInfo, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/watched.projects", accountID)
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new([]ProjectWatchInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// SetWatchedProjects adds new projects to watch or updates existing watches.
+// Watches are identified by project and filter.
+// The response contains all projects the user is watching.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#set-watched-projects
+func (s *AccountsService) SetWatchedProjects(accountID string, input []ProjectWatchInfo) (*[]ProjectWatchInfo, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/watched.projects", accountID)
+
+	req, err := s.client.NewRequest("POST", u, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new([]ProjectWatchInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// DeleteWatchedProjects deletes some or all projects a user is watching.
+// Only project and filter of the watches are used to identify the watches to delete.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#delete-watched-projects
+func (s *AccountsService) DeleteWatchedProjects(accountID string, input []ProjectWatchInfo) (*Response, error) {
+	u := fmt.Sprintf("accounts/%s/watched.projects:delete", accountID)
+	return s.client.Call("POST", u, input, nil)
+}
+
+// ListContributorAgreements lists the contributor agreements a user has signed.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#list-contributor-agreements
+func (s *AccountsService) ListContributorAgreements(accountID string) (*[]ContributorAgreementInfo, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/agreements", accountID)
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new([]ContributorAgreementInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// SignContributorAgreement signs a contributor agreement.
+// The contributor agreement must be provided in the request body as a ContributorAgreementInput.
+// The response is the name of the signed agreement.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#sign-contributor-agreement
+func (s *AccountsService) SignContributorAgreement(accountID string, input *ContributorAgreementInput) (*string, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/agreements", accountID)
+
+	req, err := s.client.NewRequest("PUT", u, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(string)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// GetAccountStatus retrieves the status of an account.
+// If the account does not have a status an empty string is returned.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#get-account-status
+func (s *AccountsService) GetAccountStatus(accountID string) (string, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/status", accountID)
+	return getStringResponseWithoutOptions(s.client, u)
+}
+
+// SetAccountStatus sets the status of an account.
+// The new account status must be provided in the request body inside an AccountStatusInput entity.
+// An empty status deletes the status.
+// The response is the new status.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#set-account-status
+func (s *AccountsService) SetAccountStatus(accountID string, input *AccountStatusInput) (*string, *Response, error) {
+	u := fmt.Sprintf("accounts/%s/status", accountID)
+
+	req, err := s.client.NewRequest("PUT", u, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(string)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
 /*
 Missing Account Endpoints:
-	Add SSH Key
 	Get Avatar
 */
@@ -0,0 +1,331 @@
+package gerrit
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// GPGPublicKey is a GPG public key that was parsed and validated locally.
+type GPGPublicKey struct {
+	// ID is the 8-char hex key ID, as used by Gerrit, e.g. "AFC8A49B".
+	ID string
+	// Fingerprint is the fingerprint formatted like Gerrit does, e.g. "0192 723D 42D1 0C5B 32A6  E1E0 9350 9E4B AFC8 A49B".
+	Fingerprint string
+	// UserIDs are the self-signed user IDs of the key, e.g. "John Doe <john.doe@example.com>", sorted.
+	UserIDs []string
+	// Emails are the email addresses of the user IDs, sorted.
+	Emails []string
+	// Armored is the public key in ASCII armored format.
+	Armored string
+
+	entity *openpgp.Entity
+}
+
+// PushCertificate is a parsed signed-push certificate.
+//
+// Git docs: https://git-scm.com/docs/pack-protocol#_push_certificate
+type PushCertificate struct {
+	Version string
+	// Pusher is the identity of the pusher, e.g. "John Doe <john.doe@example.com> 1433954361 -0700".
+	Pusher string
+	// PusherEmail is the email address of Pusher.
+	PusherEmail string
+	Pushee      string
+	Nonce       string
+	Commands    []PushCommand
+	// Payload is the signed part of the certificate.
+	Payload string
+	// Signature is the ASCII armored detached signature of Payload.
+	Signature string
+}
+
+// PushCommand is a single ref update of a push certificate.
+type PushCommand struct {
+	OldID string
+	NewID string
+	Ref   string
+}
+
+// PushCertificateSigner reports who signed the push of a revision.
+type PushCertificateSigner struct {
+	Certificate *PushCertificate
+	// Key is the key that made the signature.
+	Key *GPGPublicKey
+	// KeyStatus is the status of the key as checked by the server, e.g. "TRUSTED". See GpgKeyInfo.
+	KeyStatus string
+	// Account is the account of the pusher.
+	Account *AccountInfo
+}
+
+// ParseGPGPublicKeys parses one or more ASCII armored GPG public keys.
+// The keys can be in one armored block or in several blocks one after another.
+// Every key must have at least one valid self-signed user ID. Revoked keys are rejected.
+func ParseGPGPublicKeys(armored string) ([]GPGPublicKey, error) {
+	const header = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+
+	blocks := strings.Split(armored, header)[1:]
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("invalid GPG public key: no %s found", header)
+	}
+
+	var keys []GPGPublicKey
+	for _, block := range blocks {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(header + block))
+		if err != nil {
+			return nil, fmt.Errorf("invalid GPG public key: %v", err)
+		}
+		for _, e := range entities {
+			key, err := newGPGPublicKey(e)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+// newGPGPublicKey validates e and returns it as GPGPublicKey.
+func newGPGPublicKey(e *openpgp.Entity) (*GPGPublicKey, error) {
+	key := &GPGPublicKey{
+		ID:          e.PrimaryKey.KeyIdShortString(),
+		Fingerprint: formatGPGFingerprint(e.PrimaryKey.Fingerprint[:]),
+		entity:      e,
+	}
+	if len(e.Revocations) > 0 {
+		return nil, fmt.Errorf("GPG key %s is revoked", key.ID)
+	}
+
+	for name, identity := range e.Identities {
+		if identity.SelfSignature == nil {
+			continue
+		}
+		key.UserIDs = append(key.UserIDs, name)
+		if identity.UserId.Email != "" {
+			key.Emails = append(key.Emails, identity.UserId.Email)
+		}
+	}
+	if len(key.UserIDs) == 0 {
+		return nil, fmt.Errorf("GPG key %s has no self-signed user ID", key.ID)
+	}
+	sort.Strings(key.UserIDs)
+	sort.Strings(key.Emails)
+
+	buf := new(bytes.Buffer)
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.Serialize(w); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	key.Armored = buf.String() + "\n"
+
+	return key, nil
+}
+
+// formatGPGFingerprint formats a fingerprint in groups of four hex digits,
+// with a double space in the middle.
+func formatGPGFingerprint(fingerprint []byte) string {
+	s := strings.ToUpper(hex.EncodeToString(fingerprint))
+	var b strings.Builder
+	for i := 0; i < len(s); i += 4 {
+		if i == len(s)/2 {
+			b.WriteString(" ")
+		}
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(s[i : i+4])
+	}
+	return b.String()
+}
+
+// AddGPGKey adds the ASCII armored GPG public keys to an account.
+// The keys are parsed locally first. Each key must have a self-signed user ID with an email address
+// that is registered for the account, otherwise no key is uploaded.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#add-delete-gpg-keys
+func (s *AccountsService) AddGPGKey(accountID, armored string) (*map[string]GpgKeyInfo, *Response, error) {
+	keys, err := ParseGPGPublicKeys(armored)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	emails, resp, err := s.ListAccountEmails(accountID)
+	if err != nil {
+		return nil, resp, err
+	}
+	registered := make(map[string]bool, len(*emails))
+	for _, email := range *emails {
+		registered[strings.ToLower(email.Email)] = true
+	}
+
+	input := &GpgKeysInput{}
+	for _, key := range keys {
+		if !key.hasEmail(registered) {
+			return nil, resp, fmt.Errorf("GPG key %s: none of the user IDs %s has an email registered for account %s",
+				key.ID, strings.Join(key.UserIDs, ", "), accountID)
+		}
+		input.Add = append(input.Add, key.Armored)
+	}
+
+	return s.AddGPGKeys(accountID, input)
+}
+
+// hasEmail reports if any email of the key is in emails. The keys of emails must be lower case.
+func (key *GPGPublicKey) hasEmail(emails map[string]bool) bool {
+	for _, email := range key.Emails {
+		if emails[strings.ToLower(email)] {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePushCertificate parses a signed-push certificate as returned in PushCertificateInfo.
+func ParsePushCertificate(certificate string) (*PushCertificate, error) {
+	i := strings.Index(certificate, "-----BEGIN PGP SIGNATURE-----")
+	if i < 0 {
+		return nil, fmt.Errorf("push certificate has no signature")
+	}
+	cert := &PushCertificate{Payload: certificate[:i], Signature: certificate[i:]}
+
+	parts := strings.SplitN(cert.Payload, "\n\n", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("push certificate has no commands")
+	}
+	for _, line := range strings.Split(parts[0], "\n") {
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		value := fields[1]
+		switch fields[0] {
+		case "certificate":
+			cert.Version = strings.TrimPrefix(value, "version ")
+		case "pusher":
+			cert.Pusher = value
+		case "pushee":
+			cert.Pushee = value
+		case "nonce":
+			cert.Nonce = value
+		}
+	}
+	if cert.Pusher == "" {
+		return nil, fmt.Errorf("push certificate has no pusher")
+	}
+	if start, end := strings.Index(cert.Pusher, "<"), strings.Index(cert.Pusher, ">"); start >= 0 && end > start {
+		if addr, err := mail.ParseAddress(cert.Pusher[:end+1]); err == nil {
+			cert.PusherEmail = addr.Address
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(parts[1]), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid push certificate command: %q", line)
+		}
+		cert.Commands = append(cert.Commands, PushCommand{OldID: fields[0], NewID: fields[1], Ref: fields[2]})
+	}
+	return cert, nil
+}
+
+// Verify checks the signature of the certificate against the ASCII armored public key
+// and returns the key that made the signature.
+func (cert *PushCertificate) Verify(armoredKey string) (*GPGPublicKey, error) {
+	keys, err := ParseGPGPublicKeys(armoredKey)
+	if err != nil {
+		return nil, err
+	}
+	keyring := make(openpgp.EntityList, 0, len(keys))
+	for _, key := range keys {
+		keyring = append(keyring, key.entity)
+	}
+
+	signer, err := openpgp.CheckArmoredDetachedSignature(keyring, strings.NewReader(cert.Payload), strings.NewReader(cert.Signature))
+	if err != nil {
+		return nil, fmt.Errorf("invalid push certificate signature: %v", err)
+	}
+	for i := range keys {
+		if keys[i].entity == signer {
+			return &keys[i], nil
+		}
+	}
+	return nil, fmt.Errorf("push certificate is signed by an unknown key")
+}
+
+// GetPushCertificateSigner reports who signed the push of a revision of a change.
+// The signature of the push certificate is verified against the key stored on the server,
+// and the pusher must be one of the user IDs of that key.
+// The account of the pusher is looked up by email.
+//
+// An error is returned if the revision was not pushed with a signed push certificate.
+//
+// The returned Response is the one of the last request.
+func (s *ChangesService) GetPushCertificateSigner(changeID, revisionID string) (*PushCertificateSigner, *Response, error) {
+	change, resp, err := s.GetChange(changeID, &ChangeOptions{AdditionalFields: []string{"ALL_REVISIONS", "PUSH_CERTIFICATES"}})
+	if err != nil {
+		return nil, resp, err
+	}
+
+	revision, ok := findRevision(change, revisionID)
+	if !ok {
+		return nil, resp, fmt.Errorf("change %s has no revision %s", changeID, revisionID)
+	}
+	if revision.PushCertificate == nil {
+		return nil, resp, fmt.Errorf("revision %s of change %s was not pushed with a signed push certificate", revisionID, changeID)
+	}
+
+	cert, err := ParsePushCertificate(revision.PushCertificate.Certificate)
+	if err != nil {
+		return nil, resp, err
+	}
+	key, err := cert.Verify(revision.PushCertificate.Key.Key)
+	if err != nil {
+		return nil, resp, err
+	}
+	if !key.hasEmail(map[string]bool{strings.ToLower(cert.PusherEmail): true}) {
+		return nil, resp, fmt.Errorf("pusher %s doesn't match the user IDs %s of GPG key %s",
+			cert.Pusher, strings.Join(key.UserIDs, ", "), key.ID)
+	}
+
+	account, resp, err := s.client.Accounts.GetAccount(cert.PusherEmail)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return &PushCertificateSigner{
+		Certificate: cert,
+		Key:         key,
+		KeyStatus:   revision.PushCertificate.Key.Status,
+		Account:     account,
+	}, resp, nil
+}
+
+// findRevision returns the revision of change that is identified by revisionID:
+// "current", the commit ID or the patch set number.
+func findRevision(change *ChangeInfo, revisionID string) (RevisionInfo, bool) {
+	if revisionID == "current" {
+		revisionID = change.CurrentRevision
+	}
+	if revision, ok := change.Revisions[revisionID]; ok {
+		return revision, true
+	}
+	for _, revision := range change.Revisions {
+		if fmt.Sprint(revision.Number) == revisionID {
+			return revision, true
+		}
+	}
+	return RevisionInfo{}, false
+}
@@ -0,0 +1,119 @@
+package gerrit
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHPublicKey is an SSH public key in authorized_keys format that was parsed and validated locally.
+type SSHPublicKey struct {
+	// Algorithm is the key type, e.g. "ssh-ed25519" or "ssh-rsa".
+	Algorithm string
+	Comment   string
+	// Line is the key as single authorized_keys line, without options: "<algorithm> <base64 key> [comment]".
+	Line string
+	// SHA256Fingerprint is the fingerprint as printed by OpenSSH, e.g. "SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8".
+	SHA256Fingerprint string
+	// MD5Fingerprint is the legacy fingerprint, e.g. "c1:b1:30:29:d7:b8:de:6c:97:77:10:d7:46:41:63:87".
+	MD5Fingerprint string
+}
+
+// ParseSSHPublicKey parses and validates a single SSH public key in authorized_keys format,
+// e.g. the content of ~/.ssh/id_ed25519.pub.
+// Keys with options (e.g. from="...") are rejected, as Gerrit doesn't support them.
+func ParseSSHPublicKey(authorizedKey string) (*SSHPublicKey, error) {
+	pub, comment, options, rest, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH public key: %v", err)
+	}
+	if len(options) > 0 {
+		return nil, fmt.Errorf("invalid SSH public key: options %s are not supported", strings.Join(options, ","))
+	}
+	if strings.TrimSpace(string(rest)) != "" {
+		return nil, fmt.Errorf("invalid SSH public key: more than one key given")
+	}
+
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+	if comment != "" {
+		line += " " + comment
+	}
+	return &SSHPublicKey{
+		Algorithm:         pub.Type(),
+		Comment:           comment,
+		Line:              line,
+		SHA256Fingerprint: ssh.FingerprintSHA256(pub),
+		MD5Fingerprint:    ssh.FingerprintLegacyMD5(pub),
+	}, nil
+}
+
+// AddSSHKey adds an SSH key for a user.
+// The key must be in authorized_keys format. It is validated locally before it is uploaded as text/plain.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-accounts.html#add-ssh-key
+func (s *AccountsService) AddSSHKey(accountID, authorizedKey string) (*SSHKeyInfo, *Response, error) {
+	key, err := ParseSSHPublicKey(authorizedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u := fmt.Sprintf("accounts/%s/sshkeys", accountID)
+
+	req, err := s.client.NewRequest("POST", u, TextBody(key.Line))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(SSHKeyInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// RotateSSHKey replaces the SSH key with the sequence number oldSeq by a new key.
+// The new key is added first and read back from the server.
+// The old key is only deleted if the server reports the new key as valid with the same SHA256 fingerprint,
+// so the user never ends up without a working key.
+// If the old key was deleted already, the rotation still succeeds.
+//
+// The returned Response is the one of the last request.
+func (s *AccountsService) RotateSSHKey(accountID string, oldSeq int, authorizedKey string) (*SSHKeyInfo, *Response, error) {
+	key, err := ParseSSHPublicKey(authorizedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	added, resp, err := s.AddSSHKey(accountID, key.Line)
+	if err != nil {
+		return nil, resp, err
+	}
+	if added.Seq == oldSeq {
+		return nil, resp, fmt.Errorf("new SSH key has the same sequence number %d as the old key", oldSeq)
+	}
+
+	stored, resp, err := s.GetSSHKey(accountID, strconv.Itoa(added.Seq))
+	if err != nil {
+		return nil, resp, fmt.Errorf("verify new SSH key %d: %v", added.Seq, err)
+	}
+	storedKey, err := ParseSSHPublicKey(stored.SSHPublicKey)
+	if err != nil {
+		return nil, resp, fmt.Errorf("verify new SSH key %d: %v", added.Seq, err)
+	}
+	if !stored.Valid || storedKey.SHA256Fingerprint != key.SHA256Fingerprint {
+		return nil, resp, fmt.Errorf("verify new SSH key %d: server reports key %s (valid: %t), want %s",
+			added.Seq, storedKey.SHA256Fingerprint, stored.Valid, key.SHA256Fingerprint)
+	}
+
+	resp, err = s.DeleteSSHKey(accountID, strconv.Itoa(oldSeq))
+	if err != nil && (resp == nil || resp.Response == nil || resp.StatusCode != http.StatusNotFound) {
+		return stored, resp, fmt.Errorf("delete old SSH key %d: %v", oldSeq, err)
+	}
+
+	return stored, resp, nil
+}
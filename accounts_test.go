@@ -0,0 +1,512 @@
+package gerrit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/go-gerrit"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestAccountsService_QueryAccounts(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testFormValues(t, r, testValues{
+			"q": "is:active email:example.com",
+			"n": "2",
+			"o": "DETAILS",
+		})
+		fmt.Fprint(w, `)]}'`+"\n"+`[{"_account_id":1000096,"name":"John Doe","display_name":"John","email":"john.doe@example.com","secondary_emails":["jd@example.com"],"username":"john","avatars":[{"url":"https://example.com/john.png","height":32}],"status":"OOO"},{"_account_id":1000097,"name":"Jane Roe","inactive":true,"_more_accounts":true}]`)
+	})
+
+	opt := &gerrit.QueryAccountOptions{AdditionalFields: []string{gerrit.AccountOptionDetails}}
+	opt.Query = []string{gerrit.AccountQueryAnd(gerrit.AccountQueryIsActive, gerrit.AccountQueryOperator("email", "example.com")).String()}
+	opt.Limit = 2
+	accounts, _, err := testClient.Accounts.QueryAccounts(opt)
+	if err != nil {
+		t.Fatalf("Accounts.QueryAccounts returned error: %v", err)
+	}
+
+	want := &[]gerrit.AccountInfo{
+		{
+			AccountID:       1000096,
+			Name:            "John Doe",
+			DisplayName:     "John",
+			Email:           "john.doe@example.com",
+			SecondaryEmails: []string{"jd@example.com"},
+			Username:        "john",
+			Avatars:         []gerrit.AvatarInfo{{URL: "https://example.com/john.png", Height: 32}},
+			Status:          "OOO",
+		},
+		{AccountID: 1000097, Name: "Jane Roe", Inactive: true, MoreAccounts: true},
+	}
+	if !reflect.DeepEqual(accounts, want) {
+		t.Errorf("Accounts.QueryAccounts returned %+v, want %+v", accounts, want)
+	}
+}
+
+func TestAccountsService_QueryAccounts_RequiresQuery(t *testing.T) {
+	setup()
+	defer teardown()
+
+	if _, _, err := testClient.Accounts.QueryAccounts(nil); err == nil {
+		t.Error("Accounts.QueryAccounts returned no error without query")
+	}
+}
+
+func TestAccountsService_QueryAllAccounts(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var starts []string
+	testMux.HandleFunc("/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if q := r.FormValue("q"); q != "email:example.com" {
+			t.Errorf("Request parameter q=%q, want email:example.com", q)
+		}
+
+		start := r.FormValue("start")
+		starts = append(starts, start)
+		switch start {
+		case "":
+			fmt.Fprint(w, `)]}'`+"\n"+`[{"_account_id":1},{"_account_id":2,"_more_accounts":true}]`)
+		case "2":
+			fmt.Fprint(w, `)]}'`+"\n"+`[{"_account_id":3}]`)
+		default:
+			t.Errorf("unexpected start %q", start)
+		}
+	})
+
+	opt := &gerrit.QueryAccountOptions{}
+	opt.Query = []string{"email:example.com"}
+	opt.Limit = 2
+	accounts, _, err := testClient.Accounts.QueryAllAccounts(opt)
+	if err != nil {
+		t.Fatalf("Accounts.QueryAllAccounts returned error: %v", err)
+	}
+
+	want := &[]gerrit.AccountInfo{{AccountID: 1}, {AccountID: 2}, {AccountID: 3}}
+	if !reflect.DeepEqual(accounts, want) {
+		t.Errorf("Accounts.QueryAllAccounts returned %+v, want %+v", accounts, want)
+	}
+	if want := []string{"", "2"}; !reflect.DeepEqual(starts, want) {
+		t.Errorf("Requested pages %v, want %v", starts, want)
+	}
+}
+
+func TestAccountsService_ListExternalIDs(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/accounts/self/external.ids", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `)]}'`+"\n"+`[{"identity":"username:john","email_address":"john.doe@example.com","trusted":true},{"identity":"mailto:john.doe@example.com","email_address":"john.doe@example.com","trusted":true,"can_delete":true}]`)
+	})
+
+	ids, _, err := testClient.Accounts.ListExternalIDs("self")
+	if err != nil {
+		t.Fatalf("Accounts.ListExternalIDs returned error: %v", err)
+	}
+
+	want := &[]gerrit.AccountExternalIDInfo{
+		{Identity: "username:john", EmailAddress: "john.doe@example.com", Trusted: true},
+		{Identity: "mailto:john.doe@example.com", EmailAddress: "john.doe@example.com", Trusted: true, CanDelete: true},
+	}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("Accounts.ListExternalIDs returned %+v, want %+v", ids, want)
+	}
+}
+
+func TestAccountsService_DeleteExternalIDs(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/accounts/self/external.ids:delete", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		var v []string
+		json.NewDecoder(r.Body).Decode(&v)
+		if want := []string{"mailto:john.doe@example.com"}; !reflect.DeepEqual(v, want) {
+			t.Errorf("Request body = %v, want %v", v, want)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	_, err := testClient.Accounts.DeleteExternalIDs("self", []string{"mailto:john.doe@example.com"})
+	if err != nil {
+		t.Errorf("Accounts.DeleteExternalIDs returned error: %v", err)
+	}
+}
+
+func TestAccountsService_SetWatchedProjects(t *testing.T) {
+	setup()
+	defer teardown()
+
+	input := []gerrit.ProjectWatchInfo{
+		{Project: "go", Filter: "branch:master", NotifyNewChanges: true, NotifySubmittedChanges: true},
+	}
+
+	testMux.HandleFunc("/accounts/self/watched.projects", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		var v []gerrit.ProjectWatchInfo
+		json.NewDecoder(r.Body).Decode(&v)
+		if !reflect.DeepEqual(v, input) {
+			t.Errorf("Request body = %+v, want %+v", v, input)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`[{"project":"go","filter":"branch:master","notify_new_changes":true,"notify_submitted_changes":true},{"project":"tools","notify_all_comments":true}]`)
+	})
+
+	watches, _, err := testClient.Accounts.SetWatchedProjects("self", input)
+	if err != nil {
+		t.Fatalf("Accounts.SetWatchedProjects returned error: %v", err)
+	}
+
+	want := &[]gerrit.ProjectWatchInfo{
+		input[0],
+		{Project: "tools", NotifyAllComments: true},
+	}
+	if !reflect.DeepEqual(watches, want) {
+		t.Errorf("Accounts.SetWatchedProjects returned %+v, want %+v", watches, want)
+	}
+}
+
+func TestAccountsService_DeleteWatchedProjects(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/accounts/self/watched.projects:delete", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		b, _ := ioutil.ReadAll(r.Body)
+		if got, want := string(b), `[{"project":"go","filter":"branch:master"}]`+"\n"; got != want {
+			t.Errorf("Request body = %s, want %s", got, want)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	_, err := testClient.Accounts.DeleteWatchedProjects("self", []gerrit.ProjectWatchInfo{{Project: "go", Filter: "branch:master"}})
+	if err != nil {
+		t.Errorf("Accounts.DeleteWatchedProjects returned error: %v", err)
+	}
+}
+
+func TestAccountsService_ContributorAgreements(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/accounts/self/agreements", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `)]}'`+"\n"+`[{"name":"Individual","description":"If you are going to be contributing code on your own, this is the one you want.","url":"static/cla_individual.html"}]`)
+		case "PUT":
+			v := new(gerrit.ContributorAgreementInput)
+			json.NewDecoder(r.Body).Decode(v)
+			if v.Name != "Individual" {
+				t.Errorf("Request body = %+v", v)
+			}
+			fmt.Fprint(w, `)]}'`+"\n"+`"Individual"`)
+		default:
+			t.Errorf("unexpected method %s", r.Method)
+		}
+	})
+
+	agreements, _, err := testClient.Accounts.ListContributorAgreements("self")
+	if err != nil {
+		t.Fatalf("Accounts.ListContributorAgreements returned error: %v", err)
+	}
+	if len(*agreements) != 1 || (*agreements)[0].URL != "static/cla_individual.html" {
+		t.Errorf("Accounts.ListContributorAgreements returned %+v", agreements)
+	}
+
+	name, _, err := testClient.Accounts.SignContributorAgreement("self", &gerrit.ContributorAgreementInput{Name: "Individual"})
+	if err != nil {
+		t.Fatalf("Accounts.SignContributorAgreement returned error: %v", err)
+	}
+	if *name != "Individual" {
+		t.Errorf("Accounts.SignContributorAgreement returned %q, want Individual", *name)
+	}
+}
+
+func TestAccountsService_AccountStatus(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/accounts/self/status", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `)]}'`+"\n"+`"Available"`)
+		case "PUT":
+			v := new(gerrit.AccountStatusInput)
+			json.NewDecoder(r.Body).Decode(v)
+			fmt.Fprint(w, `)]}'`+"\n"+`"`+v.Status+`"`)
+		default:
+			t.Errorf("unexpected method %s", r.Method)
+		}
+	})
+
+	status, _, err := testClient.Accounts.GetAccountStatus("self")
+	if err != nil {
+		t.Fatalf("Accounts.GetAccountStatus returned error: %v", err)
+	}
+	if status != "Available" {
+		t.Errorf("Accounts.GetAccountStatus returned %q, want Available", status)
+	}
+
+	newStatus, _, err := testClient.Accounts.SetAccountStatus("self", &gerrit.AccountStatusInput{Status: "OOO"})
+	if err != nil {
+		t.Fatalf("Accounts.SetAccountStatus returned error: %v", err)
+	}
+	if *newStatus != "OOO" {
+		t.Errorf("Accounts.SetAccountStatus returned %q, want OOO", *newStatus)
+	}
+}
+
+// testSSHKey is an Ed25519 public key in authorized_keys format.
+const testSSHKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGtQUDZWhs8k/cZcykMkaUX7sZ0Ph5pSzEzN9S5wH9tz john@laptop"
+
+func TestParseSSHPublicKey(t *testing.T) {
+	key, err := gerrit.ParseSSHPublicKey("  " + testSSHKey + "\n")
+	if err != nil {
+		t.Fatalf("ParseSSHPublicKey returned error: %v", err)
+	}
+
+	if key.Algorithm != "ssh-ed25519" || key.Comment != "john@laptop" || key.Line != testSSHKey {
+		t.Errorf("ParseSSHPublicKey returned %+v", key)
+	}
+	if !strings.HasPrefix(key.SHA256Fingerprint, "SHA256:") {
+		t.Errorf("SHA256Fingerprint %q, want SHA256: prefix", key.SHA256Fingerprint)
+	}
+	if len(key.MD5Fingerprint) != 47 || strings.Count(key.MD5Fingerprint, ":") != 15 {
+		t.Errorf("MD5Fingerprint %q, want 16 hex pairs", key.MD5Fingerprint)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"ssh-ed25519 not-base64 john@laptop",
+		`from="10.0.0.1" ` + testSSHKey,
+		testSSHKey + "\n" + testSSHKey,
+	} {
+		if _, err := gerrit.ParseSSHPublicKey(invalid); err == nil {
+			t.Errorf("ParseSSHPublicKey(%q) returned no error", invalid)
+		}
+	}
+}
+
+func TestAccountsService_AddSSHKey(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/accounts/self/sshkeys", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		if ct := r.Header.Get("Content-Type"); ct != "text/plain" {
+			t.Errorf("Content-Type %q, want text/plain", ct)
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		if string(b) != testSSHKey {
+			t.Errorf("Request body = %q, want %q", b, testSSHKey)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `)]}'`+"\n"+`{"seq":2,"ssh_public_key":"`+testSSHKey+`","encoded_key":"AAAAC3NzaC1lZDI1NTE5AAAAIGtQUDZWhs8k/cZcykMkaUX7sZ0Ph5pSzEzN9S5wH9tz","algorithm":"ssh-ed25519","comment":"john@laptop","valid":true}`)
+	})
+
+	key, _, err := testClient.Accounts.AddSSHKey("self", testSSHKey+"\n")
+	if err != nil {
+		t.Fatalf("Accounts.AddSSHKey returned error: %v", err)
+	}
+	if key.Seq != 2 || !key.Valid {
+		t.Errorf("Accounts.AddSSHKey returned %+v", key)
+	}
+
+	if _, _, err := testClient.Accounts.AddSSHKey("self", "not a key"); err == nil {
+		t.Error("Accounts.AddSSHKey returned no error for an invalid key")
+	}
+}
+
+func TestAccountsService_RotateSSHKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		stored  string
+		deleted bool
+	}{
+		{"verified", testSSHKey, true},
+		{"mismatch", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHSc0cMHxDFMpThOgrbwF0YyEBd1wpBqZeLQRx7i4KFM other", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			testMux.HandleFunc("/accounts/self/sshkeys", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `)]}'`+"\n"+`{"seq":2,"valid":true}`)
+			})
+			testMux.HandleFunc("/accounts/self/sshkeys/2", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				fmt.Fprint(w, `)]}'`+"\n"+`{"seq":2,"ssh_public_key":"`+tt.stored+`","valid":true}`)
+			})
+			deleted := false
+			testMux.HandleFunc("/accounts/self/sshkeys/1", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "DELETE")
+				deleted = true
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			key, _, err := testClient.Accounts.RotateSSHKey("self", 1, testSSHKey)
+			if tt.deleted && (err != nil || key.Seq != 2) {
+				t.Errorf("Accounts.RotateSSHKey returned %+v, %v", key, err)
+			}
+			if !tt.deleted && err == nil {
+				t.Error("Accounts.RotateSSHKey returned no error for a mismatching key")
+			}
+			if deleted != tt.deleted {
+				t.Errorf("old key deleted = %v, want %v", deleted, tt.deleted)
+			}
+		})
+	}
+}
+
+// newTestGPGKey returns a new GPG key for the user ID and its public key in ASCII armored format.
+func newTestGPGKey(t *testing.T, name, email string) (*openpgp.Entity, string) {
+	e, err := openpgp.NewEntity(name, "", email, &packet.Config{RSABits: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	w, _ := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if err := e.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	return e, buf.String()
+}
+
+func TestParseGPGPublicKeys(t *testing.T) {
+	e, armored := newTestGPGKey(t, "John Doe", "john.doe@example.com")
+
+	keys, err := gerrit.ParseGPGPublicKeys(armored)
+	if err != nil {
+		t.Fatalf("ParseGPGPublicKeys returned error: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("ParseGPGPublicKeys returned %d keys, want 1", len(keys))
+	}
+
+	key := keys[0]
+	if key.ID != e.PrimaryKey.KeyIdShortString() {
+		t.Errorf("ID is %q, want %q", key.ID, e.PrimaryKey.KeyIdShortString())
+	}
+	if len(key.Fingerprint) != 50 || key.Fingerprint[24:26] != "  " {
+		t.Errorf("Fingerprint %q is not formatted like Gerrit does", key.Fingerprint)
+	}
+	if want := []string{"John Doe <john.doe@example.com>"}; !reflect.DeepEqual(key.UserIDs, want) {
+		t.Errorf("UserIDs are %v, want %v", key.UserIDs, want)
+	}
+	if want := []string{"john.doe@example.com"}; !reflect.DeepEqual(key.Emails, want) {
+		t.Errorf("Emails are %v, want %v", key.Emails, want)
+	}
+
+	if _, err := gerrit.ParseGPGPublicKeys("not a key"); err == nil {
+		t.Error("ParseGPGPublicKeys returned no error for an invalid key")
+	}
+}
+
+func TestAccountsService_AddGPGKey(t *testing.T) {
+	setup()
+	defer teardown()
+
+	_, registered := newTestGPGKey(t, "John Doe", "John.Doe@example.com")
+	_, unregistered := newTestGPGKey(t, "John Doe", "john@private.example.com")
+
+	testMux.HandleFunc("/accounts/self/emails", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `)]}'`+"\n"+`[{"email":"john.doe@example.com","preferred":true}]`)
+	})
+	added := 0
+	testMux.HandleFunc("/accounts/self/gpgkeys", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var input gerrit.GpgKeysInput
+		json.NewDecoder(r.Body).Decode(&input)
+		added += len(input.Add)
+		fmt.Fprint(w, `)]}'`+"\n"+`{"AFC8A49B":{"fingerprint":"0192 723D 42D1 0C5B 32A6  E1E0 9350 9E4B AFC8 A49B","status":"OK"}}`)
+	})
+
+	keys, _, err := testClient.Accounts.AddGPGKey("self", registered)
+	if err != nil {
+		t.Fatalf("Accounts.AddGPGKey returned error: %v", err)
+	}
+	if (*keys)["AFC8A49B"].Status != gerrit.GpgKeyStatusOK {
+		t.Errorf("Accounts.AddGPGKey returned %+v", *keys)
+	}
+
+	if _, _, err := testClient.Accounts.AddGPGKey("self", registered+unregistered); err == nil {
+		t.Error("Accounts.AddGPGKey returned no error for a key without registered email")
+	}
+	if added != 1 {
+		t.Errorf("%d keys uploaded, want 1", added)
+	}
+}
+
+func TestChangesService_GetPushCertificateSigner(t *testing.T) {
+	setup()
+	defer teardown()
+
+	signer, armored := newTestGPGKey(t, "John Doe", "john.doe@example.com")
+	payload := "certificate version 0.1\n" +
+		"pusher John Doe <john.doe@example.com> 1433954361 -0700\n" +
+		"pushee ssh://gerrit.example.com/project\n" +
+		"nonce 1433954361-bde756572d665bba81d8\n" +
+		"\n" +
+		"0000000000000000000000000000000000000000 b3f0d25a1b1c0d4b1d5c0e1e0f1a2b3c4d5e6f70 refs/for/master\n"
+	sig := new(bytes.Buffer)
+	if err := openpgp.ArmoredDetachSign(sig, signer, strings.NewReader(payload), nil); err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := json.Marshal(payload + sig.String())
+	key, _ := json.Marshal(armored)
+
+	testMux.HandleFunc("/changes/123", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got, want := r.URL.Query()["o"], []string{"ALL_REVISIONS", "PUSH_CERTIFICATES"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Request parameter o: %v, want %v", got, want)
+		}
+		fmt.Fprintf(w, `)]}'`+"\n"+`{"current_revision":"b3f0d25a","revisions":{"b3f0d25a":{"_number":1,"push_certificate":{"certificate":%s,"key":{"key":%s,"status":"TRUSTED"}}}}}`, certificate, key)
+	})
+	testMux.HandleFunc("/accounts/john.doe@example.com", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`{"_account_id":1000096,"email":"john.doe@example.com"}`)
+	})
+
+	got, _, err := testClient.Changes.GetPushCertificateSigner("123", "1")
+	if err != nil {
+		t.Fatalf("Changes.GetPushCertificateSigner returned error: %v", err)
+	}
+	if got.Account.AccountID != 1000096 || got.KeyStatus != gerrit.GpgKeyStatusTrusted || got.Key.ID != signer.PrimaryKey.KeyIdShortString() {
+		t.Errorf("Changes.GetPushCertificateSigner returned %+v", got)
+	}
+	if want := []gerrit.PushCommand{{OldID: "0000000000000000000000000000000000000000", NewID: "b3f0d25a1b1c0d4b1d5c0e1e0f1a2b3c4d5e6f70", Ref: "refs/for/master"}}; !reflect.DeepEqual(got.Certificate.Commands, want) {
+		t.Errorf("Commands are %+v, want %+v", got.Certificate.Commands, want)
+	}
+
+	cert, _ := gerrit.ParsePushCertificate(strings.Replace(payload, "refs/for/master", "refs/heads/master", 1) + sig.String())
+	if _, err := cert.Verify(armored); err == nil {
+		t.Error("Verify returned no error for a modified certificate")
+	}
+}
@@ -17,6 +17,8 @@ const (
 	authTypeDigest = 2
 	// HTTP Cookie Authentication
 	authTypeCookie = 3
+	// HTTP Bearer Token Authentication (e.g. OAuth2)
+	authTypeBearer = 4
 )
 
 // AuthenticationService contains Authentication related functions.
@@ -31,6 +33,24 @@ type AuthenticationService struct {
 	// Password or value of cookie
 	secret   string
 	authType int
+
+	// Source of bearer tokens
+	tokenSource TokenSource
+}
+
+// TokenSource returns tokens for HTTP Bearer auth.
+// Token is called for every request, so implementations should cache tokens until they expire.
+//
+// The gerritoauth2 package adapts an oauth2.TokenSource of golang.org/x/oauth2.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// staticTokenSource is a TokenSource that always returns the same token.
+type staticTokenSource string
+
+func (ts staticTokenSource) Token() (string, error) {
+	return string(ts), nil
 }
 
 // SetBasicAuth sets basic parameters for HTTP Basic auth
@@ -141,6 +161,32 @@ func (s *AuthenticationService) SetCookieAuth(name, value string) {
 	s.authType = authTypeCookie
 }
 
+// SetBearerToken sets a static token for HTTP Bearer auth.
+// The token is sent as "Authorization: Bearer <token>" header.
+func (s *AuthenticationService) SetBearerToken(token string) {
+	s.SetTokenSource(staticTokenSource(token))
+}
+
+// SetTokenSource sets a token source for HTTP Bearer auth.
+// ts is asked for a token before every request, so it is free to refresh expired tokens.
+// To use an OAuth2 token source, e.g. of golang.org/x/oauth2/google for googlesource.com,
+// wrap it with gerritoauth2.TokenSource.
+func (s *AuthenticationService) SetTokenSource(ts TokenSource) {
+	s.name = ""
+	s.secret = ""
+	s.tokenSource = ts
+	s.authType = authTypeBearer
+}
+
+// bearerAuthHeader returns the Authorization header for HTTP Bearer auth.
+func (s *AuthenticationService) bearerAuthHeader() (string, error) {
+	token, err := s.tokenSource.Token()
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
+
 // HasBasicAuth checks if the auth type is HTTP Basic auth
 func (s *AuthenticationService) HasBasicAuth() bool {
 	return s.authType == authTypeBasic
@@ -156,6 +202,11 @@ func (s *AuthenticationService) HasCookieAuth() bool {
 	return s.authType == authTypeCookie
 }
 
+// HasBearerAuth checks if the auth type is HTTP Bearer token based
+func (s *AuthenticationService) HasBearerAuth() bool {
+	return s.authType == authTypeBearer
+}
+
 // HasAuth checks if an auth type is used
 func (s *AuthenticationService) HasAuth() bool {
 	return s.authType > 0
@@ -165,5 +216,6 @@ func (s *AuthenticationService) HasAuth() bool {
 func (s *AuthenticationService) ResetAuth() {
 	s.name = ""
 	s.secret = ""
+	s.tokenSource = nil
 	s.authType = 0
 }
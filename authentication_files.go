@@ -0,0 +1,270 @@
+package gerrit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitCookie is a single cookie of a Netscape cookie file like ~/.gitcookies.
+type gitCookie struct {
+	domain            string
+	includeSubdomains bool
+	path              string
+	expires           time.Time
+	name              string
+	value             string
+}
+
+// matches reports if the cookie is valid for host and path at time now.
+func (c gitCookie) matches(host, path string, now time.Time) bool {
+	if !c.expires.IsZero() && c.expires.Before(now) {
+		return false
+	}
+	if !strings.HasPrefix(path, c.path) {
+		return false
+	}
+
+	domain := strings.ToLower(strings.TrimPrefix(c.domain, "."))
+	host = strings.ToLower(host)
+	if host == domain {
+		return true
+	}
+	return (c.includeSubdomains || strings.HasPrefix(c.domain, ".")) && strings.HasSuffix(host, "."+domain)
+}
+
+// DefaultGitCookiesPath returns the default location of the git cookie file, ~/.gitcookies.
+func DefaultGitCookiesPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".gitcookies"), nil
+}
+
+// DefaultNetrcPath returns the default location of the netrc file.
+// This is $NETRC if set, otherwise ~/.netrc (~/_netrc on Windows).
+func DefaultNetrcPath() (string, error) {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc"), nil
+	}
+	return filepath.Join(home, ".netrc"), nil
+}
+
+// SetGitCookiesAuth sets HTTP Cookie auth with the cookie for the Gerrit instance from a git cookie file.
+// If path is empty, ~/.gitcookies is used.
+// This is the file git uses via "http.cookiefile" and which googlesource.com hands out.
+//
+// The file is in Netscape cookie format.
+// If several cookies match the host of the Gerrit instance, the one with the most specific domain is used.
+func (s *AuthenticationService) SetGitCookiesAuth(path string) error {
+	if path == "" {
+		var err error
+		if path, err = DefaultGitCookiesPath(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cookies, err := parseGitCookies(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	host := s.client.baseURL.Hostname()
+	urlPath := s.client.baseURL.Path
+	if urlPath == "" {
+		urlPath = "/"
+	}
+
+	var match *gitCookie
+	now := time.Now()
+	for i, c := range cookies {
+		if !c.matches(host, urlPath, now) {
+			continue
+		}
+		if match == nil || len(c.domain) > len(match.domain) {
+			match = &cookies[i]
+		}
+	}
+	if match == nil {
+		return fmt.Errorf("%s: no cookie for host %s", path, host)
+	}
+
+	s.SetCookieAuth(match.name, match.value)
+	return nil
+}
+
+// SetNetrcAuth sets HTTP Basic auth with the credentials for the Gerrit instance from a netrc file.
+// If path is empty, the file returned by DefaultNetrcPath is used.
+// If there is no machine entry for the host of the Gerrit instance, the default entry is used.
+func (s *AuthenticationService) SetNetrcAuth(path string) error {
+	if path == "" {
+		var err error
+		if path, err = DefaultNetrcPath(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entries, err := parseNetrc(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	host := s.client.baseURL.Hostname()
+	var match *netrcEntry
+	for i, e := range entries {
+		if strings.EqualFold(e.machine, host) {
+			match = &entries[i]
+			break
+		}
+		if e.isDefault && match == nil {
+			match = &entries[i]
+		}
+	}
+	if match == nil {
+		return fmt.Errorf("%s: no credentials for host %s", path, host)
+	}
+
+	s.SetBasicAuth(match.login, match.password)
+	return nil
+}
+
+// parseGitCookies parses a Netscape cookie file.
+// Each line consists of seven tab separated fields:
+// domain, include subdomains, path, secure, expiry, name and value.
+// Lines prefixed with "#HttpOnly_" are cookies, all other lines starting with "#" are comments.
+func parseGitCookies(r io.Reader) ([]gitCookie, error) {
+	var cookies []gitCookie
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab separated fields, got %d", lineNumber, len(fields))
+		}
+
+		c := gitCookie{
+			domain:            fields[0],
+			includeSubdomains: strings.EqualFold(fields[1], "TRUE"),
+			path:              fields[2],
+			name:              fields[5],
+			value:             fields[6],
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %q", lineNumber, fields[4])
+		}
+		if expires > 0 {
+			c.expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, c)
+	}
+
+	return cookies, scanner.Err()
+}
+
+// netrcEntry is a single machine or default entry of a netrc file.
+type netrcEntry struct {
+	machine   string
+	isDefault bool
+	login     string
+	password  string
+}
+
+// parseNetrc parses a netrc file as described in ftp(1).
+// Macro definitions are skipped.
+func parseNetrc(r io.Reader) ([]netrcEntry, error) {
+	var entries []netrcEntry
+	var current *netrcEntry
+
+	scanner := bufio.NewScanner(r)
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// A macro definition ends with an empty line.
+		if inMacro {
+			if strings.TrimSpace(line) == "" {
+				inMacro = false
+			}
+			continue
+		}
+
+		tokens := strings.Fields(line)
+		for i := 0; i < len(tokens); i++ {
+			if strings.HasPrefix(tokens[i], "#") {
+				break
+			}
+
+			switch tokens[i] {
+			case "machine", "default":
+				entries = append(entries, netrcEntry{isDefault: tokens[i] == "default"})
+				current = &entries[len(entries)-1]
+				if tokens[i] == "default" {
+					continue
+				}
+			case "login", "password", "account":
+			case "macdef":
+				inMacro = true
+				i = len(tokens)
+				continue
+			default:
+				return nil, fmt.Errorf("unknown token %q", tokens[i])
+			}
+
+			if i+1 >= len(tokens) {
+				return nil, fmt.Errorf("missing value for %q", tokens[i])
+			}
+			if current == nil {
+				return nil, fmt.Errorf("%q outside of a machine entry", tokens[i])
+			}
+
+			value := tokens[i+1]
+			switch tokens[i] {
+			case "machine":
+				current.machine = value
+			case "login":
+				current.login = value
+			case "password":
+				current.password = value
+			}
+			i++
+		}
+	}
+
+	return entries, scanner.Err()
+}
@@ -0,0 +1,170 @@
+package gerrit_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// countingTokenSource returns a new token on every call.
+type countingTokenSource struct {
+	calls int
+}
+
+func (ts *countingTokenSource) Token() (string, error) {
+	ts.calls++
+	return fmt.Sprintf("token-%d", ts.calls), nil
+}
+
+func writeTempFile(t *testing.T, name, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "go-gerrit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestAuthenticationService_SetBearerToken(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/a/accounts/self", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Authorization"), "Bearer my-token"; got != want {
+			t.Errorf("Authorization header %q, want %q", got, want)
+		}
+		fmt.Fprint(w, `)]}'`+"\n"+`{"_account_id":1000096}`)
+	})
+
+	testClient.Authentication.SetBearerToken("my-token")
+	if !testClient.Authentication.HasBearerAuth() || !testClient.Authentication.HasAuth() {
+		t.Error("Bearer auth is not active")
+	}
+
+	if _, _, err := testClient.Accounts.GetAccount("self"); err != nil {
+		t.Errorf("Accounts.GetAccount returned error: %v", err)
+	}
+
+	testClient.Authentication.ResetAuth()
+	if testClient.Authentication.HasBearerAuth() {
+		t.Error("Bearer auth is still active after ResetAuth")
+	}
+}
+
+func TestAuthenticationService_SetTokenSource(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var headers []string
+	testMux.HandleFunc("/a/accounts/self", func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("Authorization"))
+		fmt.Fprint(w, `)]}'`+"\n"+`{"_account_id":1000096}`)
+	})
+
+	ts := &countingTokenSource{}
+	testClient.Authentication.SetTokenSource(ts)
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := testClient.Accounts.GetAccount("self"); err != nil {
+			t.Fatalf("Accounts.GetAccount returned error: %v", err)
+		}
+	}
+
+	// The token source is asked before every request.
+	want := []string{"Bearer token-1", "Bearer token-2"}
+	if !reflect.DeepEqual(headers, want) {
+		t.Errorf("Authorization headers %q, want %q", headers, want)
+	}
+}
+
+func TestAuthenticationService_SetGitCookiesAuth(t *testing.T) {
+	path, cleanup := writeTempFile(t, ".gitcookies", ""+
+		"# Netscape HTTP Cookie File\n"+
+		"\n"+
+		".googlesource.com\tTRUE\t/\tTRUE\t2147483647\to\tgit-user.example.com=generic\n"+
+		"#HttpOnly_go-review.googlesource.com\tFALSE\t/\tTRUE\t0\to\tgit-user.example.com=specific\n"+
+		"example.org\tFALSE\t/\tTRUE\t2147483647\to\tother\n"+
+		"expired.googlesource.com\tFALSE\t/\tTRUE\t1\to\texpired\n")
+	defer cleanup()
+
+	testCases := []struct {
+		url   string
+		value string
+	}{
+		{"https://go-review.googlesource.com/", "git-user.example.com=specific"},
+		{"https://android-review.googlesource.com/", "git-user.example.com=generic"},
+		{"https://expired.googlesource.com/", "git-user.example.com=generic"},
+		{"https://example.org/", "other"},
+	}
+	for _, tc := range testCases {
+		client, _ := gerrit.NewClient(tc.url, nil)
+		if err := client.Authentication.SetGitCookiesAuth(path); err != nil {
+			t.Errorf("SetGitCookiesAuth for %s returned error: %v", tc.url, err)
+			continue
+		}
+		if !client.Authentication.HasCookieAuth() {
+			t.Errorf("SetGitCookiesAuth for %s did not set cookie auth", tc.url)
+		}
+
+		req, _ := client.NewRequest("GET", "accounts/self", nil)
+		cookie, err := req.Cookie("o")
+		if err != nil {
+			t.Errorf("Request for %s has no cookie: %v", tc.url, err)
+			continue
+		}
+		if cookie.Value != tc.value {
+			t.Errorf("Cookie for %s is %q, want %q", tc.url, cookie.Value, tc.value)
+		}
+	}
+
+	client, _ := gerrit.NewClient("https://review.example.com/", nil)
+	if err := client.Authentication.SetGitCookiesAuth(path); err == nil {
+		t.Error("SetGitCookiesAuth for an unknown host returned no error")
+	}
+}
+
+func TestAuthenticationService_SetNetrcAuth(t *testing.T) {
+	path, cleanup := writeTempFile(t, ".netrc", ""+
+		"# Gerrit credentials\n"+
+		"machine review.example.com login alice password secret-alice\n"+
+		"macdef init\n"+
+		"cd /pub\n"+
+		"\n"+
+		"machine other.example.com\n"+
+		"  login bob\n"+
+		"  password secret-bob\n"+
+		"default login anonymous password guest\n")
+	defer cleanup()
+
+	testCases := []struct {
+		url      string
+		user     string
+		password string
+	}{
+		{"https://review.example.com/", "alice", "secret-alice"},
+		{"https://other.example.com/r/", "bob", "secret-bob"},
+		{"https://unknown.example.com/", "anonymous", "guest"},
+	}
+	for _, tc := range testCases {
+		client, _ := gerrit.NewClient(tc.url, nil)
+		if err := client.Authentication.SetNetrcAuth(path); err != nil {
+			t.Errorf("SetNetrcAuth for %s returned error: %v", tc.url, err)
+			continue
+		}
+
+		req, _ := client.NewRequest("GET", "accounts/self", nil)
+		user, password, ok := req.BasicAuth()
+		if !ok || user != tc.user || password != tc.password {
+			t.Errorf("Basic auth for %s is %s:%s, want %s:%s", tc.url, user, password, tc.user, tc.password)
+		}
+	}
+}
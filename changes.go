@@ -67,6 +67,15 @@ type ChangeMessageInfo struct {
 type CherryPickInput struct {
 	Message     string `json:"message"`
 	Destination string `json:"destination"`
+	// Base is the commit on top of which the cherry-pick is created. If not set, the destination branch is used.
+	Base string `json:"base,omitempty"`
+	// Parent is the 1-based index of the parent to diff against, if the cherry-picked commit is a merge commit.
+	Parent         int    `json:"parent,omitempty"`
+	Notify         string `json:"notify,omitempty"`
+	KeepReviewers  bool   `json:"keep_reviewers,omitempty"`
+	AllowConflicts bool   `json:"allow_conflicts,omitempty"`
+	Topic          string `json:"topic,omitempty"`
+	AllowEmpty     bool   `json:"allow_empty,omitempty"`
 }
 
 // CommentRange entity describes the range of an inline comment.
@@ -306,6 +315,7 @@ type RevisionInfo struct {
 	Actions           map[string]ActionInfo `json:"actions,omitempty"`
 	Reviewed          bool                  `json:"reviewed,omitempty"`
 	MessageWithFooter string                `json:"messageWithFooter,omitempty"`
+	PushCertificate   *PushCertificateInfo  `json:"push_certificate,omitempty"`
 }
 
 // CommentInfo entity contains information about an inline comment.
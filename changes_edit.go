@@ -2,6 +2,8 @@ package gerrit
 
 import (
 	"fmt"
+	"io"
+	"strings"
 )
 
 // EditInfo entity contains information about a change edit.
@@ -88,16 +90,27 @@ func (s *ChangesService) RetrieveCommitMessageFromChangeEdit(changeID string) (s
 }
 
 // ChangeFileContentInChangeEdit put content of a file to a change edit.
+// It sends no content, so the content of the file is wiped out.
+//
+// Deprecated: Use UploadFileContentInChangeEdit, which sends the content of the file.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#put-edit-file
+func (s *ChangesService) ChangeFileContentInChangeEdit(changeID, filePath string) (*Response, error) {
+	return s.UploadFileContentInChangeEdit(changeID, filePath, strings.NewReader(""))
+}
+
+// UploadFileContentInChangeEdit puts the content of a file to a change edit.
+// The content is streamed as application/octet-stream, so binary files can be uploaded as well.
 //
 // When change edit doesn’t exist for this change yet it is created.
-// When file content isn’t provided, it is wiped out for that file.
+// When the content is empty, the content of the file is wiped out.
 // As response “204 No Content” is returned.
 //
 // Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#put-edit-file
-func (s *ChangesService) ChangeFileContentInChangeEdit(changeID, filePath string) (*Response, error) {
+func (s *ChangesService) UploadFileContentInChangeEdit(changeID, filePath string, content io.Reader) (*Response, error) {
 	u := fmt.Sprintf("changes/%s/edit/%s", changeID, filePath)
 
-	req, err := s.client.NewRequest("PUT", u, nil)
+	req, err := s.client.NewRequest("PUT", u, RawBody("application/octet-stream", content))
 	if err != nil {
 		return nil, err
 	}
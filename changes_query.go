@@ -0,0 +1,99 @@
+package gerrit
+
+import (
+	"strings"
+)
+
+// ChangeQuery is a change search expression, e.g. "is:open owner:self".
+// It can be written by hand or composed with QueryOperator, QueryAnd, QueryOr and QueryNot,
+// which take care of quoting and grouping.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/user-search.html#_search_operators
+type ChangeQuery string
+
+// Common change queries.
+const (
+	QueryIsOpen       ChangeQuery = "is:open"
+	QueryIsMerged     ChangeQuery = "is:merged"
+	QueryIsAbandoned  ChangeQuery = "is:abandoned"
+	QueryIsWIP        ChangeQuery = "is:wip"
+	QueryOwnerSelf    ChangeQuery = "owner:self"
+	QueryReviewerSelf ChangeQuery = "reviewer:self"
+)
+
+// QueryOperator returns the query operator:value.
+// The value is quoted if it contains spaces, quotes or parentheses.
+func QueryOperator(operator, value string) ChangeQuery {
+	return ChangeQuery(operator + ":" + quoteQueryValue(value))
+}
+
+// QueryAnd returns a query that matches if all queries match.
+// Empty queries are skipped.
+func QueryAnd(queries ...ChangeQuery) ChangeQuery {
+	return joinQueries(" ", queries)
+}
+
+// QueryOr returns a query that matches if any of the queries matches.
+// Empty queries are skipped.
+func QueryOr(queries ...ChangeQuery) ChangeQuery {
+	return joinQueries(" OR ", queries)
+}
+
+// QueryNot returns a query that matches if q doesn't match.
+func QueryNot(q ChangeQuery) ChangeQuery {
+	if q == "" {
+		return ""
+	}
+	return "-" + group(q)
+}
+
+// String returns the query as string.
+func (q ChangeQuery) String() string {
+	return string(q)
+}
+
+// joinQueries joins the non-empty queries with sep, grouping each query that contains spaces.
+func joinQueries(sep string, queries []ChangeQuery) ChangeQuery {
+	var parts []string
+	for _, q := range queries {
+		if q != "" {
+			parts = append(parts, string(q))
+		}
+	}
+	if len(parts) == 1 {
+		return ChangeQuery(parts[0])
+	}
+	for i, part := range parts {
+		parts[i] = string(group(ChangeQuery(part)))
+	}
+	return ChangeQuery(strings.Join(parts, sep))
+}
+
+// group wraps q in parentheses if it consists of more than one term.
+func group(q ChangeQuery) ChangeQuery {
+	s := string(q)
+	if !strings.ContainsAny(s, " ") || isQuotedTerm(s) {
+		return q
+	}
+	return ChangeQuery("(" + s + ")")
+}
+
+// isQuotedTerm reports if s is a single term with a quoted value, e.g. owner:"John Doe".
+func isQuotedTerm(s string) bool {
+	i := strings.Index(s, ":\"")
+	return i > 0 && !strings.ContainsAny(s[:i], " ()") && strings.HasSuffix(s, "\"") &&
+		!strings.Contains(s[i+2:len(s)-1], "\"")
+}
+
+// quoteQueryValue quotes value if it contains characters that end a term.
+// Placeholders like ${project} of project dashboards are kept as they are.
+func quoteQueryValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \"()") && !strings.HasPrefix(value, "{") {
+		return value
+	}
+	if !strings.Contains(value, "\"") {
+		return "\"" + value + "\""
+	}
+	// Gerrit doesn't support escaping quotes within a quoted value, but braces can be used instead.
+	return "{" + value + "}"
+}
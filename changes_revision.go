@@ -53,7 +53,11 @@ type CommitInfo struct {
 // MergeableInfo entity contains information about the mergeability of a change.
 type MergeableInfo struct {
 	SubmitType    string   `json:"submit_type"`
+	Strategy      string   `json:"strategy,omitempty"`
 	Mergeable     bool     `json:"mergeable"`
+	CommitMerged  bool     `json:"commit_merged,omitempty"`
+	ContentMerged bool     `json:"content_merged,omitempty"`
+	Conflicts     []string `json:"conflicts,omitempty"`
 	MergeableInto []string `json:"mergeable_into,omitempty"`
 }
 
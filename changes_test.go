@@ -1,14 +1,73 @@
 package gerrit_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
 
 	"github.com/andygrunwald/go-gerrit"
 )
 
+func TestChangeQuery(t *testing.T) {
+	tests := []struct {
+		query gerrit.ChangeQuery
+		want  string
+	}{
+		{gerrit.QueryOperator("project", "go"), "project:go"},
+		{gerrit.QueryOperator("owner", "John Doe"), `owner:"John Doe"`},
+		{gerrit.QueryOperator("message", `say "hi"`), `message:{say "hi"}`},
+		{gerrit.QueryAnd(gerrit.QueryIsOpen, "", gerrit.QueryOwnerSelf), "is:open owner:self"},
+		{gerrit.QueryAnd(gerrit.QueryIsOpen), "is:open"},
+		{
+			gerrit.QueryAnd(gerrit.QueryIsOpen, gerrit.QueryOr(gerrit.QueryOperator("branch", "master"), gerrit.QueryOperator("branch", "stable"))),
+			"is:open (branch:master OR branch:stable)",
+		},
+		{gerrit.QueryOr(gerrit.QueryOperator("owner", "John Doe"), gerrit.QueryOwnerSelf), `owner:"John Doe" OR owner:self`},
+		{gerrit.QueryNot(gerrit.QueryIsWIP), "-is:wip"},
+		{gerrit.QueryNot(gerrit.QueryAnd(gerrit.QueryIsWIP, gerrit.QueryOwnerSelf)), "-(is:wip owner:self)"},
+		{gerrit.QueryNot(""), ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.query.String(); got != tt.want {
+			t.Errorf("query %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestChangesService_AddReviewer(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/changes/123/reviewers", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		input := new(gerrit.ReviewerInput)
+		if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+			t.Fatal(err)
+		}
+		if want := (gerrit.ReviewerInput{Reviewer: "jane", Confirmed: true}); *input != want {
+			t.Errorf("Request body %+v, want %+v", *input, want)
+		}
+		fmt.Fprint(w, `)]}'`+"\n"+`{"input":"jane","reviewers":[{"_account_id":1000097}]}`)
+	})
+
+	result, _, err := testClient.Changes.AddReviewer("123", &gerrit.ReviewerInput{Reviewer: "jane", Confirmed: true})
+	if err != nil {
+		t.Fatalf("Changes.AddReviewer returned error: %v", err)
+	}
+	want := &gerrit.AddReviewerResult{Reviewers: []gerrit.ReviewerInfo{{AccountInfo: gerrit.AccountInfo{AccountID: 1000097}}}}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Changes.AddReviewer returned %+v, want %+v", result, want)
+	}
+}
+
 func ExampleChangesService_QueryChanges() {
 	instance := "https://android-review.googlesource.com/"
-	client, err := gerrit.NewClient(instance, nil)
+	client, err := gerrit.NewClient(instance, exampleHTTPClient("ExampleChangesService_QueryChanges"))
 	if err != nil {
 		panic(err)
 	}
@@ -31,3 +90,29 @@ func ExampleChangesService_QueryChanges() {
 	// Output:
 	// Project: platform/art -> ART: Change return types of field access entrypoints -> https://android-review.googlesource.com/249244
 }
+
+func TestChangesService_UploadFileContentInChangeEdit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var bodies []string
+	testMux.HandleFunc("/changes/123/edit/README.md", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		if got, want := r.Header.Get("Content-Type"), "application/octet-stream"; got != want {
+			t.Errorf("Content-Type is %q, want %q", got, want)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := testClient.Changes.UploadFileContentInChangeEdit("123", "README.md", strings.NewReader("Hello")); err != nil {
+		t.Errorf("Changes.UploadFileContentInChangeEdit returned error: %v", err)
+	}
+	if _, err := testClient.Changes.ChangeFileContentInChangeEdit("123", "README.md"); err != nil {
+		t.Errorf("Changes.ChangeFileContentInChangeEdit returned error: %v", err)
+	}
+	if want := []string{"Hello", ""}; !reflect.DeepEqual(bodies, want) {
+		t.Errorf("Request bodies %q, want %q", bodies, want)
+	}
+}
@@ -8,7 +8,7 @@ import (
 
 func ExampleConfigService_GetVersion() {
 	instance := "https://gerrit-review.googlesource.com/"
-	client, err := gerrit.NewClient(instance, nil)
+	client, err := gerrit.NewClient(instance, exampleHTTPClient("ExampleConfigService_GetVersion"))
 	if err != nil {
 		panic(err)
 	}
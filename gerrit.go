@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/google/go-querystring/query"
 )
@@ -27,6 +28,11 @@ type Client struct {
 	// BaseURL should always be specified with a trailing slash.
 	baseURL *url.URL
 
+	// Middleware that every request passes before it is sent.
+	// See Client.Use.
+	middleware   []Middleware
+	middlewareMu sync.RWMutex
+
 	// Gerrit service for authentication
 	Authentication *AuthenticationService
 
@@ -88,26 +94,35 @@ func NewClient(gerritURL string, httpClient *http.Client) (*Client, error) {
 // A relative URL can be provided in urlStr, in which case it is resolved relative to the baseURL of the Client.
 // Relative URLs should always be specified without a preceding slash.
 // If specified, the value pointed to by body is JSON encoded and included as the request body.
+// If body is a RequestBody, e.g. RawBody or TextBody, it is sent as is with its content type instead.
+//
+// The logical operation of the request, e.g. "Changes.SetReview",
+// is derived from the calling service method and stored in the request context.
+// See OperationFromContext.
 func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Request, error) {
+	contentType := "application/json"
+	var r io.Reader
+	if b := newRequestBody(body); b != nil {
+		var err error
+		if r, err = b.Reader(); err != nil {
+			return nil, err
+		}
+		contentType = b.ContentType()
+	}
+
 	// Build URL for request
 	u, err := c.buildURLForRequest(urlStr)
 	if err != nil {
 		return nil, err
 	}
 
-	var buf io.ReadWriter
-	if body != nil {
-		buf = new(bytes.Buffer)
-		err := json.NewEncoder(buf).Encode(body)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	req, err := http.NewRequest(method, u, buf)
+	req, err := http.NewRequest(method, u, r)
 	if err != nil {
 		return nil, err
 	}
+	if operation := callerOperation(); operation != "" {
+		req = req.WithContext(ContextWithOperation(req.Context(), operation))
+	}
 
 	// Apply Authentication
 	if err := c.addAuthentication(req); err != nil {
@@ -117,7 +132,7 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 	// Request compact JSON
 	// See https://gerrit-review.googlesource.com/Documentation/rest-api.html#output
 	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Content-Type", contentType)
 
 	// TODO: Add gzip encoding
 	// Accept-Encoding request header is set to gzip
@@ -192,8 +207,10 @@ func (c *Client) buildURLForRequest(urlStr string) (string, error) {
 // or returned as an error if an API error has occurred.
 // If v implements the io.Writer interface, the raw response body will be written to v,
 // without attempting to first decode it.
+//
+// The request passes the middleware chain of the client, see Client.Use.
 func (c *Client) Do(req *http.Request, v interface{}) (*Response, error) {
-	resp, err := c.client.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return nil, err
 	}
@@ -244,6 +261,16 @@ func (c *Client) addAuthentication(req *http.Request) error {
 		return nil
 	}
 
+	// Apply HTTP Bearer Token
+	if c.Authentication.HasBearerAuth() {
+		authorization, err := c.Authentication.bearerAuthHeader()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", authorization)
+		return nil
+	}
+
 	// Apply Digest Authentication.  If we're using digest based
 	// authentication we need to make a request, process the
 	// WWW-Authenticate header, then set the Authorization header on the
@@ -363,6 +390,19 @@ func addOptions(s string, opt interface{}) (string, error) {
 	return u.String(), nil
 }
 
+// Bool returns a pointer to v.
+// It is a helper for optional boolean fields of input entities like ProjectInput,
+// where nil means "use the server default".
+func Bool(v bool) *bool {
+	return &v
+}
+
+// Int returns a pointer to v.
+// It is a helper for optional integer fields of input entities like PermissionRuleInput.
+func Int(v int) *int {
+	return &v
+}
+
 // getStringResponseWithoutOptions retrieved a single string Response for a GET request
 func getStringResponseWithoutOptions(client *Client, u string) (string, *Response, error) {
 	v := new(string)
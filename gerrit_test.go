@@ -2,15 +2,20 @@ package gerrit_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/andygrunwald/go-gerrit"
+	"github.com/andygrunwald/go-gerrit/gerrittest"
 )
 
 const (
@@ -29,6 +34,12 @@ var (
 	testServer *httptest.Server
 )
 
+// record records the cassettes of the examples against the real Gerrit instances.
+// The checked in cassettes are hand-written fixtures, not recordings.
+//
+//	go test -run Example -record
+var record = flag.Bool("record", false, "record example cassettes against the real Gerrit instances")
+
 type testValues map[string]string
 
 // setup sets up a test HTTP server along with a gerrit.Client that is configured to talk to that test server.
@@ -47,6 +58,21 @@ func teardown() {
 	testServer.Close()
 }
 
+// exampleHTTPClient returns a http.Client that replays the hand-written cassette testdata/<name>.json.
+// With -record the cassette is recorded from the network instead.
+func exampleHTTPClient(name string) *http.Client {
+	mode := gerrittest.ModeReplay
+	if *record {
+		mode = gerrittest.ModeRecord
+	}
+
+	recorder, err := gerrittest.NewRecorder(filepath.Join("testdata", name+".json"), mode)
+	if err != nil {
+		panic(err)
+	}
+	return recorder.Client()
+}
+
 func testMethod(t *testing.T, r *http.Request, want string) {
 	if got := r.Method; got != want {
 		t.Errorf("Request method: %v, want %v", got, want)
@@ -174,6 +200,72 @@ func TestNewRequest_EmptyBody(t *testing.T) {
 	}
 }
 
+func TestNewRequest_RequestBody(t *testing.T) {
+	c, err := gerrit.NewClient(testGerritInstanceURL, nil)
+	if err != nil {
+		t.Errorf("An error occured. Expected nil. Got %+v.", err)
+	}
+
+	pr, pw := io.Pipe()
+	tests := []struct {
+		name        string
+		body        interface{}
+		contentType string
+		length      int64
+	}{
+		{"json", gerrit.JSONBody(map[string]int{"a": 1}), "application/json", 8},
+		{"text", gerrit.TextBody("ssh-ed25519 AAAA"), "text/plain", 16},
+		{"bytes", gerrit.RawBody("application/octet-stream", bytes.NewReader([]byte{0, 1, 2})), "application/octet-stream", 3},
+		{"stream", gerrit.RawBody("application/java-archive", pr), "application/java-archive", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := c.NewRequest("POST", "foo", tt.body)
+			if err != nil {
+				t.Fatalf("NewRequest returned error: %v", err)
+			}
+			if got := req.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type is %q, want %q", got, tt.contentType)
+			}
+			if req.ContentLength != tt.length {
+				t.Errorf("ContentLength is %d, want %d", req.ContentLength, tt.length)
+			}
+		})
+	}
+
+	// The stream is not read before the request is sent.
+	go func() {
+		pw.Write([]byte("jar"))
+		pw.Close()
+	}()
+	req, _ := c.NewRequest("POST", "foo", gerrit.RawBody("application/java-archive", pr))
+	if body, _ := ioutil.ReadAll(req.Body); string(body) != "jar" {
+		t.Errorf("NewRequest Body is %q, want %q", body, "jar")
+	}
+}
+
+func TestDataURL(t *testing.T) {
+	if got, want := gerrit.DataURL("text/plain", []byte("Hello")), "data:text/plain;base64,SGVsbG8="; got != want {
+		t.Errorf("DataURL is %q, want %q", got, want)
+	}
+}
+
+func TestBool(t *testing.T) {
+	a, b := gerrit.Bool(false), gerrit.Bool(false)
+	if a == nil || *a {
+		t.Errorf("Bool(false) is %v, want a pointer to false", a)
+	}
+	if a == b {
+		t.Error("Bool returned the same pointer twice")
+	}
+
+	// Unset options are left out, set options are sent even if they are false.
+	data, _ := json.Marshal(gerrit.ProjectInput{PermissionsOnly: gerrit.Bool(false)})
+	if got, want := string(data), `{"permissions_only":false}`; got != want {
+		t.Errorf("ProjectInput is marshaled to %s, want %s", got, want)
+	}
+}
+
 func TestDo(t *testing.T) {
 	setup()
 	defer teardown()
@@ -0,0 +1,31 @@
+// Package gerritoauth2 adapts token sources of golang.org/x/oauth2 for HTTP Bearer auth of a gerrit.Client.
+//
+// It is a module of its own, so the core client doesn't depend on golang.org/x/oauth2:
+//
+//	ts, err := google.DefaultTokenSource(ctx, "https://www.googleapis.com/auth/gerritcodereview")
+//	client.Authentication.SetTokenSource(gerritoauth2.TokenSource(ts))
+package gerritoauth2
+
+import (
+	"github.com/andygrunwald/go-gerrit"
+	"golang.org/x/oauth2"
+)
+
+// tokenSource is a gerrit.TokenSource backed by an oauth2.TokenSource.
+type tokenSource struct {
+	ts oauth2.TokenSource
+}
+
+// TokenSource returns a gerrit.TokenSource that returns the access tokens of ts.
+// Tokens are cached and only requested from ts again once they expire.
+func TokenSource(ts oauth2.TokenSource) gerrit.TokenSource {
+	return &tokenSource{ts: oauth2.ReuseTokenSource(nil, ts)}
+}
+
+func (s *tokenSource) Token() (string, error) {
+	token, err := s.ts.Token()
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,65 @@
+package gerritoauth2_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-gerrit"
+	"github.com/andygrunwald/go-gerrit/gerritoauth2"
+	"golang.org/x/oauth2"
+)
+
+// countingTokenSource returns a new token on every call.
+type countingTokenSource struct {
+	calls  int
+	expiry time.Time
+}
+
+func (ts *countingTokenSource) Token() (*oauth2.Token, error) {
+	ts.calls++
+	return &oauth2.Token{AccessToken: fmt.Sprintf("token-%d", ts.calls), TokenType: "Bearer", Expiry: ts.expiry}, nil
+}
+
+func TestTokenSource(t *testing.T) {
+	var headers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("Authorization"))
+		fmt.Fprint(w, `)]}'`+"\n"+`{"_account_id":1000096}`)
+	}))
+	defer server.Close()
+
+	client, err := gerrit.NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		expiry    time.Time
+		wantCalls int
+	}{
+		{"without expiry", time.Time{}, 1},
+		{"expired", time.Now().Add(-time.Hour), 2},
+	}
+	for _, tt := range tests {
+		headers = nil
+		ts := &countingTokenSource{expiry: tt.expiry}
+		client.Authentication.SetTokenSource(gerritoauth2.TokenSource(ts))
+
+		for i := 0; i < 2; i++ {
+			if _, _, err := client.Accounts.GetAccount("self"); err != nil {
+				t.Fatalf("%s: Accounts.GetAccount returned error: %v", tt.name, err)
+			}
+		}
+
+		if ts.calls != tt.wantCalls {
+			t.Errorf("%s: token source was called %d times, want %d", tt.name, ts.calls, tt.wantCalls)
+		}
+		if want := fmt.Sprintf("Bearer token-%d", tt.wantCalls); headers[1] != want {
+			t.Errorf("%s: Authorization header %q, want %q", tt.name, headers[1], want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/andygrunwald/go-gerrit/gerritoauth2
+
+go 1.18
+
+require (
+	github.com/andygrunwald/go-gerrit v0.0.0
+	golang.org/x/oauth2 v0.21.0
+)
+
+require (
+	github.com/google/go-querystring v1.1.0 // indirect
+	golang.org/x/crypto v0.24.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+)
+
+// The gerritoauth2 module is developed together with the core module.
+replace github.com/andygrunwald/go-gerrit => ../
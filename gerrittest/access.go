@@ -0,0 +1,255 @@
+package gerrittest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// SetProjectAccess replaces the local access sections of an existing project, keyed by ref pattern.
+// Rules are keyed by group UUID, like Gerrit does.
+// The revision of refs/meta/config changes with every update of the access rights.
+// It returns false if the project does not exist.
+func (s *Server) SetProjectAccess(projectName string, local map[string]gerrit.AccessSectionInfo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.projects[projectName]
+	if !ok {
+		return false
+	}
+	p.access = copyAccess(local)
+	s.updateConfigRevision(p)
+	return true
+}
+
+// copyAccess returns a deep copy of access sections.
+func copyAccess(local map[string]gerrit.AccessSectionInfo) map[string]gerrit.AccessSectionInfo {
+	sections := make(map[string]gerrit.AccessSectionInfo)
+	for ref, section := range local {
+		permissions := make(map[string]gerrit.PermissionInfo)
+		for name, permission := range section.Permissions {
+			rules := make(map[string]gerrit.PermissionRuleInfo)
+			for uuid, rule := range permission.Rules {
+				rules[uuid] = rule
+			}
+			permission.Rules = rules
+			permissions[name] = permission
+		}
+		sections[ref] = gerrit.AccessSectionInfo{Permissions: permissions}
+	}
+	return sections
+}
+
+// updateConfigRevision moves refs/meta/config of p to a new commit.
+// The caller must hold s.mu.
+func (s *Server) updateConfigRevision(p *project) {
+	config := p.branches["refs/meta/config"]
+	config.Revision = fakeSHA1(p.info.Name, "refs/meta/config", config.Revision)
+	p.branches["refs/meta/config"] = config
+}
+
+// accessInfo renders the access rights of p.
+// The permissions of the caller are not evaluated, so the owner and capability flags are never set.
+func (s *Server) accessInfo(p *project) gerrit.ProjectAccessInfo {
+	info := gerrit.ProjectAccessInfo{
+		Revision: p.branches["refs/meta/config"].Revision,
+		Local:    copyAccess(p.access),
+		Groups:   make(map[string]gerrit.GroupInfo),
+	}
+	if parent, ok := s.projects[p.info.Parent]; ok {
+		info.InheritsFrom = parent.info
+	}
+
+	for _, section := range p.access {
+		for _, permission := range section.Permissions {
+			for uuid := range permission.Rules {
+				if g, ok := s.groups[uuid]; ok {
+					// Gerrit leaves out the UUID, as it is the key of the map.
+					group := encodeGroupInfo(g.info)
+					group.ID = ""
+					info.Groups[uuid] = group
+				}
+			}
+		}
+	}
+	return info
+}
+
+func (s *Server) serveAccess(w http.ResponseWriter, r *request, segments []string) {
+	if len(segments) != 0 || r.Method != "GET" {
+		s.notFound(w)
+		return
+	}
+
+	result := make(map[string]gerrit.ProjectAccessInfo)
+	for _, name := range r.URL.Query()["project"] {
+		p, ok := s.projects[name]
+		if !ok {
+			s.writeError(w, http.StatusNotFound, "Not found: %s", name)
+			return
+		}
+		result[name] = s.accessInfo(p)
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) serveProjectAccess(w http.ResponseWriter, r *request, p *project) {
+	switch r.Method {
+	case "GET":
+		s.writeJSON(w, http.StatusOK, s.accessInfo(p))
+	case "POST":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		input := new(gerrit.ProjectAccessInput)
+		if err := r.decode(input); err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid input: %s", err)
+			return
+		}
+		access, err := s.applyAccessInput(p.access, input)
+		if err != nil {
+			s.writeError(w, http.StatusUnprocessableEntity, "%s", err)
+			return
+		}
+		if input.Parent != "" {
+			if _, ok := s.projects[input.Parent]; !ok {
+				s.writeError(w, http.StatusUnprocessableEntity, "Parent project %q does not exist", input.Parent)
+				return
+			}
+			p.info.Parent = input.Parent
+		}
+		p.access = access
+		s.updateConfigRevision(p)
+		s.writeJSON(w, http.StatusOK, s.accessInfo(p))
+	default:
+		s.methodNotAllowed(w)
+	}
+}
+
+// createAccessChange answers "PUT /projects/X/access:review".
+// The input is validated and a change for refs/meta/config is created,
+// but the access rights only change once the change is submitted on a real server,
+// which the fake doesn't do.
+func (s *Server) createAccessChange(w http.ResponseWriter, r *request, p *project) {
+	if r.Method != "PUT" {
+		s.methodNotAllowed(w)
+		return
+	}
+	if !s.requireCaller(w, r) {
+		return
+	}
+
+	input := new(gerrit.ProjectAccessInput)
+	if err := r.decode(input); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid input: %s", err)
+		return
+	}
+	if _, err := s.applyAccessInput(p.access, input); err != nil {
+		s.writeError(w, http.StatusUnprocessableEntity, "%s", err)
+		return
+	}
+
+	subject := input.Message
+	if subject == "" {
+		subject = "Review access change"
+	}
+	c, err := s.newChange(gerrit.ChangeInfo{
+		Project: p.info.Name,
+		Branch:  "refs/meta/config",
+		Subject: subject,
+		Owner:   r.caller.info,
+	})
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, s.changeInfo(c, false))
+}
+
+// applyAccessInput returns a copy of access with the removals and then the additions of input applied.
+// A section without permissions removes the whole section and a permission without rules
+// removes the whole permission. Sections and permissions that end up empty are dropped.
+func (s *Server) applyAccessInput(access map[string]gerrit.AccessSectionInfo, input *gerrit.ProjectAccessInput) (map[string]gerrit.AccessSectionInfo, error) {
+	result := copyAccess(access)
+
+	for ref, section := range input.Remove {
+		live, ok := result[ref]
+		if !ok {
+			continue
+		}
+		if len(section.Permissions) == 0 {
+			delete(result, ref)
+			continue
+		}
+		for name, permission := range section.Permissions {
+			if len(permission.Rules) == 0 {
+				delete(live.Permissions, name)
+				continue
+			}
+			for uuid := range permission.Rules {
+				delete(live.Permissions[name].Rules, uuid)
+			}
+			if len(live.Permissions[name].Rules) == 0 {
+				delete(live.Permissions, name)
+			}
+		}
+		if len(live.Permissions) == 0 {
+			delete(result, ref)
+		}
+	}
+
+	for ref, section := range input.Add {
+		if ref != "GLOBAL_CAPABILITIES" && !strings.HasPrefix(ref, "refs/") && !strings.HasPrefix(ref, "^refs/") {
+			return nil, fmt.Errorf("invalid ref pattern %s", ref)
+		}
+		live, ok := result[ref]
+		if !ok {
+			live = gerrit.AccessSectionInfo{Permissions: make(map[string]gerrit.PermissionInfo)}
+		}
+		for name, permission := range section.Permissions {
+			p, ok := live.Permissions[name]
+			if !ok {
+				p = gerrit.PermissionInfo{Rules: make(map[string]gerrit.PermissionRuleInfo)}
+			}
+			if strings.HasPrefix(name, "label-") {
+				p.Label = strings.TrimPrefix(name, "label-")
+			}
+			if permission.Exclusive != nil {
+				p.Exclusive = *permission.Exclusive
+			}
+			for uuid, rule := range permission.Rules {
+				if _, known := s.groups[uuid]; !known && !gerrit.IsExternalGroupUUID(uuid) {
+					return nil, fmt.Errorf("group %q doesn't exist", uuid)
+				}
+				p.Rules[uuid] = accessRule(rule)
+			}
+			live.Permissions[name] = p
+		}
+		if len(live.Permissions) > 0 {
+			result[ref] = live
+		}
+	}
+
+	return result, nil
+}
+
+// accessRule converts a rule of an access input, defaulting the action to ALLOW.
+func accessRule(input gerrit.PermissionRuleInput) gerrit.PermissionRuleInfo {
+	rule := gerrit.PermissionRuleInfo{Action: input.Action}
+	if rule.Action == "" {
+		rule.Action = "ALLOW"
+	}
+	if input.Force != nil {
+		rule.Force = *input.Force
+	}
+	if input.Min != nil {
+		rule.Min = *input.Min
+	}
+	if input.Max != nil {
+		rule.Max = *input.Max
+	}
+	return rule
+}
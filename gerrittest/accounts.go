@@ -0,0 +1,343 @@
+package gerrittest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// account is the server side state of an account.
+type account struct {
+	info     gerrit.AccountInfo
+	password string
+	active   bool
+	sshKeys  []gerrit.SSHKeyInfo
+	gpgKeys  map[string]gerrit.GpgKeyInfo
+	nextSeq  int
+}
+
+// AddAccount adds an active account to the server.
+// If info.AccountID is 0 a new ID is assigned.
+// The httpPassword is used for all authentication schemes;
+// with AuthCookie the cookie value has to be the HTTP password.
+// An empty password creates an account that cannot authenticate.
+func (s *Server) AddAccount(info gerrit.AccountInfo, httpPassword string) gerrit.AccountInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if info.AccountID == 0 {
+		info.AccountID = s.nextAccountID
+	}
+	if info.AccountID >= s.nextAccountID {
+		s.nextAccountID = info.AccountID + 1
+	}
+
+	s.accounts[info.AccountID] = &account{
+		info:     info,
+		password: httpPassword,
+		active:   true,
+		gpgKeys:  make(map[string]gerrit.GpgKeyInfo),
+		nextSeq:  1,
+	}
+	return info
+}
+
+// AddSSHKey adds an SSH key to an existing account and returns it with its sequence number.
+// It returns false if the account does not exist.
+func (s *Server) AddSSHKey(accountID int, key gerrit.SSHKeyInfo) (gerrit.SSHKeyInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.accounts[accountID]
+	if !ok {
+		return key, false
+	}
+	key.Seq = a.nextSeq
+	a.nextSeq++
+	a.sshKeys = append(a.sshKeys, key)
+	return key, true
+}
+
+// AddGPGKey adds a GPG key to an existing account.
+// The key is identified by key.ID, the short ID of the key.
+// It returns false if the account does not exist.
+func (s *Server) AddGPGKey(accountID int, key gerrit.GpgKeyInfo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.accounts[accountID]
+	if !ok {
+		return false
+	}
+	a.gpgKeys[key.ID] = key
+	return true
+}
+
+// lookupAccount resolves an account identifier like Gerrit does.
+// Supported are "self", the numeric account ID, the username and the email address.
+func (s *Server) lookupAccount(r *request, id string) *account {
+	if id == "self" || id == "me" {
+		return r.caller
+	}
+	if n, err := strconv.Atoi(id); err == nil {
+		return s.accounts[n]
+	}
+	for _, a := range s.accounts {
+		if a.info.Username == id || (a.info.Email != "" && a.info.Email == id) {
+			return a
+		}
+	}
+	return nil
+}
+
+// sortedAccounts returns all accounts sorted by account ID.
+func (s *Server) sortedAccounts() []*account {
+	accounts := make([]*account, 0, len(s.accounts))
+	for _, a := range s.accounts {
+		accounts = append(accounts, a)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].info.AccountID < accounts[j].info.AccountID })
+	return accounts
+}
+
+func (s *Server) serveAccounts(w http.ResponseWriter, r *request, segments []string) {
+	if len(segments) == 0 {
+		if r.Method != "GET" {
+			s.methodNotAllowed(w)
+			return
+		}
+		s.queryAccounts(w, r)
+		return
+	}
+
+	if segments[0] == "self" && r.caller == nil {
+		s.writeError(w, http.StatusForbidden, "Authentication required")
+		return
+	}
+	a := s.lookupAccount(r, segments[0])
+	if a == nil {
+		s.writeError(w, http.StatusNotFound, "Account '%s' not found", segments[0])
+		return
+	}
+
+	if len(segments) == 1 {
+		s.writeJSON(w, http.StatusOK, a.info)
+		return
+	}
+
+	switch segments[1] {
+	case "detail":
+		s.writeJSON(w, http.StatusOK, gerrit.AccountDetailInfo{AccountInfo: a.info})
+	case "name":
+		s.writeJSON(w, http.StatusOK, a.info.Name)
+	case "username":
+		s.writeJSON(w, http.StatusOK, a.info.Username)
+	case "groups":
+		s.listAccountGroups(w, a)
+	case "active":
+		s.serveAccountActive(w, r, a)
+	case "sshkeys":
+		s.serveSSHKeys(w, r, a, segments[2:])
+	case "gpgkeys":
+		s.serveGPGKeys(w, r, a, segments[2:])
+	default:
+		s.notFound(w)
+	}
+}
+
+func (s *Server) serveAccountActive(w http.ResponseWriter, r *request, a *account) {
+	switch r.Method {
+	case "GET":
+		if !a.active {
+			s.writeNoContent(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, "ok")
+	case "PUT":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		if a.active {
+			s.writeJSON(w, http.StatusOK, "ok")
+			return
+		}
+		a.active = true
+		s.writeJSON(w, http.StatusCreated, "ok")
+	case "DELETE":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		if !a.active {
+			s.writeError(w, http.StatusConflict, "Account is already inactive")
+			return
+		}
+		a.active = false
+		s.writeNoContent(w)
+	default:
+		s.methodNotAllowed(w)
+	}
+}
+
+// listAccountGroups lists all groups a is a member of, including external groups and the system groups
+// every account is in. Like Gerrit, groups a is only an indirect member of through included groups are listed as well.
+func (s *Server) listAccountGroups(w http.ResponseWriter, a *account) {
+	member := map[string]bool{}
+	for _, g := range s.groups {
+		if g.hasMember(a.info.AccountID) {
+			member[g.info.ID] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, g := range s.groups {
+			if member[g.info.ID] {
+				continue
+			}
+			for _, inc := range g.includes {
+				if member[inc] {
+					member[g.info.ID] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	result := []gerrit.GroupInfo{}
+	for _, g := range s.sortedGroups() {
+		if member[g.info.ID] {
+			result = append(result, s.groupInfo(g, false))
+		}
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) serveSSHKeys(w http.ResponseWriter, r *request, a *account, segments []string) {
+	if len(segments) == 0 {
+		if r.Method != "GET" {
+			s.methodNotAllowed(w)
+			return
+		}
+		keys := []gerrit.SSHKeyInfo{}
+		s.writeJSON(w, http.StatusOK, append(keys, a.sshKeys...))
+		return
+	}
+
+	index := -1
+	for i, key := range a.sshKeys {
+		if strconv.Itoa(key.Seq) == segments[0] {
+			index = i
+		}
+	}
+	if index < 0 {
+		s.writeError(w, http.StatusNotFound, "Not found: %s", segments[0])
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		s.writeJSON(w, http.StatusOK, a.sshKeys[index])
+	case "DELETE":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		a.sshKeys = append(a.sshKeys[:index], a.sshKeys[index+1:]...)
+		s.writeNoContent(w)
+	default:
+		s.methodNotAllowed(w)
+	}
+}
+
+func (s *Server) serveGPGKeys(w http.ResponseWriter, r *request, a *account, segments []string) {
+	if len(segments) == 0 {
+		if r.Method != "GET" {
+			s.methodNotAllowed(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, a.gpgKeys)
+		return
+	}
+
+	key, ok := a.gpgKeys[segments[0]]
+	if !ok {
+		s.writeError(w, http.StatusNotFound, "Not found: %s", segments[0])
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		s.writeJSON(w, http.StatusOK, key)
+	case "DELETE":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		delete(a.gpgKeys, segments[0])
+		s.writeNoContent(w)
+	default:
+		s.methodNotAllowed(w)
+	}
+}
+
+// queryAccounts answers account queries and suggestions.
+// Supported operators are name:, email:, username: and is:active / is:inactive.
+// Free text matches name, email and username as a substring.
+func (s *Server) queryAccounts(w http.ResponseWriter, r *request) {
+	q := r.URL.Query()
+
+	var matches []gerrit.AccountInfo
+	for _, a := range s.sortedAccounts() {
+		if accountMatches(a, q.Get("q")) {
+			info := a.info
+			info.Inactive = !a.active
+			matches = append(matches, info)
+		}
+	}
+
+	start, end, more := paginate(q, len(matches))
+	result := []gerrit.AccountInfo{}
+	if len(matches) > 0 {
+		result = matches[start:end]
+	}
+	if more && len(result) > 0 {
+		result[len(result)-1].MoreAccounts = true
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+func accountMatches(a *account, query string) bool {
+	for _, term := range strings.Fields(query) {
+		op, value := "", term
+		if i := strings.Index(term, ":"); i > 0 {
+			op, value = term[:i], strings.Trim(term[i+1:], `"`)
+		}
+		value = strings.ToLower(value)
+
+		switch op {
+		case "name":
+			if !strings.Contains(strings.ToLower(a.info.Name), value) {
+				return false
+			}
+		case "email":
+			if !strings.Contains(strings.ToLower(a.info.Email), value) {
+				return false
+			}
+		case "username":
+			if strings.ToLower(a.info.Username) != value {
+				return false
+			}
+		case "is":
+			if (value == "active") != a.active {
+				return false
+			}
+		default:
+			text := strings.ToLower(a.info.Name + " " + a.info.Email + " " + a.info.Username)
+			if !strings.Contains(text, strings.ToLower(term)) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package gerrittest
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// AuthMode defines how requests to the authenticated "/a/" endpoints need to authenticate.
+type AuthMode int
+
+const (
+	// AuthBasic requires HTTP Basic authentication with username and HTTP password.
+	AuthBasic AuthMode = iota
+	// AuthDigest requires HTTP Digest authentication with username and HTTP password.
+	AuthDigest
+	// AuthCookie requires a HTTP Cookie with the HTTP password as value.
+	AuthCookie
+)
+
+// authState contains the authentication configuration of the server.
+type authState struct {
+	mode       AuthMode
+	cookieName string
+	realm      string
+	nonces     map[string]bool
+}
+
+// SetAuthMode sets the authentication scheme requests to "/a/" endpoints have to use.
+// The default is AuthBasic.
+func (s *Server) SetAuthMode(mode AuthMode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auth.mode = mode
+}
+
+// SetCookieName sets the name of the cookie which is checked for AuthCookie.
+// The default is "o", as used by googlesource.com.
+func (s *Server) SetCookieName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auth.cookieName = name
+}
+
+// authenticate identifies the calling account for requests to "/a/" endpoints.
+// If the request is not authenticated an error response is written and false is returned.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (*account, bool) {
+	var a *account
+	switch s.auth.mode {
+	case AuthBasic:
+		if username, password, ok := r.BasicAuth(); ok {
+			a = s.accountByCredentials(username, password)
+		}
+	case AuthCookie:
+		if cookie, err := r.Cookie(s.auth.cookieName); err == nil {
+			a = s.accountByCookie(cookie.Value)
+		}
+	case AuthDigest:
+		a = s.verifyDigest(r)
+		if a == nil {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="%s", qop="auth", nonce="%s"`, s.auth.realm, s.newNonce()))
+		}
+	}
+
+	if a == nil || !a.active {
+		s.writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+	return a, true
+}
+
+func (s *Server) accountByCredentials(username, password string) *account {
+	for _, a := range s.accounts {
+		if a.info.Username == username && a.password != "" && a.password == password {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *Server) accountByCookie(value string) *account {
+	for _, a := range s.accounts {
+		if a.password != "" && a.password == value {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *Server) newNonce() string {
+	b := make([]byte, 16)
+	io.ReadFull(rand.Reader, b)
+	nonce := hex.EncodeToString(b)
+	s.auth.nonces[nonce] = true
+	return nonce
+}
+
+// verifyDigest checks the Authorization header of r against the RFC 2617 digest
+// of the matching account and returns it on success.
+func (s *Server) verifyDigest(r *http.Request) *account {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Digest ") {
+		return nil
+	}
+
+	fields := map[string]string{}
+	for _, field := range strings.Split(strings.TrimPrefix(header, "Digest "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		fields[kv[0]] = strings.Trim(kv[1], `"`)
+	}
+
+	if !s.auth.nonces[fields["nonce"]] || fields["realm"] != s.auth.realm {
+		return nil
+	}
+
+	for _, a := range s.accounts {
+		if a.info.Username != fields["username"] || a.password == "" {
+			continue
+		}
+
+		ha1 := md5hex(fmt.Sprintf("%s:%s:%s", a.info.Username, s.auth.realm, a.password))
+		ha2 := md5hex(fmt.Sprintf("%s:%s", r.Method, fields["uri"]))
+		want := md5hex(strings.Join([]string{ha1, fields["nonce"], fields["nc"], fields["cnonce"], fields["qop"], ha2}, ":"))
+		if want == fields["response"] {
+			return a
+		}
+	}
+	return nil
+}
+
+func md5hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
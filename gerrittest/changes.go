@@ -0,0 +1,784 @@
+package gerrittest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// labelRanges contains the labels the fake server knows and their allowed voting range.
+var labelRanges = map[string][2]int{
+	"Code-Review": {-2, 2},
+	"Verified":    {-1, 1},
+}
+
+// change is the server side state of a change.
+type change struct {
+	info      gerrit.ChangeInfo
+	revisions []gerrit.RevisionInfo
+	shas      []string
+
+	// votes maps label name to account ID to vote.
+	votes     map[string]map[int]int
+	reviewers map[int]bool
+	comments  []gerrit.CommentInfo
+	messages  []gerrit.ChangeMessageInfo
+}
+
+func (c *change) currentRevision() string {
+	return c.shas[len(c.shas)-1]
+}
+
+// revisionIndex resolves a revision ID ("current", a patch set number or a (abbreviated) commit SHA).
+func (c *change) revisionIndex(id string) int {
+	if id == "current" || id == "" {
+		return len(c.shas) - 1
+	}
+	if n, err := strconv.Atoi(id); err == nil && n > 0 && n <= len(c.shas) && len(id) < 4 {
+		return n - 1
+	}
+	for i, sha := range c.shas {
+		if len(id) >= 4 && strings.HasPrefix(sha, id) {
+			return i
+		}
+	}
+	return -1
+}
+
+// AddChange adds a change to the server and returns it.
+// Project and Branch are required; the project must exist.
+// Number, ChangeID, ID, Status, Created and Updated are filled in if empty.
+// The change gets a first patch set uploaded by the owner.
+func (s *Server) AddChange(info gerrit.ChangeInfo) (gerrit.ChangeInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.newChange(info)
+	if err != nil {
+		return gerrit.ChangeInfo{}, err
+	}
+	return s.changeInfo(c, false), nil
+}
+
+// AddRevision uploads a new patch set to an existing change and returns its commit SHA.
+func (s *Server) AddRevision(changeNumber int, rev gerrit.RevisionInfo) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.changes[changeNumber]
+	if !ok {
+		return "", fmt.Errorf("change %d does not exist", changeNumber)
+	}
+	return s.addRevision(c, rev), nil
+}
+
+// newChange registers a change.
+// The caller must hold s.mu.
+func (s *Server) newChange(info gerrit.ChangeInfo) (*change, error) {
+	p, ok := s.projects[info.Project]
+	if !ok {
+		return nil, fmt.Errorf("project %q does not exist", info.Project)
+	}
+	if info.Branch == "" {
+		return nil, fmt.Errorf("branch is required")
+	}
+	if _, ok := p.branches[branchRef(info.Branch)]; !ok {
+		return nil, fmt.Errorf("branch %q does not exist in project %q", info.Branch, info.Project)
+	}
+
+	if info.Number == 0 {
+		info.Number = s.nextChangeNumber
+	}
+	if info.Number >= s.nextChangeNumber {
+		s.nextChangeNumber = info.Number + 1
+	}
+	if _, exists := s.changes[info.Number]; exists {
+		return nil, fmt.Errorf("change %d already exists", info.Number)
+	}
+
+	info.Branch = strings.TrimPrefix(info.Branch, "refs/heads/")
+	if info.ChangeID == "" {
+		info.ChangeID = "I" + fakeSHA1("change", strconv.Itoa(info.Number))
+	}
+	info.ID = fmt.Sprintf("%s~%s~%s", strings.Replace(info.Project, "/", "%2F", -1), info.Branch, info.ChangeID)
+	if info.Status == "" {
+		info.Status = "NEW"
+	}
+	now := s.timestamp()
+	if info.Created == "" {
+		info.Created = now
+	}
+	if info.Updated == "" {
+		info.Updated = info.Created
+	}
+
+	c := &change{
+		info:      info,
+		votes:     make(map[string]map[int]int),
+		reviewers: make(map[int]bool),
+	}
+	s.addRevision(c, gerrit.RevisionInfo{Uploader: info.Owner})
+	s.changes[info.Number] = c
+	return c, nil
+}
+
+// addRevision appends a patch set to c.
+// The caller must hold s.mu.
+func (s *Server) addRevision(c *change, rev gerrit.RevisionInfo) string {
+	number := len(c.revisions) + 1
+	sha := rev.Commit.Commit
+	if sha == "" {
+		sha = fakeSHA1("revision", strconv.Itoa(c.info.Number), strconv.Itoa(number))
+	}
+
+	rev.Number = number
+	rev.Commit.Commit = ""
+	if rev.Created == "" {
+		rev.Created = s.timestamp()
+	}
+	if rev.Uploader.AccountID == 0 {
+		rev.Uploader = c.info.Owner
+	}
+	rev.Ref = fmt.Sprintf("refs/changes/%02d/%d/%d", c.info.Number%100, c.info.Number, number)
+	if rev.Commit.Subject == "" {
+		rev.Commit.Subject = c.info.Subject
+		rev.Commit.Message = c.info.Subject + "\n\nChange-Id: " + c.info.ChangeID + "\n"
+	}
+
+	c.revisions = append(c.revisions, rev)
+	c.shas = append(c.shas, sha)
+	c.info.Updated = rev.Created
+	return sha
+}
+
+// lookupChange resolves a change identifier.
+// Supported are the numeric change number, the Change-Id, "project~branch~Change-Id" and "project~number".
+func (s *Server) lookupChange(id string) *change {
+	if n, err := strconv.Atoi(id); err == nil {
+		return s.changes[n]
+	}
+
+	parts := strings.Split(id, "~")
+	if len(parts) == 2 {
+		n, err := strconv.Atoi(parts[1])
+		if c, ok := s.changes[n]; err == nil && ok && c.info.Project == parts[0] {
+			return c
+		}
+		return nil
+	}
+
+	for _, c := range s.changes {
+		switch len(parts) {
+		case 1:
+			if c.info.ChangeID == id {
+				return c
+			}
+		case 3:
+			if c.info.Project == parts[0] && c.info.Branch == strings.TrimPrefix(parts[1], "refs/heads/") && c.info.ChangeID == parts[2] {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// changeInfo renders c.
+// With detail the labels, messages and all revisions are included.
+func (s *Server) changeInfo(c *change, detail bool, options ...string) gerrit.ChangeInfo {
+	info := c.info
+	info.Mergeable = info.Status == "NEW"
+
+	opts := map[string]bool{}
+	for _, o := range options {
+		opts[o] = true
+	}
+
+	if detail || opts["LABELS"] || opts["DETAILED_LABELS"] {
+		info.Labels = s.labels(c, detail || opts["DETAILED_LABELS"])
+	}
+	if detail || opts["MESSAGES"] {
+		info.Messages = c.messages
+	}
+	if opts["CURRENT_REVISION"] || opts["ALL_REVISIONS"] {
+		info.CurrentRevision = c.currentRevision()
+		info.Revisions = make(map[string]gerrit.RevisionInfo)
+		for i, rev := range c.revisions {
+			if opts["ALL_REVISIONS"] || i == len(c.revisions)-1 {
+				info.Revisions[c.shas[i]] = rev
+			}
+		}
+	}
+	return info
+}
+
+// labels renders the votes of c into the go-gerrit Labels type.
+func (s *Server) labels(c *change, detailed bool) gerrit.Labels {
+	render := func(name string) gerrit.LabelInfo {
+		label := gerrit.LabelInfo{}
+		r := labelRanges[name]
+
+		var ids []int
+		for id := range c.votes[name] {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			value := c.votes[name][id]
+			a := s.accounts[id].info
+			switch {
+			case value == r[0]:
+				label.Rejected = a
+			case value == r[1]:
+				label.Approved = a
+			case value < 0:
+				label.Disliked = a
+			case value > 0:
+				label.Recommended = a
+			}
+			if detailed {
+				label.All = append(label.All, gerrit.ApprovalInfo{AccountInfo: a, Value: value})
+			}
+		}
+		// A blocking vote always wins over an approval.
+		if label.Rejected.AccountID != 0 {
+			label.Approved = gerrit.AccountInfo{}
+		}
+
+		if detailed {
+			label.Values = make(map[string]string)
+			for v := r[0]; v <= r[1]; v++ {
+				label.Values[formatVote(v)] = ""
+			}
+		}
+		return label
+	}
+
+	return gerrit.Labels{
+		CodeReview: render("Code-Review"),
+		Verified:   render("Verified"),
+	}
+}
+
+// formatVote formats a vote like Gerrit does, e.g. "+2", " 0" or "-1".
+func formatVote(v int) string {
+	switch {
+	case v > 0:
+		return fmt.Sprintf("+%d", v)
+	case v == 0:
+		return " 0"
+	}
+	return strconv.Itoa(v)
+}
+
+func (s *Server) serveChanges(w http.ResponseWriter, r *request, segments []string) {
+	if len(segments) == 0 {
+		switch r.Method {
+		case "GET":
+			s.queryChanges(w, r)
+		case "POST":
+			s.createChange(w, r)
+		default:
+			s.methodNotAllowed(w)
+		}
+		return
+	}
+
+	c := s.lookupChange(segments[0])
+	if c == nil {
+		s.writeError(w, http.StatusNotFound, "Not found: %s", segments[0])
+		return
+	}
+
+	if len(segments) == 1 {
+		if r.Method != "GET" {
+			s.methodNotAllowed(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.changeInfo(c, false, r.URL.Query()["o"]...))
+		return
+	}
+
+	switch segments[1] {
+	case "detail":
+		s.writeJSON(w, http.StatusOK, s.changeInfo(c, true, r.URL.Query()["o"]...))
+	case "topic":
+		s.serveTopic(w, r, c)
+	case "abandon":
+		s.setChangeStatus(w, r, c, "NEW", "ABANDONED", "Abandoned")
+	case "restore":
+		s.setChangeStatus(w, r, c, "ABANDONED", "NEW", "Restored")
+	case "submit":
+		s.submitChange(w, r, c)
+	case "comments":
+		s.listComments(w, c, -1)
+	case "reviewers":
+		s.serveReviewers(w, r, c, segments[2:])
+	case "revisions":
+		s.serveRevisions(w, r, c, segments[2:])
+	default:
+		s.notFound(w)
+	}
+}
+
+// queryChanges answers change queries.
+// Multiple q parameters result in a list of result lists, like Gerrit does.
+func (s *Server) queryChanges(w http.ResponseWriter, r *request) {
+	q := r.URL.Query()
+	queries := q["q"]
+	if len(queries) == 0 {
+		queries = []string{"status:open"}
+	}
+
+	var results [][]gerrit.ChangeInfo
+	for _, query := range queries {
+		matches, err := s.matchChanges(r, query)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "%s", err)
+			return
+		}
+
+		start, end, more := paginate(q, len(matches))
+		result := []gerrit.ChangeInfo{}
+		for _, c := range matches[start:end] {
+			result = append(result, s.changeInfo(c, false, q["o"]...))
+		}
+		if more && len(result) > 0 {
+			result[len(result)-1].MoreChanges = true
+		}
+		results = append(results, result)
+	}
+
+	if len(results) == 1 {
+		s.writeJSON(w, http.StatusOK, results[0])
+		return
+	}
+	s.writeJSON(w, http.StatusOK, results)
+}
+
+// matchChanges returns all changes matching query, most recently updated first.
+// All terms of the query are combined with AND.
+func (s *Server) matchChanges(r *request, query string) ([]*change, error) {
+	type predicate func(c *change) bool
+	var predicates []predicate
+
+	for _, term := range strings.Fields(query) {
+		op, value := "", term
+		if i := strings.Index(term, ":"); i > 0 {
+			op, value = term[:i], strings.Trim(term[i+1:], `"{}`)
+		}
+
+		switch op {
+		case "status", "is":
+			switch value {
+			case "open", "new", "pending":
+				predicates = append(predicates, func(c *change) bool { return c.info.Status == "NEW" })
+			case "closed":
+				predicates = append(predicates, func(c *change) bool { return c.info.Status != "NEW" })
+			case "merged", "abandoned":
+				status := strings.ToUpper(value)
+				predicates = append(predicates, func(c *change) bool { return c.info.Status == status })
+			default:
+				return nil, fmt.Errorf("unsupported operator %s", term)
+			}
+		case "project":
+			predicates = append(predicates, func(c *change) bool { return c.info.Project == value })
+		case "branch":
+			branch := strings.TrimPrefix(value, "refs/heads/")
+			predicates = append(predicates, func(c *change) bool { return c.info.Branch == branch })
+		case "topic":
+			predicates = append(predicates, func(c *change) bool { return c.info.Topic == value })
+		case "owner":
+			a := s.lookupAccount(r, value)
+			predicates = append(predicates, func(c *change) bool { return a != nil && c.info.Owner.AccountID == a.info.AccountID })
+		case "reviewer":
+			a := s.lookupAccount(r, value)
+			predicates = append(predicates, func(c *change) bool { return a != nil && c.reviewers[a.info.AccountID] })
+		case "change", "":
+			predicates = append(predicates, func(c *change) bool {
+				return strconv.Itoa(c.info.Number) == value || c.info.ChangeID == value
+			})
+		default:
+			return nil, fmt.Errorf("unsupported operator %s", term)
+		}
+	}
+
+	var matches []*change
+	for _, c := range s.changes {
+		ok := true
+		for _, p := range predicates {
+			if !p(c) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matches = append(matches, c)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].info.Updated != matches[j].info.Updated {
+			return matches[i].info.Updated > matches[j].info.Updated
+		}
+		return matches[i].info.Number > matches[j].info.Number
+	})
+	return matches, nil
+}
+
+func (s *Server) createChange(w http.ResponseWriter, r *request) {
+	if !s.requireCaller(w, r) {
+		return
+	}
+
+	input := new(gerrit.ChangeInfo)
+	if err := r.decode(input); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid input: %s", err)
+		return
+	}
+	if input.Subject == "" {
+		s.writeError(w, http.StatusBadRequest, "commit message must be non-empty")
+		return
+	}
+
+	c, err := s.newChange(gerrit.ChangeInfo{
+		Project: input.Project,
+		Branch:  input.Branch,
+		Subject: input.Subject,
+		Topic:   input.Topic,
+		Status:  input.Status,
+		Owner:   r.caller.info,
+	})
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "%s", err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, s.changeInfo(c, false))
+}
+
+func (s *Server) serveTopic(w http.ResponseWriter, r *request, c *change) {
+	switch r.Method {
+	case "GET":
+		s.writeJSON(w, http.StatusOK, c.info.Topic)
+	case "PUT":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		input := new(gerrit.TopicInput)
+		if err := r.decode(input); err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid input: %s", err)
+			return
+		}
+		c.info.Topic = input.Topic
+		if input.Topic == "" {
+			s.writeNoContent(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, input.Topic)
+	case "DELETE":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		c.info.Topic = ""
+		s.writeNoContent(w)
+	default:
+		s.methodNotAllowed(w)
+	}
+}
+
+func (s *Server) setChangeStatus(w http.ResponseWriter, r *request, c *change, from, to, verb string) {
+	if r.Method != "POST" {
+		s.methodNotAllowed(w)
+		return
+	}
+	if !s.requireCaller(w, r) {
+		return
+	}
+	if c.info.Status != from {
+		s.writeError(w, http.StatusConflict, "change is %s", strings.ToLower(c.info.Status))
+		return
+	}
+
+	input := new(gerrit.AbandonInput)
+	if err := r.decode(input); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid input: %s", err)
+		return
+	}
+
+	c.info.Status = to
+	message := verb
+	if input.Message != "" {
+		message += "\n\n" + input.Message
+	}
+	s.addMessage(c, r.caller, message)
+	s.writeJSON(w, http.StatusOK, s.changeInfo(c, false))
+}
+
+// submitChange merges a change if it is approved.
+// Like the default submit rule, it requires a maximum Code-Review vote and no minimum vote.
+func (s *Server) submitChange(w http.ResponseWriter, r *request, c *change) {
+	if r.Method != "POST" {
+		s.methodNotAllowed(w)
+		return
+	}
+	if !s.requireCaller(w, r) {
+		return
+	}
+	if c.info.Status != "NEW" {
+		s.writeError(w, http.StatusConflict, "change is %s", strings.ToLower(c.info.Status))
+		return
+	}
+
+	labels := s.labels(c, false)
+	if labels.CodeReview.Approved.AccountID == 0 {
+		s.writeError(w, http.StatusConflict, "submit requirement not satisfied: Code-Review")
+		return
+	}
+
+	c.info.Status = "MERGED"
+	s.addMessage(c, r.caller, fmt.Sprintf("Change has been successfully merged by %s", r.caller.info.Name))
+	s.writeJSON(w, http.StatusOK, s.changeInfo(c, false))
+}
+
+func (s *Server) addMessage(c *change, author *account, message string) {
+	now := s.timestamp()
+	c.messages = append(c.messages, gerrit.ChangeMessageInfo{
+		ID:             fakeSHA1("message", strconv.Itoa(c.info.Number), strconv.Itoa(len(c.messages))),
+		Author:         author.info,
+		Date:           now,
+		Message:        message,
+		RevisionNumber: len(c.revisions),
+	})
+	c.info.Updated = now
+}
+
+func (s *Server) serveReviewers(w http.ResponseWriter, r *request, c *change, segments []string) {
+	if len(segments) == 0 {
+		switch r.Method {
+		case "GET":
+			s.writeJSON(w, http.StatusOK, s.reviewerInfos(c))
+		case "POST":
+			s.addReviewer(w, r, c)
+		default:
+			s.methodNotAllowed(w)
+		}
+		return
+	}
+
+	a := s.lookupAccount(r, segments[0])
+	if a == nil || !c.reviewers[a.info.AccountID] {
+		s.writeError(w, http.StatusNotFound, "Not found: %s", segments[0])
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		s.writeJSON(w, http.StatusOK, []gerrit.ReviewerInfo{s.reviewerInfo(c, a)})
+	case "DELETE":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		delete(c.reviewers, a.info.AccountID)
+		for _, votes := range c.votes {
+			delete(votes, a.info.AccountID)
+		}
+		s.writeNoContent(w)
+	default:
+		s.methodNotAllowed(w)
+	}
+}
+
+func (s *Server) reviewerInfo(c *change, a *account) gerrit.ReviewerInfo {
+	approvals := make(map[string]string)
+	for label, votes := range c.votes {
+		if v, ok := votes[a.info.AccountID]; ok {
+			approvals[label] = formatVote(v)
+		}
+	}
+	return gerrit.ReviewerInfo{AccountInfo: a.info, Approvals: approvals}
+}
+
+func (s *Server) reviewerInfos(c *change) []gerrit.ReviewerInfo {
+	result := []gerrit.ReviewerInfo{}
+	for _, a := range s.sortedAccounts() {
+		if c.reviewers[a.info.AccountID] {
+			result = append(result, s.reviewerInfo(c, a))
+		}
+	}
+	return result
+}
+
+// addReviewer adds an account or all members of a group as reviewers.
+func (s *Server) addReviewer(w http.ResponseWriter, r *request, c *change) {
+	if !s.requireCaller(w, r) {
+		return
+	}
+
+	input := new(gerrit.ReviewerInput)
+	if err := r.decode(input); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid input: %s", err)
+		return
+	}
+
+	var accounts []*account
+	if a := s.lookupAccount(r, input.Reviewer); a != nil {
+		accounts = append(accounts, a)
+	} else if g := s.lookupGroup(input.Reviewer); g != nil {
+		for _, m := range s.groupMembers(g, true) {
+			accounts = append(accounts, s.accounts[m.AccountID])
+		}
+	} else {
+		s.writeJSON(w, http.StatusOK, gerrit.AddReviewerResult{
+			Error: fmt.Sprintf("%s does not identify a registered user or group", input.Reviewer),
+		})
+		return
+	}
+
+	result := gerrit.AddReviewerResult{}
+	for _, a := range accounts {
+		if !c.reviewers[a.info.AccountID] {
+			c.reviewers[a.info.AccountID] = true
+			result.Reviewers = append(result.Reviewers, s.reviewerInfo(c, a))
+		}
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) serveRevisions(w http.ResponseWriter, r *request, c *change, segments []string) {
+	if len(segments) < 2 {
+		s.notFound(w)
+		return
+	}
+
+	index := c.revisionIndex(segments[0])
+	if index < 0 {
+		s.writeError(w, http.StatusNotFound, "Not found: %s", segments[0])
+		return
+	}
+
+	switch segments[1] {
+	case "review":
+		switch r.Method {
+		case "GET":
+			s.writeJSON(w, http.StatusOK, s.changeInfo(c, true, "CURRENT_REVISION"))
+		case "POST":
+			s.setReview(w, r, c, index)
+		default:
+			s.methodNotAllowed(w)
+		}
+	case "comments":
+		s.listComments(w, c, index+1)
+	case "commit":
+		commit := c.revisions[index].Commit
+		commit.Commit = c.shas[index]
+		s.writeJSON(w, http.StatusOK, commit)
+	default:
+		s.notFound(w)
+	}
+}
+
+// setReview applies votes, inline comments and a message to a revision.
+func (s *Server) setReview(w http.ResponseWriter, r *request, c *change, index int) {
+	if !s.requireCaller(w, r) {
+		return
+	}
+
+	input := new(gerrit.ReviewInput)
+	if err := r.decode(input); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid input: %s", err)
+		return
+	}
+
+	votes := make(map[string]int)
+	for label, value := range input.Labels {
+		allowed, ok := labelRanges[label]
+		if !ok {
+			s.writeError(w, http.StatusBadRequest, "label \"%s\" is not a configured label", label)
+			return
+		}
+		v, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || v < allowed[0] || v > allowed[1] {
+			s.writeError(w, http.StatusBadRequest, "label \"%s\": %s is not a valid value", label, value)
+			return
+		}
+		votes[label] = v
+	}
+
+	if index != len(c.revisions)-1 && len(votes) > 0 {
+		s.writeError(w, http.StatusConflict, "cannot post review on outdated patch set")
+		return
+	}
+
+	id := r.caller.info.AccountID
+	for label, v := range votes {
+		if c.votes[label] == nil {
+			c.votes[label] = make(map[int]int)
+		}
+		if v == 0 {
+			delete(c.votes[label], id)
+		} else {
+			c.votes[label][id] = v
+		}
+	}
+	if id != c.info.Owner.AccountID {
+		c.reviewers[id] = true
+	}
+
+	now := s.timestamp()
+	for path, comments := range input.Comments {
+		for _, in := range comments {
+			c.comments = append(c.comments, gerrit.CommentInfo{
+				PatchSet:  index + 1,
+				ID:        fakeSHA1("comment", strconv.Itoa(c.info.Number), strconv.Itoa(len(c.comments))),
+				Path:      path,
+				Side:      in.Side,
+				Line:      in.Line,
+				Range:     in.Range,
+				InReplyTo: in.InReplyTo,
+				Message:   in.Message,
+				Updated:   now,
+				Author:    r.caller.info,
+			})
+		}
+	}
+
+	message := fmt.Sprintf("Patch Set %d:", index+1)
+	var labels []string
+	for label, v := range votes {
+		labels = append(labels, fmt.Sprintf("%s%s", label, strings.TrimSpace(formatVote(v))))
+	}
+	sort.Strings(labels)
+	if len(labels) > 0 {
+		message += " " + strings.Join(labels, " ")
+	}
+	if input.Message != "" {
+		message += "\n\n" + input.Message
+	}
+	s.addMessage(c, r.caller, message)
+
+	result := gerrit.ReviewInfo{Labels: votes}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+// listComments returns the published inline comments of c, keyed by file path.
+// If patchSet is positive, only comments of this patch set are returned.
+func (s *Server) listComments(w http.ResponseWriter, c *change, patchSet int) {
+	result := make(map[string][]gerrit.CommentInfo)
+	for _, comment := range c.comments {
+		if patchSet > 0 && comment.PatchSet != patchSet {
+			continue
+		}
+		if patchSet > 0 {
+			// Per revision listings don't repeat the patch set.
+			comment.PatchSet = 0
+		}
+		path := comment.Path
+		comment.Path = ""
+		result[path] = append(result[path], comment)
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
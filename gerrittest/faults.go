@@ -0,0 +1,69 @@
+package gerrittest
+
+import (
+	"strings"
+	"time"
+)
+
+// Fault describes a failure the fake server should inject into matching requests.
+type Fault struct {
+	// Method limits the fault to requests with this HTTP method.
+	// An empty method matches all requests.
+	Method string
+
+	// Path limits the fault to requests whose escaped path (without the "/a" prefix) starts with Path,
+	// e.g. "/changes/" or "/projects/go/branches/".
+	// An empty path matches all requests.
+	Path string
+
+	// Status is the HTTP status code of the injected response.
+	// If Status is 0 the request is handled normally after Delay.
+	Status int
+
+	// Body is the plain text body of the injected response.
+	Body string
+
+	// Delay is applied before the request is answered.
+	Delay time.Duration
+
+	// Times is the number of requests the fault applies to.
+	// If Times is 0 the fault applies to all matching requests until ClearFaults is called.
+	Times int
+}
+
+// InjectFault registers a fault.
+// Faults are checked in the order they were injected and the first matching fault wins.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all registered faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// matchFault returns the first fault matching the request and consumes one use of it.
+// The caller must hold s.mu.
+func (s *Server) matchFault(method, path string) *Fault {
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != method {
+			continue
+		}
+		if f.Path != "" && !strings.HasPrefix(path, f.Path) {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
@@ -0,0 +1,525 @@
+package gerrittest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// UUIDs of the system groups every server has.
+const (
+	anonymousUsersUUID  = "global:Anonymous-Users"
+	registeredUsersUUID = "global:Registered-Users"
+)
+
+// group is the server side state of a Gerrit group.
+// Groups with a scheme prefix in their UUID, e.g. "ldap:" or "global:", are external groups.
+type group struct {
+	info     gerrit.GroupInfo
+	members  []int
+	includes []string
+}
+
+// external reports if the members of g are managed outside of Gerrit.
+func (g *group) external() bool {
+	return gerrit.IsExternalGroupUUID(g.info.ID)
+}
+
+// hasMember reports if accountID is a direct member of g.
+// Every account is a member of the Anonymous Users and Registered Users system groups.
+func (g *group) hasMember(accountID int) bool {
+	if g.info.ID == anonymousUsersUUID || g.info.ID == registeredUsersUUID {
+		return true
+	}
+	for _, id := range g.members {
+		if id == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+// AddGroup adds a group to the server.
+// If info.ID is empty a UUID is generated.
+// Members and Includes of info are stored as the direct members and included groups.
+//
+// An info.ID with a scheme prefix, e.g. "ldap:cn=developers", adds an external group.
+// Its members only show up in the groups of an account, they can't be listed or modified.
+//
+// The returned group has the raw UUID, while the REST API returns it URL encoded like Gerrit does.
+func (s *Server) AddGroup(info gerrit.GroupInfo) gerrit.GroupInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g := s.newGroup(info)
+	for _, m := range info.Members {
+		g.members = append(g.members, m.AccountID)
+	}
+	for _, inc := range info.Includes {
+		g.includes = append(g.includes, inc.ID)
+	}
+	return g.info
+}
+
+// addSystemGroups registers the system groups every Gerrit server has.
+// The caller must hold s.mu.
+func (s *Server) addSystemGroups() {
+	for _, info := range []gerrit.GroupInfo{
+		{ID: anonymousUsersUUID, Name: "Anonymous Users"},
+		{ID: registeredUsersUUID, Name: "Registered Users"},
+	} {
+		s.groups[info.ID] = &group{info: info}
+	}
+}
+
+// newGroup registers a new group without members.
+// The caller must hold s.mu.
+func (s *Server) newGroup(info gerrit.GroupInfo) *group {
+	if info.GroupID == 0 && !gerrit.IsExternalGroupUUID(info.ID) {
+		info.GroupID = s.nextGroupID
+	}
+	if info.GroupID >= s.nextGroupID {
+		s.nextGroupID = info.GroupID + 1
+	}
+	if info.ID == "" {
+		info.ID = fakeSHA1("group", info.Name)
+	}
+	if info.OwnerID == "" {
+		info.OwnerID = info.ID
+		info.Owner = info.Name
+	}
+	info.URL = "#/admin/groups/uuid-" + info.ID
+	info.Members = nil
+	info.Includes = nil
+
+	g := &group{info: info}
+	s.groups[info.ID] = g
+	return g
+}
+
+// lookupGroup resolves a group by UUID, name or legacy numeric ID.
+func (s *Server) lookupGroup(id string) *group {
+	if g, ok := s.groups[id]; ok {
+		return g
+	}
+	n, numErr := strconv.Atoi(id)
+	for _, g := range s.groups {
+		if g.info.Name == id || (numErr == nil && g.info.GroupID == n) {
+			return g
+		}
+	}
+	return nil
+}
+
+// sortedGroups returns all groups sorted by name.
+func (s *Server) sortedGroups() []*group {
+	groups := make([]*group, 0, len(s.groups))
+	for _, g := range s.groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].info.Name < groups[j].info.Name })
+	return groups
+}
+
+// groupInfo renders g, optionally with its direct members and included groups.
+func (s *Server) groupInfo(g *group, detail bool) gerrit.GroupInfo {
+	info := encodeGroupInfo(g.info)
+	if detail {
+		info.Members = s.groupMembers(g, false)
+		info.Includes = s.includedGroups(g)
+	}
+	return info
+}
+
+// encodeGroupInfo URL encodes the UUIDs of info, e.g. "global%3ARegistered-Users", as Gerrit does in responses.
+func encodeGroupInfo(info gerrit.GroupInfo) gerrit.GroupInfo {
+	info.ID = url.QueryEscape(info.ID)
+	info.OwnerID = url.QueryEscape(info.OwnerID)
+	return info
+}
+
+// groupMembers returns the members of g, optionally resolving included groups recursively.
+func (s *Server) groupMembers(g *group, recursive bool) []gerrit.AccountInfo {
+	seenGroups := map[string]bool{}
+	seenAccounts := map[int]bool{}
+	members := []gerrit.AccountInfo{}
+
+	var collect func(g *group)
+	collect = func(g *group) {
+		if seenGroups[g.info.ID] || g.external() {
+			return
+		}
+		seenGroups[g.info.ID] = true
+		for _, id := range g.members {
+			if a, ok := s.accounts[id]; ok && !seenAccounts[id] {
+				seenAccounts[id] = true
+				members = append(members, a.info)
+			}
+		}
+		if recursive {
+			for _, inc := range g.includes {
+				if included, ok := s.groups[inc]; ok {
+					collect(included)
+				}
+			}
+		}
+	}
+	collect(g)
+
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Name != members[j].Name {
+			return members[i].Name < members[j].Name
+		}
+		return members[i].AccountID < members[j].AccountID
+	})
+	return members
+}
+
+func (s *Server) includedGroups(g *group) []gerrit.GroupInfo {
+	result := []gerrit.GroupInfo{}
+	for _, id := range g.includes {
+		result = append(result, s.includedGroupInfo(id))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (s *Server) serveGroups(w http.ResponseWriter, r *request, segments []string) {
+	if len(segments) == 0 {
+		if r.Method != "GET" {
+			s.methodNotAllowed(w)
+			return
+		}
+		s.listGroups(w, r)
+		return
+	}
+
+	g := s.lookupGroup(segments[0])
+	if g != nil && g.external() && r.Method != "GET" {
+		// The members of external groups are managed outside of Gerrit.
+		s.methodNotAllowed(w)
+		return
+	}
+	if len(segments) == 1 {
+		switch r.Method {
+		case "GET":
+			if g == nil {
+				s.writeError(w, http.StatusNotFound, "Not found: %s", segments[0])
+				return
+			}
+			s.writeJSON(w, http.StatusOK, s.groupInfo(g, false))
+		case "PUT":
+			s.createGroup(w, r, segments[0], g)
+		case "DELETE":
+			if g == nil {
+				s.writeError(w, http.StatusNotFound, "Not found: %s", segments[0])
+				return
+			}
+			if !s.requireCaller(w, r) {
+				return
+			}
+			delete(s.groups, g.info.ID)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			s.methodNotAllowed(w)
+		}
+		return
+	}
+
+	if g == nil {
+		s.writeError(w, http.StatusNotFound, "Not found: %s", segments[0])
+		return
+	}
+
+	switch segments[1] {
+	case "detail":
+		s.writeJSON(w, http.StatusOK, s.groupInfo(g, true))
+	case "name":
+		s.writeJSON(w, http.StatusOK, g.info.Name)
+	case "description":
+		s.writeJSON(w, http.StatusOK, g.info.Description)
+	case "options":
+		s.writeJSON(w, http.StatusOK, g.info.Options)
+	case "members":
+		s.serveGroupMembers(w, r, g, segments[2:])
+	case "members.add":
+		s.addGroupMembers(w, r, g)
+	case "members.delete":
+		s.deleteGroupMembers(w, r, g)
+	case "groups":
+		s.serveIncludedGroups(w, r, g, segments[2:])
+	case "index":
+		if r.Method != "POST" {
+			s.methodNotAllowed(w)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.notFound(w)
+	}
+}
+
+func (s *Server) listGroups(w http.ResponseWriter, r *request) {
+	q := r.URL.Query()
+	detail := false
+	for _, o := range q["o"] {
+		if o == "MEMBERS" || o == "INCLUDES" {
+			detail = true
+		}
+	}
+
+	var groups []*group
+	for _, g := range s.sortedGroups() {
+		// Only internal groups are listed.
+		if !g.external() {
+			groups = append(groups, g)
+		}
+	}
+	if name := q.Get("q"); name != "" {
+		groups = nil
+		if g := s.lookupGroup(name); g != nil {
+			groups = append(groups, g)
+		}
+	}
+
+	start, end, _ := paginate(q, len(groups))
+	result := make(map[string]gerrit.GroupInfo)
+	for _, g := range groups[start:end] {
+		info := s.groupInfo(g, detail)
+		info.Name = ""
+		result[g.info.Name] = info
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) createGroup(w http.ResponseWriter, r *request, name string, existing *group) {
+	if !s.requireCaller(w, r) {
+		return
+	}
+	if existing != nil {
+		s.writeError(w, http.StatusConflict, "group '%s' already exists", name)
+		return
+	}
+
+	input := new(gerrit.GroupInput)
+	if err := r.decode(input); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid input: %s", err)
+		return
+	}
+
+	info := gerrit.GroupInfo{
+		Name:        name,
+		Description: input.Description,
+		Options:     gerrit.GroupOptionsInfo{VisibleToAll: input.VisibleToAll},
+	}
+	if input.OwnerID != "" {
+		owner := s.lookupGroup(input.OwnerID)
+		if owner == nil {
+			s.writeError(w, http.StatusUnprocessableEntity, "Group Not Found: %s", input.OwnerID)
+			return
+		}
+		info.OwnerID = owner.info.ID
+		info.Owner = owner.info.Name
+	}
+
+	members := []int{r.caller.info.AccountID}
+	if input.Members != nil {
+		members = nil
+		for _, id := range input.Members {
+			a := s.lookupAccount(r, id)
+			if a == nil {
+				s.writeError(w, http.StatusUnprocessableEntity, "Account '%s' not found", id)
+				return
+			}
+			members = append(members, a.info.AccountID)
+		}
+	}
+
+	g := s.newGroup(info)
+	g.members = members
+	s.writeJSON(w, http.StatusCreated, s.groupInfo(g, false))
+}
+
+func (s *Server) serveGroupMembers(w http.ResponseWriter, r *request, g *group, segments []string) {
+	if len(segments) == 0 {
+		switch r.Method {
+		case "GET":
+			s.writeJSON(w, http.StatusOK, s.groupMembers(g, r.URL.Query().Get("recursive") != ""))
+		case "POST":
+			s.addGroupMembers(w, r, g)
+		default:
+			s.methodNotAllowed(w)
+		}
+		return
+	}
+
+	a := s.lookupAccount(r, segments[0])
+	if a == nil {
+		s.writeError(w, http.StatusNotFound, "Account '%s' not found", segments[0])
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		if !g.hasMember(a.info.AccountID) {
+			s.writeError(w, http.StatusNotFound, "Not found: %s", segments[0])
+			return
+		}
+		s.writeJSON(w, http.StatusOK, a.info)
+	case "PUT":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		status := http.StatusOK
+		if !g.hasMember(a.info.AccountID) {
+			g.members = append(g.members, a.info.AccountID)
+			status = http.StatusCreated
+		}
+		s.writeJSON(w, status, a.info)
+	case "DELETE":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		// Only direct members can be removed.
+		if !g.hasMember(a.info.AccountID) {
+			s.writeError(w, http.StatusNotFound, "Not found: %s", segments[0])
+			return
+		}
+		s.removeMember(g, a.info.AccountID)
+		s.writeNoContent(w)
+	default:
+		s.methodNotAllowed(w)
+	}
+}
+
+func (s *Server) removeMember(g *group, accountID int) {
+	for i, id := range g.members {
+		if id == accountID {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			return
+		}
+	}
+}
+
+// resolveMembersInput resolves all accounts of a MembersInput.
+// It writes an error response and returns nil if an account does not exist.
+func (s *Server) resolveMembersInput(w http.ResponseWriter, r *request) []*account {
+	input := new(gerrit.MembersInput)
+	if err := r.decode(input); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid input: %s", err)
+		return nil
+	}
+
+	ids := input.Members
+	if input.OneMember != "" {
+		ids = append(ids, input.OneMember)
+	}
+
+	accounts := []*account{}
+	for _, id := range ids {
+		a := s.lookupAccount(r, id)
+		if a == nil {
+			s.writeError(w, http.StatusUnprocessableEntity, "Account '%s' not found", id)
+			return nil
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts
+}
+
+func (s *Server) addGroupMembers(w http.ResponseWriter, r *request, g *group) {
+	if !s.requireCaller(w, r) {
+		return
+	}
+	accounts := s.resolveMembersInput(w, r)
+	if accounts == nil {
+		return
+	}
+
+	result := []gerrit.AccountInfo{}
+	for _, a := range accounts {
+		if !g.hasMember(a.info.AccountID) {
+			g.members = append(g.members, a.info.AccountID)
+		}
+		result = append(result, a.info)
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) deleteGroupMembers(w http.ResponseWriter, r *request, g *group) {
+	if !s.requireCaller(w, r) {
+		return
+	}
+	accounts := s.resolveMembersInput(w, r)
+	if accounts == nil {
+		return
+	}
+
+	for _, a := range accounts {
+		s.removeMember(g, a.info.AccountID)
+	}
+	s.writeNoContent(w)
+}
+
+func (s *Server) serveIncludedGroups(w http.ResponseWriter, r *request, g *group, segments []string) {
+	if len(segments) == 0 {
+		if r.Method != "GET" {
+			s.methodNotAllowed(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.includedGroups(g))
+		return
+	}
+
+	id := segments[0]
+	if included := s.lookupGroup(id); included != nil {
+		id = included.info.ID
+	}
+
+	index := -1
+	for i, inc := range g.includes {
+		if inc == id {
+			index = i
+		}
+	}
+
+	switch r.Method {
+	case "GET":
+		if index < 0 {
+			s.writeError(w, http.StatusNotFound, "Not found: %s", segments[0])
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.includedGroupInfo(id))
+	case "PUT":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		status := http.StatusOK
+		if index < 0 {
+			g.includes = append(g.includes, id)
+			status = http.StatusCreated
+		}
+		s.writeJSON(w, status, s.includedGroupInfo(id))
+	case "DELETE":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		if index >= 0 {
+			g.includes = append(g.includes[:index], g.includes[index+1:]...)
+		}
+		s.writeNoContent(w)
+	default:
+		s.methodNotAllowed(w)
+	}
+}
+
+func (s *Server) includedGroupInfo(id string) gerrit.GroupInfo {
+	if g, ok := s.groups[id]; ok {
+		return encodeGroupInfo(g.info)
+	}
+	// Unknown external groups are only known by their UUID.
+	return gerrit.GroupInfo{ID: url.QueryEscape(id)}
+}
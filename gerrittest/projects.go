@@ -0,0 +1,381 @@
+package gerrittest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// project is the server side state of a project.
+type project struct {
+	info gerrit.ProjectInfo
+
+	// branches maps the full ref name (e.g. refs/heads/master) to the branch.
+	branches map[string]gerrit.BranchInfo
+
+	// access maps ref patterns to the local access sections.
+	access map[string]gerrit.AccessSectionInfo
+}
+
+func newProject(info gerrit.ProjectInfo) *project {
+	if info.ID == "" {
+		info.ID = strings.Replace(info.Name, "/", "%2F", -1)
+	}
+	if info.State == "" {
+		info.State = "ACTIVE"
+	}
+
+	p := &project{
+		info:     info,
+		branches: make(map[string]gerrit.BranchInfo),
+		access:   make(map[string]gerrit.AccessSectionInfo),
+	}
+	p.branches["HEAD"] = gerrit.BranchInfo{Ref: "HEAD", Revision: "master"}
+	p.branches["refs/meta/config"] = gerrit.BranchInfo{Ref: "refs/meta/config", Revision: fakeSHA1(info.Name, "refs/meta/config")}
+	return p
+}
+
+// fakeSHA1 returns a deterministic commit ID for the given parts.
+func fakeSHA1(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// branchRef expands a short branch name to a full ref.
+func branchRef(branch string) string {
+	if branch == "HEAD" || strings.HasPrefix(branch, "refs/") {
+		return branch
+	}
+	return "refs/heads/" + branch
+}
+
+// AddProject adds a project to the server.
+// If no parent is given, the project inherits from All-Projects.
+// An existing project with the same name is replaced.
+func (s *Server) AddProject(info gerrit.ProjectInfo) gerrit.ProjectInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if info.Parent == "" && info.Name != "All-Projects" {
+		info.Parent = "All-Projects"
+	}
+	p := newProject(info)
+	p.branches["refs/heads/master"] = gerrit.BranchInfo{Ref: "refs/heads/master", Revision: fakeSHA1(info.Name, "refs/heads/master"), CanDelete: true}
+	s.projects[info.Name] = p
+	return p.info
+}
+
+// AddBranch adds a branch to an existing project.
+// If the branch has no revision a deterministic one is generated.
+// It returns false if the project does not exist.
+func (s *Server) AddBranch(projectName string, branch gerrit.BranchInfo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.projects[projectName]
+	if !ok {
+		return false
+	}
+
+	branch.Ref = branchRef(branch.Ref)
+	if branch.Revision == "" {
+		branch.Revision = fakeSHA1(projectName, branch.Ref)
+	}
+	branch.CanDelete = true
+	p.branches[branch.Ref] = branch
+	return true
+}
+
+func (s *Server) serveProjects(w http.ResponseWriter, r *request, segments []string) {
+	if len(segments) == 0 {
+		if r.Method != "GET" {
+			s.methodNotAllowed(w)
+			return
+		}
+		s.listProjects(w, r)
+		return
+	}
+
+	name := segments[0]
+	p, exists := s.projects[name]
+
+	if len(segments) == 1 {
+		switch r.Method {
+		case "GET":
+			if !exists {
+				s.writeError(w, http.StatusNotFound, "Not found: %s", name)
+				return
+			}
+			s.writeJSON(w, http.StatusOK, p.info)
+		case "PUT":
+			s.createProject(w, r, name)
+		default:
+			s.methodNotAllowed(w)
+		}
+		return
+	}
+
+	if !exists {
+		s.writeError(w, http.StatusNotFound, "Not found: %s", name)
+		return
+	}
+
+	switch segments[1] {
+	case "description":
+		s.writeJSON(w, http.StatusOK, p.info.Description)
+	case "parent":
+		s.writeJSON(w, http.StatusOK, p.info.Parent)
+	case "HEAD":
+		s.writeJSON(w, http.StatusOK, branchRef(p.branches["HEAD"].Revision))
+	case "children":
+		s.listChildProjects(w, r, name)
+	case "branches":
+		s.serveBranches(w, r, p, segments[2:])
+	case "access":
+		s.serveProjectAccess(w, r, p)
+	case "access:review":
+		s.createAccessChange(w, r, p)
+	default:
+		s.notFound(w)
+	}
+}
+
+func (s *Server) listProjects(w http.ResponseWriter, r *request) {
+	q := r.URL.Query()
+
+	var re *regexp.Regexp
+	if expr := q.Get("r"); expr != "" {
+		var err error
+		re, err = regexp.Compile("^" + expr + "$")
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid regular expression: %s", err)
+			return
+		}
+	}
+
+	var names []string
+	for name := range s.projects {
+		if prefix := q.Get("p"); prefix != "" && !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if substring := q.Get("m"); substring != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(substring)) {
+			continue
+		}
+		if re != nil && !re.MatchString(name) {
+			continue
+		}
+		if b := q.Get("b"); b != "" {
+			if _, ok := s.projects[name].branches[branchRef(b)]; !ok {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	start, end, _ := paginate(q, len(names))
+	result := make(map[string]gerrit.ProjectInfo)
+	for _, name := range names[start:end] {
+		p := s.projects[name]
+		info := p.info
+		info.Name = ""
+		if q.Get("d") == "" {
+			info.Description = ""
+		}
+		if q.Get("t") == "" {
+			info.Parent = ""
+		}
+		if b := q.Get("b"); b != "" {
+			info.Branches = map[string]string{b: p.branches[branchRef(b)].Revision}
+		}
+		result[name] = info
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *request, name string) {
+	if !s.requireCaller(w, r) {
+		return
+	}
+	if _, exists := s.projects[name]; exists {
+		s.writeError(w, http.StatusConflict, "Project already exists")
+		return
+	}
+
+	input := new(gerrit.ProjectInput)
+	if err := r.decode(input); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid input: %s", err)
+		return
+	}
+	if input.Name != "" && input.Name != name {
+		s.writeError(w, http.StatusBadRequest, "name must match URL")
+		return
+	}
+
+	parent := input.Parent
+	if parent == "" {
+		parent = "All-Projects"
+	}
+	if _, ok := s.projects[parent]; !ok {
+		s.writeError(w, http.StatusUnprocessableEntity, "Parent project %q does not exist", parent)
+		return
+	}
+
+	p := newProject(gerrit.ProjectInfo{
+		Name:        name,
+		Parent:      parent,
+		Description: input.Description,
+	})
+
+	branches := input.Branches
+	if len(branches) == 0 {
+		branches = []string{"master"}
+	}
+	p.branches["HEAD"] = gerrit.BranchInfo{Ref: "HEAD", Revision: strings.TrimPrefix(branchRef(branches[0]), "refs/heads/")}
+	for _, b := range branches {
+		ref := branchRef(b)
+		p.branches[ref] = gerrit.BranchInfo{Ref: ref, Revision: fakeSHA1(name, ref), CanDelete: true}
+	}
+
+	s.projects[name] = p
+	s.writeJSON(w, http.StatusCreated, p.info)
+}
+
+func (s *Server) listChildProjects(w http.ResponseWriter, r *request, name string) {
+	recursive := r.URL.Query().Get("recursive") != ""
+
+	result := []gerrit.ProjectInfo{}
+	queue := []string{name}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		for _, child := range s.projects {
+			if child.info.Parent != parent {
+				continue
+			}
+			result = append(result, child.info)
+			if recursive {
+				queue = append(queue, child.info.Name)
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) serveBranches(w http.ResponseWriter, r *request, p *project, segments []string) {
+	if len(segments) == 0 {
+		if r.Method != "GET" {
+			s.methodNotAllowed(w)
+			return
+		}
+		s.listBranches(w, r, p)
+		return
+	}
+
+	// Branch names like refs/heads/feature/x are passed unescaped and span several segments.
+	ref := branchRef(strings.Join(segments, "/"))
+	branch, exists := p.branches[ref]
+
+	switch r.Method {
+	case "GET":
+		if !exists {
+			s.writeError(w, http.StatusNotFound, "Not found: %s", ref)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, branch)
+	case "PUT":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		if exists {
+			s.writeError(w, http.StatusConflict, "Branch %q already exists", ref)
+			return
+		}
+		input := new(gerrit.BranchInput)
+		if err := r.decode(input); err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid input: %s", err)
+			return
+		}
+		if input.Ref != "" && branchRef(input.Ref) != ref {
+			s.writeError(w, http.StatusBadRequest, "ref must match URL")
+			return
+		}
+		revision := input.Revision
+		if revision == "" {
+			revision = p.branches[branchRef(p.branches["HEAD"].Revision)].Revision
+		} else if base, ok := p.branches[branchRef(revision)]; ok {
+			revision = base.Revision
+		}
+		branch = gerrit.BranchInfo{Ref: ref, Revision: revision, CanDelete: true}
+		p.branches[ref] = branch
+		s.writeJSON(w, http.StatusCreated, branch)
+	case "DELETE":
+		if !s.requireCaller(w, r) {
+			return
+		}
+		if !exists {
+			s.writeError(w, http.StatusNotFound, "Not found: %s", ref)
+			return
+		}
+		delete(p.branches, ref)
+		s.writeNoContent(w)
+	default:
+		s.methodNotAllowed(w)
+	}
+}
+
+func (s *Server) listBranches(w http.ResponseWriter, r *request, p *project) {
+	q := r.URL.Query()
+
+	var re *regexp.Regexp
+	if expr := q.Get("r"); expr != "" {
+		var err error
+		re, err = regexp.Compile("^" + expr + "$")
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid regular expression: %s", err)
+			return
+		}
+	}
+
+	refs := []string{}
+	for ref := range p.branches {
+		if substring := q.Get("m"); substring != "" && !strings.Contains(strings.ToLower(ref), strings.ToLower(substring)) {
+			continue
+		}
+		if re != nil && !re.MatchString(ref) {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+
+	// Gerrit lists HEAD and refs/meta/config first.
+	order := func(ref string) int {
+		switch ref {
+		case "HEAD":
+			return 0
+		case "refs/meta/config":
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if order(refs[i]) != order(refs[j]) {
+			return order(refs[i]) < order(refs[j])
+		}
+		return refs[i] < refs[j]
+	})
+
+	start, end, _ := paginate(q, len(refs))
+	result := []gerrit.BranchInfo{}
+	for _, ref := range refs[start:end] {
+		result = append(result, p.branches[ref])
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,273 @@
+package gerrittest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RecorderMode defines if a Recorder talks to the network or replays a cassette.
+type RecorderMode int
+
+const (
+	// ModeReplay answers all requests from the cassette and never touches the network.
+	// Requests without a matching interaction fail.
+	ModeReplay RecorderMode = iota
+
+	// ModeRecord sends all requests to the real server and writes the interactions to the cassette.
+	// An existing cassette is overwritten.
+	ModeRecord
+)
+
+// scrubbedRequestHeaders are never written to a cassette, because they carry credentials.
+var scrubbedRequestHeaders = []string{"Authorization", "Cookie"}
+
+// scrubbedResponseHeaders are never written to a cassette, because they carry credentials.
+var scrubbedResponseHeaders = []string{"Set-Cookie", "WWW-Authenticate"}
+
+// Interaction is a single recorded HTTP request and its response.
+type Interaction struct {
+	Request  RecordedHTTPRequest  `json:"request"`
+	Response RecordedHTTPResponse `json:"response"`
+}
+
+// RecordedHTTPRequest is the request part of an Interaction.
+type RecordedHTTPRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedHTTPResponse is the response part of an Interaction.
+type RecordedHTTPResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// cassette is the on-disk format of a Recorder.
+type cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is a http.RoundTripper that records HTTP interactions into a cassette file
+// and replays them deterministically.
+//
+// Interactions are matched on method, URL path and query parameters.
+// If the same request was recorded several times, the recordings are replayed in order
+// and the last one is repeated afterwards.
+//
+// Authorization headers and cookies are scrubbed before anything is written to disk.
+//
+// A Recorder plugs into gerrit.NewClient:
+//
+//	recorder, err := gerrittest.NewRecorder("testdata/list-projects.json", gerrittest.ModeReplay)
+//	client, err := gerrit.NewClient("https://gerrit-review.googlesource.com/", recorder.Client())
+type Recorder struct {
+	// Transport is used to send requests in ModeRecord.
+	// If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	mode RecorderMode
+	path string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         map[int]bool
+}
+
+// NewRecorder returns a Recorder for the cassette at path.
+// In ModeReplay the cassette is loaded and has to exist.
+func NewRecorder(path string, mode RecorderMode) (*Recorder, error) {
+	r := &Recorder{
+		mode: mode,
+		path: path,
+		used: make(map[int]bool),
+	}
+
+	if mode == ModeReplay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		c := new(cassette)
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, fmt.Errorf("cassette %s: %v", path, err)
+		}
+		r.interactions = c.Interactions
+	}
+
+	return r, nil
+}
+
+// Client returns a http.Client that uses the Recorder as transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns the interactions of the cassette.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interactions := make([]Interaction, len(r.interactions))
+	copy(interactions, r.interactions)
+	return interactions
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeRecord {
+		return r.record(req)
+	}
+	return r.replay(req)
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	last := -1
+	for i, interaction := range r.interactions {
+		if !matches(interaction.Request, req) {
+			continue
+		}
+		last = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if last < 0 {
+		return nil, fmt.Errorf("gerrittest: no recorded interaction for %s %s in %s", req.Method, req.URL, r.path)
+	}
+	r.used[last] = true
+
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	recorded := r.interactions[last].Response
+	header := http.Header{}
+	for k, v := range recorded.Header {
+		header[k] = v
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: RecordedHTTPRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: scrub(req.Header, scrubbedRequestHeaders),
+			Body:   string(reqBody),
+		},
+		Response: RecordedHTTPResponse{
+			StatusCode: resp.StatusCode,
+			Header:     scrub(resp.Header, scrubbedResponseHeaders),
+			Body:       string(respBody),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, interaction)
+
+	// The cassette is written after every interaction,
+	// so callers don't need to remember to save it.
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// save writes the cassette to disk.
+// The caller must hold r.mu.
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(cassette{Interactions: r.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// matches reports if req matches the recorded request on method, path and query parameters.
+func matches(recorded RecordedHTTPRequest, req *http.Request) bool {
+	if recorded.Method != req.Method {
+		return false
+	}
+
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	if u.EscapedPath() != req.URL.EscapedPath() {
+		return false
+	}
+	return u.Query().Encode() == req.URL.Query().Encode()
+}
+
+// scrub returns a copy of header without the given keys.
+func scrub(header http.Header, keys []string) http.Header {
+	scrubbed := http.Header{}
+	for k, v := range header {
+		scrubbed[k] = v
+	}
+	for _, k := range keys {
+		scrubbed.Del(k)
+	}
+	if len(scrubbed) == 0 {
+		return nil
+	}
+	return scrubbed
+}
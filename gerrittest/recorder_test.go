@@ -0,0 +1,80 @@
+package gerrittest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/go-gerrit"
+	"github.com/andygrunwald/go-gerrit/gerrittest"
+)
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	server, _, _ := setupServer()
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "gerrittest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	recorder, err := gerrittest.NewRecorder(path, gerrittest.ModeRecord)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+	client, err := gerrit.NewClient(server.URL, recorder.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	recorded, _, err := client.Projects.ListProjects(&gerrit.ProjectOptions{Description: true})
+	if err != nil {
+		t.Fatalf("Projects.ListProjects returned error while recording: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Cassette was not written: %v", err)
+	}
+	if strings.Contains(string(data), "Authorization") || strings.Contains(string(data), "b3duZXI6b3duZXItc2VjcmV0") {
+		t.Errorf("Cassette contains credentials: %s", data)
+	}
+
+	// Replay without the server.
+	server.Close()
+
+	replayer, err := gerrittest.NewRecorder(path, gerrittest.ModeReplay)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+	client, err = gerrit.NewClient(server.URL, replayer.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	replayed, _, err := client.Projects.ListProjects(&gerrit.ProjectOptions{Description: true})
+	if err != nil {
+		t.Fatalf("Projects.ListProjects returned error while replaying: %v", err)
+	}
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("Replayed %+v, want %+v", replayed, recorded)
+	}
+
+	if _, _, err := client.Projects.ListProjects(&gerrit.ProjectOptions{Prefix: "go"}); err == nil {
+		t.Error("Request with different query parameters was replayed, expected an error")
+	}
+}
+
+func TestRecorder_MissingCassette(t *testing.T) {
+	_, err := gerrittest.NewRecorder(filepath.Join("testdata", "does-not-exist.json"), gerrittest.ModeReplay)
+	if err == nil {
+		t.Error("NewRecorder with a missing cassette returned no error")
+	}
+}
@@ -0,0 +1,333 @@
+/*
+Package gerrittest provides an in-process fake of the Gerrit REST API.
+
+The fake keeps its state (projects with their access rights, branches, changes
+with revisions, reviews, comments, accounts with their SSH and GPG keys, and
+groups) in memory, so client code built on go-gerrit can be exercised end-to-end
+without talking to a real Gerrit instance:
+
+	server := gerrittest.NewServer()
+	defer server.Close()
+
+	server.AddProject(gerrit.ProjectInfo{Name: "go"})
+	client := server.Client()
+
+	project, _, err := client.Projects.GetProject("go")
+
+All JSON responses carry the magic XSSI prefix line like a real Gerrit does.
+Authentication (HTTP Basic, HTTP Digest and HTTP Cookie) can be emulated with
+Server.SetAuthMode and failures can be injected with Server.InjectFault.
+*/
+package gerrittest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// magicPrefix is the XSSI protection line Gerrit puts in front of every JSON response.
+const magicPrefix = ")]}'\n"
+
+// timeLayout is the timestamp format used by the Gerrit REST API.
+const timeLayout = "2006-01-02 15:04:05.000000000"
+
+// Version is the Gerrit version the fake server claims to be.
+const Version = "2.13.1"
+
+// Server is a stateful fake Gerrit server.
+// It is safe for concurrent use.
+type Server struct {
+	// URL of the fake server, with a trailing slash.
+	URL string
+
+	// Now returns the current time and is used for all timestamps.
+	// It can be replaced to get deterministic output.
+	Now func() time.Time
+
+	// DisableMagicPrefix turns off the XSSI prefix line in JSON responses.
+	// Some plugins do not send it, which can be emulated with this.
+	DisableMagicPrefix bool
+
+	server *httptest.Server
+
+	mu               sync.Mutex
+	auth             authState
+	faults           []*Fault
+	requests         []RecordedRequest
+	projects         map[string]*project
+	accounts         map[int]*account
+	groups           map[string]*group
+	changes          map[int]*change
+	nextAccountID    int
+	nextGroupID      int
+	nextChangeNumber int
+}
+
+// RecordedRequest describes a single request the fake server has received.
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+}
+
+// NewServer starts and returns a new fake Gerrit server.
+// The server contains the All-Projects project and no other data.
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		Now:              time.Now,
+		projects:         make(map[string]*project),
+		accounts:         make(map[int]*account),
+		groups:           make(map[string]*group),
+		changes:          make(map[int]*change),
+		nextAccountID:    1000000,
+		nextGroupID:      1,
+		nextChangeNumber: 1,
+	}
+	s.auth.cookieName = "o"
+	s.auth.realm = "Gerrit Code Review"
+	s.auth.nonces = make(map[string]bool)
+
+	s.addSystemGroups()
+	s.projects["All-Projects"] = newProject(gerrit.ProjectInfo{
+		Name:        "All-Projects",
+		Description: "Access inherited by all other projects.",
+	})
+
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL + "/"
+	return s
+}
+
+// Close shuts down the server and blocks until all outstanding requests on this server have completed.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a new gerrit.Client which talks to the fake server.
+func (s *Server) Client() *gerrit.Client {
+	client, err := gerrit.NewClient(s.URL, s.server.Client())
+	if err != nil {
+		// The URL is created by httptest and always valid.
+		panic(err)
+	}
+	return client
+}
+
+// Requests returns all requests received by the server so far, in order.
+func (s *Server) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := make([]RecordedRequest, len(s.requests))
+	copy(r, s.requests)
+	return r
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.EscapedPath()
+	authenticated := false
+	if path == "/a" || strings.HasPrefix(path, "/a/") {
+		authenticated = true
+		path = strings.TrimPrefix(path, "/a")
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, RecordedRequest{
+		Method: r.Method,
+		Path:   path,
+		Query:  r.URL.Query(),
+		Header: r.Header,
+	})
+	fault := s.matchFault(r.Method, path)
+	s.mu.Unlock()
+
+	if fault != nil {
+		if fault.Delay > 0 {
+			time.Sleep(fault.Delay)
+		}
+		if fault.Status != 0 {
+			http.Error(w, fault.Body, fault.Status)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req := &request{
+		Request:  r,
+		segments: splitPath(path),
+	}
+	if authenticated {
+		caller, ok := s.authenticate(w, r)
+		if !ok {
+			return
+		}
+		req.caller = caller
+	}
+
+	s.route(w, req)
+}
+
+// request is an incoming request after the "/a/" prefix has been stripped and authentication took place.
+type request struct {
+	*http.Request
+
+	// segments are the unescaped elements of the URL path.
+	segments []string
+
+	// caller is the authenticated account or nil for anonymous requests.
+	caller *account
+}
+
+// decode JSON decodes the request body into v.
+// An empty body leaves v untouched.
+func (r *request) decode(v interface{}) error {
+	if r.Body == nil {
+		return nil
+	}
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// splitPath splits an escaped URL path into its unescaped segments.
+// Gerrit identifiers like project names are encoded with url.QueryEscape by the client,
+// so "plugins%2Fdelete-project" becomes a single segment.
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if unescaped, err := url.QueryUnescape(p); err == nil {
+			parts[i] = unescaped
+		}
+	}
+	return parts
+}
+
+func (s *Server) route(w http.ResponseWriter, r *request) {
+	if len(r.segments) == 0 {
+		s.notFound(w)
+		return
+	}
+
+	switch r.segments[0] {
+	case "projects":
+		s.serveProjects(w, r, r.segments[1:])
+	case "changes":
+		s.serveChanges(w, r, r.segments[1:])
+	case "accounts":
+		s.serveAccounts(w, r, r.segments[1:])
+	case "groups":
+		s.serveGroups(w, r, r.segments[1:])
+	case "access":
+		s.serveAccess(w, r, r.segments[1:])
+	case "config":
+		s.serveConfig(w, r, r.segments[1:])
+	default:
+		s.notFound(w)
+	}
+}
+
+func (s *Server) serveConfig(w http.ResponseWriter, r *request, segments []string) {
+	if len(segments) == 2 && segments[0] == "server" && segments[1] == "version" && r.Method == "GET" {
+		s.writeJSON(w, http.StatusOK, Version)
+		return
+	}
+	s.notFound(w)
+}
+
+// writeJSON writes v as JSON response including the magic prefix line.
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if !s.DisableMagicPrefix {
+		fmt.Fprint(w, magicPrefix)
+	}
+	w.Write(body)
+}
+
+// writeNoContent answers with "204 No Content".
+func (s *Server) writeNoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError answers with a plain text error message, the same way Gerrit does.
+func (s *Server) writeError(w http.ResponseWriter, status int, format string, a ...interface{}) {
+	http.Error(w, fmt.Sprintf(format, a...), status)
+}
+
+func (s *Server) notFound(w http.ResponseWriter) {
+	s.writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func (s *Server) methodNotAllowed(w http.ResponseWriter) {
+	s.writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+}
+
+// requireCaller ensures the request was authenticated.
+// Gerrit answers write requests of anonymous users with "403 Forbidden".
+func (s *Server) requireCaller(w http.ResponseWriter, r *request) bool {
+	if r.caller == nil {
+		s.writeError(w, http.StatusForbidden, "Authentication required")
+		return false
+	}
+	return true
+}
+
+// timestamp returns the current time in Gerrit's timestamp format.
+func (s *Server) timestamp() string {
+	return s.Now().UTC().Format(timeLayout)
+}
+
+// paginate applies the "n" (limit) and skip query parameters to a list of length n.
+// Like Gerrit, the skip is read from "S", "s" (used by the branch and tag lists) or "start".
+// It returns the bounds of the page and if there are more results after it.
+func paginate(q url.Values, n int) (start, end int, more bool) {
+	for _, name := range []string{"S", "s", "start"} {
+		if start = atoi(q.Get(name)); start != 0 {
+			break
+		}
+	}
+	if start > n {
+		start = n
+	}
+
+	end = n
+	if limit := atoi(q.Get("n")); limit > 0 && start+limit < n {
+		end = start + limit
+		more = true
+	}
+	return start, end, more
+}
+
+// atoi converts s to an int and returns 0 on failure.
+func atoi(s string) int {
+	var i int
+	fmt.Sscanf(s, "%d", &i)
+	return i
+}
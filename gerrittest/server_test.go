@@ -0,0 +1,584 @@
+package gerrittest_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-gerrit"
+	"github.com/andygrunwald/go-gerrit/gerrittest"
+)
+
+// setupServer starts a fake server with one project and two accounts.
+func setupServer() (*gerrittest.Server, gerrit.AccountInfo, gerrit.AccountInfo) {
+	server := gerrittest.NewServer()
+	server.AddProject(gerrit.ProjectInfo{Name: "go", Description: "The Go Programming Language"})
+	owner := server.AddAccount(gerrit.AccountInfo{Name: "Owner", Username: "owner", Email: "owner@example.com"}, "owner-secret")
+	reviewer := server.AddAccount(gerrit.AccountInfo{Name: "Reviewer", Username: "reviewer", Email: "reviewer@example.com"}, "reviewer-secret")
+	return server, owner, reviewer
+}
+
+func TestServer_Projects(t *testing.T) {
+	server, _, _ := setupServer()
+	defer server.Close()
+
+	client := server.Client()
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	_, _, err := client.Projects.CreateProject("plugins/delete-project", &gerrit.ProjectInput{
+		Parent:   "go",
+		Branches: []string{"main"},
+	})
+	if err != nil {
+		t.Fatalf("Projects.CreateProject returned error: %v", err)
+	}
+
+	project, _, err := client.Projects.GetProject("plugins/delete-project")
+	if err != nil {
+		t.Fatalf("Projects.GetProject returned error: %v", err)
+	}
+	want := &gerrit.ProjectInfo{
+		ID:     "plugins%2Fdelete-project",
+		Name:   "plugins/delete-project",
+		Parent: "go",
+		State:  "ACTIVE",
+	}
+	if !reflect.DeepEqual(project, want) {
+		t.Errorf("Projects.GetProject returned %+v, want %+v", project, want)
+	}
+
+	opt := &gerrit.ProjectOptions{Prefix: "plugins/"}
+	projects, _, err := client.Projects.ListProjects(opt)
+	if err != nil {
+		t.Fatalf("Projects.ListProjects returned error: %v", err)
+	}
+	if _, ok := (*projects)["plugins/delete-project"]; !ok || len(*projects) != 1 {
+		t.Errorf("Projects.ListProjects returned %+v, want only plugins/delete-project", projects)
+	}
+
+	children, _, err := client.Projects.ListChildProjects("All-Projects", &gerrit.ChildProjectOptions{Recursive: 1})
+	if err != nil {
+		t.Fatalf("Projects.ListChildProjects returned error: %v", err)
+	}
+	if len(*children) != 2 {
+		t.Errorf("Projects.ListChildProjects returned %d projects, want 2", len(*children))
+	}
+}
+
+func TestServer_Branches(t *testing.T) {
+	server, _, _ := setupServer()
+	defer server.Close()
+
+	client := server.Client()
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	branch, _, err := client.Projects.CreateBranch("go", "release-1.0", &gerrit.BranchInput{Revision: "master"})
+	if err != nil {
+		t.Fatalf("Projects.CreateBranch returned error: %v", err)
+	}
+	if branch.Ref != "refs/heads/release-1.0" {
+		t.Errorf("Projects.CreateBranch returned ref %q, want refs/heads/release-1.0", branch.Ref)
+	}
+
+	branches, _, err := client.Projects.ListBranches("go", &gerrit.BranchOptions{Substring: "release"})
+	if err != nil {
+		t.Fatalf("Projects.ListBranches returned error: %v", err)
+	}
+	if len(*branches) != 1 || (*branches)[0].Ref != "refs/heads/release-1.0" {
+		t.Errorf("Projects.ListBranches returned %+v, want only refs/heads/release-1.0", branches)
+	}
+
+	if _, err := client.Projects.DeleteBranch("go", "release-1.0"); err != nil {
+		t.Fatalf("Projects.DeleteBranch returned error: %v", err)
+	}
+	if _, resp, err := client.Projects.GetBranch("go", "release-1.0"); err == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Projects.GetBranch after deletion returned %v, want 404", err)
+	}
+}
+
+func TestServer_BranchPaging(t *testing.T) {
+	server, _, _ := setupServer()
+	defer server.Close()
+
+	client := server.Client()
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	var want []string
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("release-1.%d", i)
+		if _, _, err := client.Projects.CreateBranch("go", name, &gerrit.BranchInput{Revision: "master"}); err != nil {
+			t.Fatalf("Projects.CreateBranch returned error: %v", err)
+		}
+		want = append(want, "refs/heads/"+name)
+	}
+
+	page, _, err := client.Projects.ListBranches("go", &gerrit.BranchOptions{Substring: "release", Limit: 2, Skip: "2"})
+	if err != nil {
+		t.Fatalf("Projects.ListBranches returned error: %v", err)
+	}
+	if len(*page) != 2 || (*page)[0].Ref != want[2] {
+		t.Errorf("Projects.ListBranches returned %+v, want %v", *page, want[2:4])
+	}
+
+	branches, _, err := client.Projects.ListAllBranches("go", &gerrit.BranchOptions{Substring: "release", Limit: 2})
+	if err != nil {
+		t.Fatalf("Projects.ListAllBranches returned error: %v", err)
+	}
+	var refs []string
+	for _, b := range *branches {
+		refs = append(refs, b.Ref)
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("Projects.ListAllBranches returned %v, want %v", refs, want)
+	}
+}
+
+func TestServer_ChangeReviewAndSubmit(t *testing.T) {
+	server, _, _ := setupServer()
+	defer server.Close()
+
+	owner := server.Client()
+	owner.Authentication.SetBasicAuth("owner", "owner-secret")
+	reviewer := server.Client()
+	reviewer.Authentication.SetBasicAuth("reviewer", "reviewer-secret")
+
+	change, _, err := owner.Changes.CreateChange(&gerrit.ChangeInfo{
+		Project: "go",
+		Branch:  "master",
+		Subject: "Add fake server",
+	})
+	if err != nil {
+		t.Fatalf("Changes.CreateChange returned error: %v", err)
+	}
+
+	if _, _, err := owner.Changes.SubmitChange(change.ID, nil); err == nil {
+		t.Error("Changes.SubmitChange of an unapproved change returned no error")
+	}
+
+	input := &gerrit.ReviewInput{
+		Message: "Looks good",
+		Labels:  map[string]string{"Code-Review": "+2"},
+		Comments: map[string][]gerrit.CommentInput{
+			"server.go": {{Line: 10, Message: "Nit: typo"}},
+		},
+	}
+	review, _, err := reviewer.Changes.SetReview(change.ID, "current", input)
+	if err != nil {
+		t.Fatalf("Changes.SetReview returned error: %v", err)
+	}
+	if review.Labels["Code-Review"] != 2 {
+		t.Errorf("Changes.SetReview returned %+v, want Code-Review 2", review)
+	}
+
+	comments, _, err := owner.Changes.ListChangeComments(change.ID)
+	if err != nil {
+		t.Fatalf("Changes.ListChangeComments returned error: %v", err)
+	}
+	if c := (*comments)["server.go"]; len(c) != 1 || c[0].Message != "Nit: typo" || c[0].Author.Username != "reviewer" {
+		t.Errorf("Changes.ListChangeComments returned %+v", comments)
+	}
+
+	opt := &gerrit.QueryChangeOptions{}
+	opt.Query = []string{"project:go status:open"}
+	opt.AdditionalFields = []string{"LABELS"}
+	changes, _, err := owner.Changes.QueryChanges(opt)
+	if err != nil {
+		t.Fatalf("Changes.QueryChanges returned error: %v", err)
+	}
+	if len(*changes) != 1 || (*changes)[0].Labels.CodeReview.Approved.Username != "reviewer" {
+		t.Errorf("Changes.QueryChanges returned %+v, want one change approved by reviewer", changes)
+	}
+
+	merged, _, err := owner.Changes.SubmitChange(change.ID, nil)
+	if err != nil {
+		t.Fatalf("Changes.SubmitChange returned error: %v", err)
+	}
+	if merged.Status != "MERGED" {
+		t.Errorf("Changes.SubmitChange returned status %q, want MERGED", merged.Status)
+	}
+}
+
+func TestServer_InvalidLabel(t *testing.T) {
+	server, owner, _ := setupServer()
+	defer server.Close()
+
+	change, err := server.AddChange(gerrit.ChangeInfo{Project: "go", Branch: "master", Subject: "Test", Owner: owner})
+	if err != nil {
+		t.Fatalf("AddChange returned error: %v", err)
+	}
+
+	client := server.Client()
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	input := &gerrit.ReviewInput{Labels: map[string]string{"Code-Review": "+3"}}
+	_, resp, err := client.Changes.SetReview(change.ChangeID, "1", input)
+	if err == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Changes.SetReview with invalid vote returned %v, want 400", err)
+	}
+}
+
+func TestServer_Accounts(t *testing.T) {
+	server, owner, _ := setupServer()
+	defer server.Close()
+
+	server.AddGroup(gerrit.GroupInfo{Name: "Maintainers", Members: []gerrit.AccountInfo{owner}})
+
+	client := server.Client()
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	self, _, err := client.Accounts.GetAccount("self")
+	if err != nil {
+		t.Fatalf("Accounts.GetAccount returned error: %v", err)
+	}
+	if !reflect.DeepEqual(*self, owner) {
+		t.Errorf("Accounts.GetAccount returned %+v, want %+v", self, owner)
+	}
+
+	groups, _, err := client.Accounts.ListGroups("self")
+	if err != nil {
+		t.Fatalf("Accounts.ListGroups returned error: %v", err)
+	}
+	var names []string
+	for _, g := range *groups {
+		names = append(names, g.Name)
+	}
+	if want := []string{"Anonymous Users", "Maintainers", "Registered Users"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Accounts.ListGroups returned %v, want %v", names, want)
+	}
+
+	anonymous := server.Client()
+	if _, _, err := anonymous.Accounts.GetAccount("self"); err == nil {
+		t.Error("Accounts.GetAccount(self) without authentication returned no error")
+	}
+}
+
+func TestServer_QueryAccounts(t *testing.T) {
+	server, owner, reviewer := setupServer()
+	defer server.Close()
+
+	server.AddAccount(gerrit.AccountInfo{Name: "Other", Username: "other", Email: "other@example.org"}, "other-secret")
+
+	client := server.Client()
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	opt := &gerrit.QueryAccountOptions{}
+	opt.Query = []string{"email:example.com"}
+	opt.Limit = 1
+	accounts, _, err := client.Accounts.QueryAllAccounts(opt)
+	if err != nil {
+		t.Fatalf("Accounts.QueryAllAccounts returned error: %v", err)
+	}
+	if want := []gerrit.AccountInfo{owner, reviewer}; !reflect.DeepEqual(*accounts, want) {
+		t.Errorf("Accounts.QueryAllAccounts returned %+v, want %+v", *accounts, want)
+	}
+}
+
+func TestServer_Groups(t *testing.T) {
+	server, owner, reviewer := setupServer()
+	defer server.Close()
+
+	inner := server.AddGroup(gerrit.GroupInfo{Name: "Inner", Members: []gerrit.AccountInfo{reviewer}})
+	outer := server.AddGroup(gerrit.GroupInfo{Name: "Outer", Members: []gerrit.AccountInfo{owner}, Includes: []gerrit.GroupInfo{inner}})
+
+	client := server.Client()
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	members, _, err := client.Groups.ListGroupMembers(outer.ID, &gerrit.ListGroupMembersOptions{Recursive: true})
+	if err != nil {
+		t.Fatalf("Groups.ListGroupMembers returned error: %v", err)
+	}
+	if len(*members) != 2 {
+		t.Errorf("Groups.ListGroupMembers returned %+v, want 2 members", members)
+	}
+
+	group, _, err := client.Groups.CreateGroup("New", &gerrit.GroupInput{Description: "A new group"})
+	if err != nil {
+		t.Fatalf("Groups.CreateGroup returned error: %v", err)
+	}
+	if _, _, err := client.Groups.AddGroupMember(group.ID, "reviewer"); err != nil {
+		t.Fatalf("Groups.AddGroupMember returned error: %v", err)
+	}
+
+	detail, _, err := client.Groups.GetGroupDetail("New")
+	if err != nil {
+		t.Fatalf("Groups.GetGroupDetail returned error: %v", err)
+	}
+	if len(detail.Members) != 2 {
+		t.Errorf("Groups.GetGroupDetail returned %+v, want creator and reviewer as members", detail.Members)
+	}
+
+	withMembers, _, err := client.Groups.CreateGroup("Reviewers", &gerrit.GroupInput{Members: []string{"reviewer"}})
+	if err != nil {
+		t.Fatalf("Groups.CreateGroup returned error: %v", err)
+	}
+	if detail, _, _ := client.Groups.GetGroupDetail(withMembers.ID); len(detail.Members) != 1 || detail.Members[0].AccountID != reviewer.AccountID {
+		t.Errorf("Groups.CreateGroup with members created %+v, want reviewer as only member", detail.Members)
+	}
+	if _, err := client.Groups.IndexGroup(withMembers.ID); err != nil {
+		t.Errorf("Groups.IndexGroup returned error: %v", err)
+	}
+	if _, err := client.Groups.DeleteGroup(withMembers.ID); err != nil {
+		t.Fatalf("Groups.DeleteGroup returned error: %v", err)
+	}
+	if _, _, err := client.Groups.GetGroup(withMembers.ID); err == nil {
+		t.Error("Groups.GetGroup returned no error for a deleted group")
+	}
+}
+
+func TestServer_ExternalGroups(t *testing.T) {
+	server, owner, _ := setupServer()
+	defer server.Close()
+
+	external := server.AddGroup(gerrit.GroupInfo{ID: "ldap:cn=eng", Name: "eng", Members: []gerrit.AccountInfo{owner}})
+	maintainers := server.AddGroup(gerrit.GroupInfo{Name: "Maintainers", Includes: []gerrit.GroupInfo{external}})
+
+	client := server.Client()
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	groups, _, err := client.Accounts.ListGroups("self")
+	if err != nil {
+		t.Fatalf("Accounts.ListGroups returned error: %v", err)
+	}
+	ids := map[string]string{}
+	for _, g := range *groups {
+		ids[g.Name] = g.ID
+	}
+	// Maintainers is listed, as it includes eng.
+	want := map[string]string{
+		"Anonymous Users":  "global%3AAnonymous-Users",
+		"Maintainers":      maintainers.ID,
+		"Registered Users": "global%3ARegistered-Users",
+		"eng":              "ldap%3Acn%3Deng",
+	}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("Accounts.ListGroups returned IDs %v, want %v", ids, want)
+	}
+
+	detail, _, err := client.Groups.GetGroupDetail("Maintainers")
+	if err != nil {
+		t.Fatalf("Groups.GetGroupDetail returned error: %v", err)
+	}
+	if len(detail.Includes) != 1 || detail.Includes[0].ID != "ldap%3Acn%3Deng" {
+		t.Errorf("Groups.GetGroupDetail returned includes %+v, want the encoded external group", detail.Includes)
+	}
+
+	list, _, err := client.Groups.ListGroups(nil)
+	if err != nil {
+		t.Fatalf("Groups.ListGroups returned error: %v", err)
+	}
+	if _, ok := (*list)["eng"]; ok || len(*list) != 1 {
+		t.Errorf("Groups.ListGroups returned %v, want only the internal group", *list)
+	}
+
+	resp, err := client.Groups.DeleteGroupMember(url.QueryEscape(external.ID), "owner")
+	if err == nil || resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Groups.DeleteGroupMember of an external group returned %v, want 405", err)
+	}
+}
+
+func TestServer_Keys(t *testing.T) {
+	server, owner, _ := setupServer()
+	defer server.Close()
+
+	sshKey, _ := server.AddSSHKey(owner.AccountID, gerrit.SSHKeyInfo{SSHPublicKey: "ssh-rsa AAAA owner@laptop", Comment: "owner@laptop", Valid: true})
+	server.AddGPGKey(owner.AccountID, gerrit.GpgKeyInfo{ID: "AFC8A49B", Fingerprint: "0192 723D 42D1 0C5B 32A6  E1E0 9350 9E4B AFC8 A49B"})
+
+	client := server.Client()
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	sshKeys, _, err := client.Accounts.ListSSHKeys("self")
+	if err != nil {
+		t.Fatalf("Accounts.ListSSHKeys returned error: %v", err)
+	}
+	if want := []gerrit.SSHKeyInfo{sshKey}; !reflect.DeepEqual(*sshKeys, want) {
+		t.Errorf("Accounts.ListSSHKeys returned %+v, want %+v", *sshKeys, want)
+	}
+	if _, err := client.Accounts.DeleteSSHKey("self", "1"); err != nil {
+		t.Errorf("Accounts.DeleteSSHKey returned error: %v", err)
+	}
+	if resp, err := client.Accounts.DeleteSSHKey("self", "1"); err == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Accounts.DeleteSSHKey of a deleted key returned %v, want 404", err)
+	}
+
+	gpgKeys, _, err := client.Accounts.ListGPGKeys("self")
+	if err != nil {
+		t.Fatalf("Accounts.ListGPGKeys returned error: %v", err)
+	}
+	if _, ok := (*gpgKeys)["AFC8A49B"]; !ok || len(*gpgKeys) != 1 {
+		t.Errorf("Accounts.ListGPGKeys returned %+v, want AFC8A49B", *gpgKeys)
+	}
+	if _, err := client.Accounts.DeleteGPGKey("self", "AFC8A49B"); err != nil {
+		t.Errorf("Accounts.DeleteGPGKey returned error: %v", err)
+	}
+}
+
+func TestServer_Access(t *testing.T) {
+	server, _, _ := setupServer()
+	defer server.Close()
+
+	maintainers := server.AddGroup(gerrit.GroupInfo{Name: "Maintainers"})
+	server.SetProjectAccess("go", map[string]gerrit.AccessSectionInfo{
+		"refs/heads/*": {
+			Permissions: map[string]gerrit.PermissionInfo{
+				"push": {Rules: map[string]gerrit.PermissionRuleInfo{"global:Registered-Users": {Action: "ALLOW"}}},
+			},
+		},
+	})
+
+	client := server.Client()
+	client.Authentication.SetBasicAuth("owner", "owner-secret")
+
+	before, _, err := client.Projects.GetAccess("go")
+	if err != nil {
+		t.Fatalf("Projects.GetAccess returned error: %v", err)
+	}
+	if before.InheritsFrom.Name != "All-Projects" {
+		t.Errorf("Projects.GetAccess inherits from %q, want All-Projects", before.InheritsFrom.Name)
+	}
+
+	after, _, err := client.Projects.SetAccess("go", &gerrit.ProjectAccessInput{
+		Remove: map[string]gerrit.AccessSectionInput{"refs/heads/*": {}},
+		Add: map[string]gerrit.AccessSectionInput{
+			"refs/heads/*": {
+				Permissions: map[string]gerrit.PermissionInput{
+					"label-Code-Review": {Rules: map[string]gerrit.PermissionRuleInput{maintainers.ID: {Min: gerrit.Int(-2), Max: gerrit.Int(2)}}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Projects.SetAccess returned error: %v", err)
+	}
+	want := map[string]gerrit.AccessSectionInfo{
+		"refs/heads/*": {
+			Permissions: map[string]gerrit.PermissionInfo{
+				"label-Code-Review": {Label: "Code-Review", Rules: map[string]gerrit.PermissionRuleInfo{maintainers.ID: {Action: "ALLOW", Min: -2, Max: 2}}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(after.Local, want) {
+		t.Errorf("Projects.SetAccess returned %+v, want %+v", after.Local, want)
+	}
+	if after.Revision == before.Revision {
+		t.Error("Projects.SetAccess didn't change the revision")
+	}
+	if after.Groups[maintainers.ID].Name != "Maintainers" {
+		t.Errorf("Projects.SetAccess returned groups %+v, want Maintainers", after.Groups)
+	}
+
+	change, _, err := client.Projects.CreateAccessChange("go", &gerrit.ProjectAccessInput{
+		Remove:  map[string]gerrit.AccessSectionInput{"refs/heads/*": {}},
+		Message: "Remove all access",
+	})
+	if err != nil {
+		t.Fatalf("Projects.CreateAccessChange returned error: %v", err)
+	}
+	if change.Branch != "refs/meta/config" || change.Subject != "Remove all access" {
+		t.Errorf("Projects.CreateAccessChange returned %+v, want a change for refs/meta/config", change)
+	}
+
+	all, _, err := client.Access.ListAccessRights(&gerrit.ListAccessRightsOptions{Project: []string{"go"}})
+	if err != nil {
+		t.Fatalf("Access.ListAccessRights returned error: %v", err)
+	}
+	if !reflect.DeepEqual((*all)["go"].Local, want) {
+		t.Errorf("Access.ListAccessRights returned %+v, want the access rights before the review", (*all)["go"].Local)
+	}
+
+	_, resp, err := client.Projects.SetAccess("go", &gerrit.ProjectAccessInput{
+		Add: map[string]gerrit.AccessSectionInput{
+			"refs/*": {Permissions: map[string]gerrit.PermissionInput{"read": {Rules: map[string]gerrit.PermissionRuleInput{"unknown": {}}}}},
+		},
+	})
+	if err == nil || resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Projects.SetAccess with an unknown group returned %v, want 422", err)
+	}
+}
+
+func TestServer_Authentication(t *testing.T) {
+	server, _, _ := setupServer()
+	defer server.Close()
+
+	mockData := []struct {
+		Mode  gerrittest.AuthMode
+		Setup func(c *gerrit.Client)
+	}{
+		{gerrittest.AuthBasic, func(c *gerrit.Client) { c.Authentication.SetBasicAuth("owner", "owner-secret") }},
+		{gerrittest.AuthDigest, func(c *gerrit.Client) { c.Authentication.SetDigestAuth("owner", "owner-secret") }},
+		{gerrittest.AuthCookie, func(c *gerrit.Client) { c.Authentication.SetCookieAuth("o", "owner-secret") }},
+	}
+	for _, mock := range mockData {
+		server.SetAuthMode(mock.Mode)
+
+		client := server.Client()
+		mock.Setup(client)
+		self, _, err := client.Accounts.GetAccount("self")
+		if err != nil {
+			t.Errorf("Auth mode %d: Accounts.GetAccount returned error: %v", mock.Mode, err)
+			continue
+		}
+		if self.Username != "owner" {
+			t.Errorf("Auth mode %d: Accounts.GetAccount returned %+v, want owner", mock.Mode, self)
+		}
+
+		wrong := server.Client()
+		wrong.Authentication.SetBasicAuth("owner", "wrong")
+		if _, resp, err := wrong.Accounts.GetAccount("self"); err == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Auth mode %d: wrong credentials returned %v, want 401", mock.Mode, err)
+		}
+	}
+}
+
+func TestServer_InjectFault(t *testing.T) {
+	server, _, _ := setupServer()
+	defer server.Close()
+
+	server.InjectFault(gerrittest.Fault{
+		Method: "GET",
+		Path:   "/projects/",
+		Status: http.StatusServiceUnavailable,
+		Body:   "maintenance",
+		Times:  1,
+	})
+
+	client := server.Client()
+	_, resp, err := client.Projects.GetProject("go")
+	if err == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Projects.GetProject returned %v, want injected 503", err)
+	}
+
+	// The fault was limited to one request.
+	if _, _, err := client.Projects.GetProject("go"); err != nil {
+		t.Errorf("Projects.GetProject after fault returned error: %v", err)
+	}
+
+	server.InjectFault(gerrittest.Fault{Path: "/config/", Delay: 10 * time.Millisecond})
+	start := time.Now()
+	if _, _, err := client.Config.GetVersion(); err != nil {
+		t.Errorf("Config.GetVersion returned error: %v", err)
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Error("Config.GetVersion was not delayed")
+	}
+}
+
+func TestServer_MagicPrefix(t *testing.T) {
+	server, _, _ := setupServer()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "config/server/version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	buf := make([]byte, 5)
+	resp.Body.Read(buf)
+	if !strings.HasPrefix(string(buf), ")]}'\n") {
+		t.Errorf("Response starts with %q, want magic prefix", buf)
+	}
+}
@@ -0,0 +1,14 @@
+module github.com/andygrunwald/go-gerrit
+
+go 1.18
+
+require (
+	github.com/google/go-querystring v1.1.0
+	golang.org/x/crypto v0.24.0
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require (
+	github.com/google/go-cmp v0.5.9 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+)
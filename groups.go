@@ -12,11 +12,17 @@ type GroupsService struct {
 }
 
 // GroupAuditEventInfo entity contains information about an audit event of a group.
+// Depending on Type, either MemberAccount or MemberGroup is set.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-groups.html#group-audit-event-info
 type GroupAuditEventInfo struct {
-	// TODO Member AccountInfo OR GroupInfo `json:"member"`
 	Type string      `json:"type"`
 	User AccountInfo `json:"user"`
 	Date string      `json:"date"`
+	// MemberAccount is the account that was added or removed by ADD_USER and REMOVE_USER events.
+	MemberAccount *AccountInfo `json:"-"`
+	// MemberGroup is the group that was included or removed by ADD_GROUP and REMOVE_GROUP events.
+	MemberGroup *GroupInfo `json:"-"`
 }
 
 // GroupInfo entity contains information about a group.
@@ -40,6 +46,9 @@ type GroupInput struct {
 	Description  string `json:"description,omitempty"`
 	VisibleToAll bool   `json:"visible_to_all,omitempty"`
 	OwnerID      string `json:"owner_id,omitempty"`
+	// Members are the accounts (account IDs, usernames or emails) that become the members of the group.
+	// If not set, the user creating the group becomes the only member.
+	Members []string `json:"members,omitempty"`
 }
 
 // GroupOptionsInfo entity contains options of the group.
@@ -304,7 +313,7 @@ func (s *GroupsService) SetGroupDescription(groupID, description string) (*strin
 //
 // Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-groups.html#delete-group-description
 func (s *GroupsService) DeleteGroupDescription(groupID string) (*Response, error) {
-	u := fmt.Sprintf("groups/%s/description'", groupID)
+	u := fmt.Sprintf("groups/%s/description", groupID)
 	return s.client.DeleteRequest(u, nil)
 }
 
@@ -0,0 +1,152 @@
+package gerrit
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Types of a GroupAuditEventInfo.
+const (
+	GroupAuditAddUser     = "ADD_USER"
+	GroupAuditRemoveUser  = "REMOVE_USER"
+	GroupAuditAddGroup    = "ADD_GROUP"
+	GroupAuditRemoveGroup = "REMOVE_GROUP"
+)
+
+// groupAuditTimeLayout is the layout of the date of a GroupAuditEventInfo. Dates are in UTC.
+const groupAuditTimeLayout = "2006-01-02 15:04:05.000000000"
+
+// groupAuditEvent is the JSON representation of a GroupAuditEventInfo.
+type groupAuditEvent struct {
+	Type   string          `json:"type"`
+	User   AccountInfo     `json:"user"`
+	Date   string          `json:"date"`
+	Member json.RawMessage `json:"member,omitempty"`
+}
+
+// UnmarshalJSON decodes the member of the event as AccountInfo or GroupInfo, depending on the type.
+// The member of unknown event types is ignored.
+func (e *GroupAuditEventInfo) UnmarshalJSON(data []byte) error {
+	var raw groupAuditEvent
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = GroupAuditEventInfo{Type: raw.Type, User: raw.User, Date: raw.Date}
+	if len(raw.Member) == 0 || string(raw.Member) == "null" {
+		return nil
+	}
+
+	switch raw.Type {
+	case GroupAuditAddUser, GroupAuditRemoveUser:
+		e.MemberAccount = new(AccountInfo)
+		return json.Unmarshal(raw.Member, e.MemberAccount)
+	case GroupAuditAddGroup, GroupAuditRemoveGroup:
+		e.MemberGroup = new(GroupInfo)
+		return json.Unmarshal(raw.Member, e.MemberGroup)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the event like Gerrit does, with MemberAccount or MemberGroup as member.
+func (e GroupAuditEventInfo) MarshalJSON() ([]byte, error) {
+	raw := groupAuditEvent{Type: e.Type, User: e.User, Date: e.Date}
+	var member interface{}
+	switch {
+	case e.MemberAccount != nil:
+		member = e.MemberAccount
+	case e.MemberGroup != nil:
+		member = e.MemberGroup
+	}
+	if member != nil {
+		b, err := json.Marshal(member)
+		if err != nil {
+			return nil, err
+		}
+		raw.Member = b
+	}
+	return json.Marshal(raw)
+}
+
+// Time returns the date of the event.
+func (e *GroupAuditEventInfo) Time() (time.Time, error) {
+	return time.ParseInLocation(groupAuditTimeLayout, e.Date, time.UTC)
+}
+
+// AuditLogOptions specifies the parameters for GroupsService.QueryAuditLog.
+// Gerrit doesn't filter the audit log, so the events are filtered after they were retrieved.
+type AuditLogOptions struct {
+	// From excludes the events before this time. It is ignored if zero.
+	From time.Time
+	// To excludes the events at and after this time. It is ignored if zero.
+	To time.Time
+	// Types limits the events to the given types, e.g. GroupAuditAddUser. All types are returned if empty.
+	Types []string
+}
+
+// match reports if the event matches the options.
+func (opt *AuditLogOptions) match(e *GroupAuditEventInfo) (bool, error) {
+	if len(opt.Types) > 0 {
+		found := false
+		for _, t := range opt.Types {
+			found = found || t == e.Type
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	if opt.From.IsZero() && opt.To.IsZero() {
+		return true, nil
+	}
+
+	date, err := e.Time()
+	if err != nil {
+		return false, fmt.Errorf("invalid date of audit event: %v", err)
+	}
+	if !opt.From.IsZero() && date.Before(opt.From) {
+		return false, nil
+	}
+	if !opt.To.IsZero() && !date.Before(opt.To) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// QueryAuditLog gets the audit events of a Gerrit internal group that match the options.
+// Like GetAuditLog, the newest audit event comes first.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-groups.html#get-audit-log
+func (s *GroupsService) QueryAuditLog(groupID string, opt *AuditLogOptions) (*[]GroupAuditEventInfo, *Response, error) {
+	events, resp, err := s.GetAuditLog(groupID)
+	if err != nil || opt == nil {
+		return events, resp, err
+	}
+
+	filtered := []GroupAuditEventInfo{}
+	for i := range *events {
+		ok, err := opt.match(&(*events)[i])
+		if err != nil {
+			return nil, resp, err
+		}
+		if ok {
+			filtered = append(filtered, (*events)[i])
+		}
+	}
+	return &filtered, resp, nil
+}
+
+// IndexGroup adds or updates the internal group in the secondary index.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-groups.html#index-group
+func (s *GroupsService) IndexGroup(groupID string) (*Response, error) {
+	u := fmt.Sprintf("groups/%s/index", groupID)
+	return s.client.Call("POST", u, nil, nil)
+}
+
+// DeleteGroup deletes a Gerrit internal group.
+// Core Gerrit doesn't support the deletion of groups; the endpoint must be provided by a plugin.
+// Without it, the server responds with “404 Not Found” or “405 Method Not Allowed”.
+func (s *GroupsService) DeleteGroup(groupID string) (*Response, error) {
+	u := fmt.Sprintf("groups/%s", groupID)
+	return s.client.DeleteRequest(u, nil)
+}
@@ -0,0 +1,275 @@
+package gerrit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GroupSyncInput describes the desired members and included groups of a Gerrit internal group
+// for GroupsService.SyncGroupMembers.
+type GroupSyncInput struct {
+	// Members are the desired members: account IDs, usernames or emails.
+	// If nil, the members are left as they are. An empty, non-nil slice removes all members.
+	Members []string
+	// IncludedGroups are the desired included groups: group UUIDs, names or legacy numeric IDs.
+	// External groups, e.g. LDAP groups, must be given by UUID, e.g. "ldap:cn=developers,ou=groups".
+	// If nil, the included groups are left as they are. An empty, non-nil slice removes all included groups.
+	IncludedGroups []string
+	// DryRun computes the report without changing the group.
+	DryRun bool
+}
+
+// GroupSyncReport lists the changes of GroupsService.SyncGroupMembers.
+// In a dry-run, nothing was changed and the report lists what would be changed.
+type GroupSyncReport struct {
+	// GroupID is the UUID of the synced group.
+	// The IDs of the groups in the report are UUIDs as well, not URL encoded like in other responses.
+	GroupID        string
+	DryRun         bool
+	AddedMembers   []AccountInfo
+	RemovedMembers []AccountInfo
+	AddedGroups    []GroupInfo
+	RemovedGroups  []GroupInfo
+	// Unresolved lists the desired members and included groups that don't exist or aren't visible.
+	// They are skipped. As an unresolved entry may name a current member or included group,
+	// no members are removed if a desired member is unresolved,
+	// and no included groups are removed if a desired group is unresolved.
+	Unresolved []string
+}
+
+// Changed reports if members or included groups were (or in a dry-run, would be) added or removed.
+func (r *GroupSyncReport) Changed() bool {
+	return len(r.AddedMembers)+len(r.RemovedMembers)+len(r.AddedGroups)+len(r.RemovedGroups) > 0
+}
+
+// IsExternalGroupUUID reports if uuid is the UUID of an external group, e.g. "ldap:cn=developers,ou=groups"
+// or a system group like "global:Registered-Users". The members of external groups can't be modified through Gerrit.
+//
+// External UUIDs start with the lowercase scheme of their group backend, followed by a colon,
+// while the UUIDs of internal groups are hex strings.
+// The function only accepts UUIDs: a group name like "team:backend" looks like an external UUID,
+// so names have to be resolved to UUIDs before, e.g. with GroupsService.GetGroup.
+func IsExternalGroupUUID(uuid string) bool {
+	i := strings.Index(uuid, ":")
+	if i <= 0 || i == len(uuid)-1 || uuid[i+1] == ' ' {
+		return false
+	}
+	for j, c := range uuid[:i] {
+		switch {
+		case c >= 'a' && c <= 'z':
+		case j > 0 && (c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// SyncGroupMembers makes the direct members and included groups of a Gerrit internal group
+// match the desired state, e.g. an export of a directory service.
+// The desired accounts and groups are resolved first, then only the differences are added and removed.
+// Syncing a group that is already in the desired state sends no modifying request.
+// Desired accounts or groups that can't be resolved are listed in GroupSyncReport.Unresolved
+// and prevent the removal of members or included groups respectively, while additions are still made.
+//
+// External groups can't be synced, as their members are managed outside of Gerrit,
+// but they can be included into an internal group.
+//
+// The returned Response is the one of the last request.
+func (s *GroupsService) SyncGroupMembers(groupID string, input *GroupSyncInput) (*GroupSyncReport, *Response, error) {
+	if IsExternalGroupUUID(groupID) {
+		return nil, nil, fmt.Errorf("group %s is an external group and can't be modified", groupID)
+	}
+	if input == nil {
+		input = &GroupSyncInput{}
+	}
+
+	group, resp, err := s.GetGroupDetail(url.QueryEscape(groupID))
+	if err != nil {
+		return nil, resp, err
+	}
+	group.ID = unescapeGroupID(group.ID)
+	if IsExternalGroupUUID(group.ID) {
+		return nil, resp, fmt.Errorf("group %s is an external group and can't be modified", group.ID)
+	}
+	for i := range group.Includes {
+		group.Includes[i].ID = unescapeGroupID(group.Includes[i].ID)
+	}
+	report := &GroupSyncReport{GroupID: group.ID, DryRun: input.DryRun}
+
+	if input.Members != nil {
+		unresolved := len(report.Unresolved)
+		desired, r, err := s.resolveAccounts(input.Members, report)
+		if err != nil {
+			return nil, r, err
+		}
+		report.AddedMembers, report.RemovedMembers = diffAccounts(group.Members, desired)
+		if len(report.Unresolved) > unresolved {
+			report.RemovedMembers = nil
+		}
+	}
+	if input.IncludedGroups != nil {
+		unresolved := len(report.Unresolved)
+		desired, r, err := s.resolveGroups(input.IncludedGroups, report)
+		if err != nil {
+			return nil, r, err
+		}
+		report.AddedGroups, report.RemovedGroups = diffGroups(group.Includes, desired)
+		if len(report.Unresolved) > unresolved {
+			report.RemovedGroups = nil
+		}
+	}
+
+	if input.DryRun {
+		return report, resp, nil
+	}
+
+	u := url.QueryEscape(group.ID)
+	if len(report.AddedMembers) > 0 {
+		if _, resp, err = s.AddGroupMembers(u, &MembersInput{Members: accountIDs(report.AddedMembers)}); err != nil {
+			return report, resp, fmt.Errorf("add members: %v", err)
+		}
+	}
+	if len(report.RemovedMembers) > 0 {
+		if resp, err = s.DeleteGroupMembers(u, &MembersInput{Members: accountIDs(report.RemovedMembers)}); err != nil {
+			return report, resp, fmt.Errorf("remove members: %v", err)
+		}
+	}
+	if len(report.AddedGroups) > 0 {
+		if _, resp, err = s.IncludeGroups(u, &GroupsInput{Groups: groupIDs(report.AddedGroups)}); err != nil {
+			return report, resp, fmt.Errorf("include groups: %v", err)
+		}
+	}
+	if len(report.RemovedGroups) > 0 {
+		if resp, err = s.DeleteIncludedGroups(u, &GroupsInput{Groups: groupIDs(report.RemovedGroups)}); err != nil {
+			return report, resp, fmt.Errorf("remove included groups: %v", err)
+		}
+	}
+
+	return report, resp, nil
+}
+
+// resolveAccounts looks up the accounts. Accounts that don't exist are added to report.Unresolved.
+func (s *GroupsService) resolveAccounts(accounts []string, report *GroupSyncReport) ([]AccountInfo, *Response, error) {
+	var resolved []AccountInfo
+	for _, account := range accounts {
+		info, resp, err := s.client.Accounts.GetAccount(url.QueryEscape(account))
+		if isNotFound(resp) {
+			report.Unresolved = append(report.Unresolved, account)
+			continue
+		}
+		if err != nil {
+			return nil, resp, fmt.Errorf("account %s: %v", account, err)
+		}
+		resolved = append(resolved, *info)
+	}
+	return resolved, nil, nil
+}
+
+// resolveGroups looks up the internal groups. External group UUIDs are taken as they are,
+// as Gerrit can't look up all external groups. Groups that don't exist are added to report.Unresolved.
+func (s *GroupsService) resolveGroups(groups []string, report *GroupSyncReport) ([]GroupInfo, *Response, error) {
+	var resolved []GroupInfo
+	for _, group := range groups {
+		if IsExternalGroupUUID(group) {
+			resolved = append(resolved, GroupInfo{ID: group})
+			continue
+		}
+		info, resp, err := s.GetGroup(url.QueryEscape(group))
+		if isNotFound(resp) {
+			report.Unresolved = append(report.Unresolved, group)
+			continue
+		}
+		if err != nil {
+			return nil, resp, fmt.Errorf("group %s: %v", group, err)
+		}
+		info.ID = unescapeGroupID(info.ID)
+		resolved = append(resolved, *info)
+	}
+	return resolved, nil, nil
+}
+
+// unescapeGroupID returns the UUID of a GroupInfo, which Gerrit returns URL encoded.
+func unescapeGroupID(id string) string {
+	if uuid, err := url.QueryUnescape(id); err == nil {
+		return uuid
+	}
+	return id
+}
+
+// diffAccounts returns the accounts of desired that are not in current, and the accounts of current that are not in desired.
+// Both results are sorted by account ID.
+func diffAccounts(current, desired []AccountInfo) (add, remove []AccountInfo) {
+	has := func(accounts []AccountInfo, id int) bool {
+		for _, a := range accounts {
+			if a.AccountID == id {
+				return true
+			}
+		}
+		return false
+	}
+	for _, a := range desired {
+		if !has(current, a.AccountID) && !has(add, a.AccountID) {
+			add = append(add, a)
+		}
+	}
+	for _, a := range current {
+		if !has(desired, a.AccountID) {
+			remove = append(remove, a)
+		}
+	}
+	sort.Slice(add, func(i, j int) bool { return add[i].AccountID < add[j].AccountID })
+	sort.Slice(remove, func(i, j int) bool { return remove[i].AccountID < remove[j].AccountID })
+	return add, remove
+}
+
+// diffGroups returns the groups of desired that are not in current, and the groups of current that are not in desired.
+// Both results are sorted by group UUID.
+func diffGroups(current, desired []GroupInfo) (add, remove []GroupInfo) {
+	has := func(groups []GroupInfo, id string) bool {
+		for _, g := range groups {
+			if g.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+	for _, g := range desired {
+		if !has(current, g.ID) && !has(add, g.ID) {
+			add = append(add, g)
+		}
+	}
+	for _, g := range current {
+		if !has(desired, g.ID) {
+			remove = append(remove, g)
+		}
+	}
+	sort.Slice(add, func(i, j int) bool { return add[i].ID < add[j].ID })
+	sort.Slice(remove, func(i, j int) bool { return remove[i].ID < remove[j].ID })
+	return add, remove
+}
+
+func accountIDs(accounts []AccountInfo) []string {
+	ids := make([]string, len(accounts))
+	for i, a := range accounts {
+		ids[i] = strconv.Itoa(a.AccountID)
+	}
+	return ids
+}
+
+func groupIDs(groups []GroupInfo) []string {
+	ids := make([]string, len(groups))
+	for i, g := range groups {
+		ids[i] = g.ID
+	}
+	return ids
+}
+
+// isNotFound reports if resp is a "404 Not Found" response.
+func isNotFound(resp *Response) bool {
+	return resp != nil && resp.Response != nil && resp.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,300 @@
+package gerrit_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+func TestIsExternalGroupUUID(t *testing.T) {
+	for uuid, want := range map[string]bool{
+		"ldap:cn=developers,ou=groups":             true,
+		"global:Registered-Users":                  true,
+		"github-oauth:octocat":                     true,
+		"6a1e70e1b0e5b6f3c0b8a1d2e3f4a5b6c7d8e9f0": false,
+		"Team: Backend":                            false,
+		"Releases/2024:Q1":                         false,
+		"Ops:":                                     false,
+		":developers":                              false,
+		"Developers:Frontend":                      false,
+	} {
+		if got := gerrit.IsExternalGroupUUID(uuid); got != want {
+			t.Errorf("IsExternalGroupUUID(%q) = %v, want %v", uuid, got, want)
+		}
+	}
+}
+
+func TestGroupsService_SyncGroupMembers(t *testing.T) {
+	for _, dryRun := range []bool{true, false} {
+		t.Run(fmt.Sprintf("dryRun=%v", dryRun), func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			testMux.HandleFunc("/groups/developers/detail", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				fmt.Fprint(w, `)]}'`+"\n"+`{"id":"6a1e70e1","name":"developers",`+
+					`"members":[{"_account_id":1000},{"_account_id":1001}],`+
+					`"includes":[{"id":"ldap%3Acn%3Dold","name":"ldap/old"},{"id":"9999","name":"admins"}]}`)
+			})
+			testMux.HandleFunc("/accounts/", func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/accounts/1000":
+					fmt.Fprint(w, `)]}'`+"\n"+`{"_account_id":1000}`)
+				case "/accounts/jane@example.com":
+					fmt.Fprint(w, `)]}'`+"\n"+`{"_account_id":1002,"email":"jane@example.com"}`)
+				default:
+					http.NotFound(w, r)
+				}
+			})
+			testMux.HandleFunc("/groups/admins", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `)]}'`+"\n"+`{"id":"9999","name":"admins"}`)
+			})
+
+			requests := map[string]interface{}{}
+			record := func(path string) {
+				testMux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+					testMethod(t, r, "POST")
+					var input map[string]interface{}
+					json.NewDecoder(r.Body).Decode(&input)
+					requests[path] = input
+					fmt.Fprint(w, `)]}'`+"\n"+`[]`)
+				})
+			}
+			record("/groups/6a1e70e1/members")
+			record("/groups/6a1e70e1/members.delete")
+			record("/groups/6a1e70e1/groups")
+			record("/groups/6a1e70e1/groups.delete")
+
+			report, _, err := testClient.Groups.SyncGroupMembers("developers", &gerrit.GroupSyncInput{
+				Members:        []string{"1000", "jane@example.com"},
+				IncludedGroups: []string{"admins", "ldap:cn=new"},
+				DryRun:         dryRun,
+			})
+			if err != nil {
+				t.Fatalf("Groups.SyncGroupMembers returned error: %v", err)
+			}
+
+			want := &gerrit.GroupSyncReport{
+				GroupID:        "6a1e70e1",
+				DryRun:         dryRun,
+				AddedMembers:   []gerrit.AccountInfo{{AccountID: 1002, Email: "jane@example.com"}},
+				RemovedMembers: []gerrit.AccountInfo{{AccountID: 1001}},
+				AddedGroups:    []gerrit.GroupInfo{{ID: "ldap:cn=new"}},
+				RemovedGroups:  []gerrit.GroupInfo{{ID: "ldap:cn=old", Name: "ldap/old"}},
+			}
+			if !reflect.DeepEqual(report, want) {
+				t.Errorf("Groups.SyncGroupMembers returned %+v, want %+v", report, want)
+			}
+
+			wantRequests := map[string]interface{}{}
+			if !dryRun {
+				wantRequests = map[string]interface{}{
+					"/groups/6a1e70e1/members":        map[string]interface{}{"members": []interface{}{"1002"}},
+					"/groups/6a1e70e1/members.delete": map[string]interface{}{"members": []interface{}{"1001"}},
+					"/groups/6a1e70e1/groups":         map[string]interface{}{"groups": []interface{}{"ldap:cn=new"}},
+					"/groups/6a1e70e1/groups.delete":  map[string]interface{}{"groups": []interface{}{"ldap:cn=old"}},
+				}
+			}
+			if !reflect.DeepEqual(requests, wantRequests) {
+				t.Errorf("Requests are %v, want %v", requests, wantRequests)
+			}
+		})
+	}
+}
+
+func TestGroupsService_SyncGroupMembers_Unresolved(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/groups/developers/detail", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`{"id":"6a1e70e1","name":"developers",`+
+			`"members":[{"_account_id":1000},{"_account_id":1001}],`+
+			`"includes":[{"id":"9999","name":"admins"}]}`)
+	})
+	testMux.HandleFunc("/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/accounts/jane@example.com" {
+			fmt.Fprint(w, `)]}'`+"\n"+`{"_account_id":1002,"email":"jane@example.com"}`)
+			return
+		}
+		// 1000 isn't visible to the caller.
+		http.NotFound(w, r)
+	})
+	testMux.HandleFunc("/groups/admins", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`{"id":"9999","name":"admins"}`)
+	})
+
+	var requests []string
+	for _, path := range []string{"/groups/6a1e70e1/members", "/groups/6a1e70e1/members.delete", "/groups/6a1e70e1/groups", "/groups/6a1e70e1/groups.delete"} {
+		path := path
+		testMux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, path)
+			fmt.Fprint(w, `)]}'`+"\n"+`[]`)
+		})
+	}
+
+	report, _, err := testClient.Groups.SyncGroupMembers("developers", &gerrit.GroupSyncInput{
+		Members:        []string{"1000", "jane@example.com"},
+		IncludedGroups: []string{"ldap:cn=new"},
+	})
+	if err != nil {
+		t.Fatalf("Groups.SyncGroupMembers returned error: %v", err)
+	}
+
+	// No member is removed, as the unresolved 1000 is a current member. Included groups are synced.
+	want := &gerrit.GroupSyncReport{
+		GroupID:       "6a1e70e1",
+		AddedMembers:  []gerrit.AccountInfo{{AccountID: 1002, Email: "jane@example.com"}},
+		AddedGroups:   []gerrit.GroupInfo{{ID: "ldap:cn=new"}},
+		RemovedGroups: []gerrit.GroupInfo{{ID: "9999", Name: "admins"}},
+		Unresolved:    []string{"1000"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Groups.SyncGroupMembers returned %+v, want %+v", report, want)
+	}
+	if want := []string{"/groups/6a1e70e1/members", "/groups/6a1e70e1/groups", "/groups/6a1e70e1/groups.delete"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("Requests are %v, want %v", requests, want)
+	}
+}
+
+func TestGroupsService_SyncGroupMembers_ExternalGroup(t *testing.T) {
+	setup()
+	defer teardown()
+
+	if _, _, err := testClient.Groups.SyncGroupMembers("ldap:cn=developers", &gerrit.GroupSyncInput{Members: []string{}}); err == nil {
+		t.Error("Groups.SyncGroupMembers returned no error for an external group")
+	}
+}
+
+func TestGroupsService_QueryAuditLog(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/groups/developers/log.audit", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `)]}'`+"\n"+`[`+
+			`{"member":{"id":"9999","name":"admins"},"type":"ADD_GROUP","user":{"_account_id":1000},"date":"2024-03-02 10:00:00.000000000"},`+
+			`{"member":{"_account_id":1001},"type":"REMOVE_USER","user":{"_account_id":1000},"date":"2024-02-01 10:00:00.000000000"},`+
+			`{"member":{"_account_id":1001},"type":"ADD_USER","user":{"_account_id":1000},"date":"2024-01-01 10:00:00.000000000"}]`)
+	})
+
+	events, _, err := testClient.Groups.GetAuditLog("developers")
+	if err != nil {
+		t.Fatalf("Groups.GetAuditLog returned error: %v", err)
+	}
+	if e := (*events)[0]; e.MemberGroup == nil || e.MemberGroup.Name != "admins" || e.MemberAccount != nil {
+		t.Errorf("ADD_GROUP event decoded as %+v", e)
+	}
+	if e := (*events)[1]; e.MemberAccount == nil || e.MemberAccount.AccountID != 1001 || e.MemberGroup != nil {
+		t.Errorf("REMOVE_USER event decoded as %+v", e)
+	}
+
+	// Events survive a round trip, e.g. when they are stored for access reviews.
+	b, _ := json.Marshal((*events)[0])
+	var decoded gerrit.GroupAuditEventInfo
+	if err := json.Unmarshal(b, &decoded); err != nil || !reflect.DeepEqual(decoded, (*events)[0]) {
+		t.Errorf("Round trip returned %+v, %v, want %+v", decoded, err, (*events)[0])
+	}
+
+	filtered, _, err := testClient.Groups.QueryAuditLog("developers", &gerrit.AuditLogOptions{
+		From:  time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC),
+		Types: []string{gerrit.GroupAuditAddUser, gerrit.GroupAuditRemoveUser},
+	})
+	if err != nil {
+		t.Fatalf("Groups.QueryAuditLog returned error: %v", err)
+	}
+	if len(*filtered) != 1 || (*filtered)[0].Type != gerrit.GroupAuditRemoveUser {
+		t.Errorf("Groups.QueryAuditLog returned %+v, want the REMOVE_USER event", *filtered)
+	}
+}
+
+func TestGroupsService_IndexGroup(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/groups/developers/index", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := testClient.Groups.IndexGroup("developers"); err != nil {
+		t.Errorf("Groups.IndexGroup returned error: %v", err)
+	}
+}
+
+func TestGroupsService_DeleteGroup(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/groups/developers", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := testClient.Groups.DeleteGroup("developers"); err != nil {
+		t.Errorf("Groups.DeleteGroup returned error: %v", err)
+	}
+}
+
+func TestGroupsService_DeleteGroupMember(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/groups/developers/members/1000096", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := testClient.Groups.DeleteGroupMember("developers", "1000096"); err != nil {
+		t.Errorf("Groups.DeleteGroupMember returned error: %v", err)
+	}
+}
+
+func TestGroupsService_IncludeGroups(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/groups/developers/groups", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var input gerrit.GroupsInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Errorf("Request body is not a GroupsInput: %v", err)
+		}
+		if want := []string{"admins", "ldap:cn=eng"}; !reflect.DeepEqual(input.Groups, want) {
+			t.Errorf("Request body groups %v, want %v", input.Groups, want)
+		}
+		fmt.Fprint(w, `)]}'`+"\n"+`[{"id":"9999","name":"admins"},{"id":"ldap%3Acn%3Deng","name":"ldap/eng"}]`)
+	})
+
+	groups, _, err := testClient.Groups.IncludeGroups("developers", &gerrit.GroupsInput{Groups: []string{"admins", "ldap:cn=eng"}})
+	if err != nil {
+		t.Fatalf("Groups.IncludeGroups returned error: %v", err)
+	}
+	if len(*groups) != 2 {
+		t.Errorf("Groups.IncludeGroups returned %+v", *groups)
+	}
+}
+
+func TestGroupsService_DeleteGroupMembers(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/groups/developers/members.delete", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var input gerrit.MembersInput
+		json.NewDecoder(r.Body).Decode(&input)
+		if want := []string{"1000096", "jane@example.com"}; !reflect.DeepEqual(input.Members, want) {
+			t.Errorf("Request body members %v, want %v", input.Members, want)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if _, err := testClient.Groups.DeleteGroupMembers("developers", &gerrit.MembersInput{Members: []string{"1000096", "jane@example.com"}}); err != nil {
+		t.Errorf("Groups.DeleteGroupMembers returned error: %v", err)
+	}
+}
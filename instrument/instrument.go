@@ -0,0 +1,64 @@
+// Package instrument provides OpenTelemetry tracing and Prometheus metrics
+// for the Gerrit API client.
+//
+// The instrumentation is built as gerrit.Middleware and lives in its own package,
+// so the core package stays free of these dependencies.
+// Every API call is named after the service method that issued it, e.g. "Changes.SetReview":
+//
+//	client, _ := gerrit.NewClient("https://gerrit-review.googlesource.com/", nil)
+//
+//	metrics := instrument.NewMetrics("myapp")
+//	prometheus.MustRegister(metrics)
+//
+//	client.Use(
+//		instrument.TracingMiddleware(nil),
+//		metrics.Middleware(),
+//	)
+package instrument
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// unknownOperation is used for requests that were not issued by a service method.
+const unknownOperation = "unknown"
+
+// target describes the Gerrit entities a request is about.
+type target struct {
+	project string
+	change  string
+}
+
+// requestTarget extracts the project and change from the URL of req.
+// It understands the REST endpoints below /projects/ and /changes/,
+// also if Gerrit is served below a path prefix or via the authenticated /a/ prefix.
+func requestTarget(req *http.Request) target {
+	var t target
+
+	segments := strings.Split(strings.Trim(req.URL.EscapedPath(), "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		value, err := url.PathUnescape(segments[i+1])
+		if err != nil || value == "" {
+			continue
+		}
+
+		switch segments[i] {
+		case "projects":
+			if t.project == "" {
+				t.project = value
+			}
+		case "changes":
+			if t.change == "" {
+				t.change = value
+				// Change IDs of the form "project~branch~Change-Id" or "project~number"
+				// name the project as well.
+				if parts := strings.Split(value, "~"); len(parts) > 1 && t.project == "" {
+					t.project = parts[0]
+				}
+			}
+		}
+	}
+	return t
+}
@@ -0,0 +1,149 @@
+package instrument_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andygrunwald/go-gerrit"
+	"github.com/andygrunwald/go-gerrit/instrument"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setup(t *testing.T) (*gerrit.Client, func()) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/changes/myProject~master~I8473b95934b5732ac55d26311a706c9c2bde9940/revisions/current/review", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`{"labels":{"Code-Review":2}}`)
+	})
+	mux.HandleFunc("/projects/my%2Fproject/branches/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Not found", http.StatusNotFound)
+	})
+	server := httptest.NewServer(mux)
+
+	client, err := gerrit.NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server.Close
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	client, teardown := setup(t)
+	defer teardown()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client.Use(instrument.TracingMiddleware(tp))
+
+	changeID := "myProject~master~I8473b95934b5732ac55d26311a706c9c2bde9940"
+	if _, _, err := client.Changes.SetReview(changeID, "current", &gerrit.ReviewInput{}); err != nil {
+		t.Fatalf("Changes.SetReview returned error: %v", err)
+	}
+	if _, _, err := client.Projects.ListBranches("my/project", nil); err == nil {
+		t.Fatal("Projects.ListBranches returned no error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Got %d spans, want 2", len(spans))
+	}
+
+	review := spans[0]
+	if review.Name() != "Changes.SetReview" {
+		t.Errorf("Span name %q, want %q", review.Name(), "Changes.SetReview")
+	}
+	wantAttrs := map[attribute.Key]attribute.Value{
+		instrument.OperationKey:  attribute.StringValue("Changes.SetReview"),
+		instrument.ProjectKey:    attribute.StringValue("myProject"),
+		instrument.ChangeKey:     attribute.StringValue(changeID),
+		instrument.MethodKey:     attribute.StringValue("POST"),
+		instrument.StatusCodeKey: attribute.IntValue(200),
+	}
+	gotAttrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range review.Attributes() {
+		gotAttrs[kv.Key] = kv.Value
+	}
+	for k, want := range wantAttrs {
+		if got := gotAttrs[k]; got != want {
+			t.Errorf("Attribute %s is %v, want %v", k, got.Emit(), want.Emit())
+		}
+	}
+	if review.Status().Code == codes.Error {
+		t.Errorf("Span status of a successful call is %v", review.Status())
+	}
+
+	branches := spans[1]
+	if branches.Name() != "Projects.ListBranches" {
+		t.Errorf("Span name %q, want %q", branches.Name(), "Projects.ListBranches")
+	}
+	if branches.Status().Code != codes.Error {
+		t.Errorf("Span status of a failed call is %v, want error", branches.Status())
+	}
+	found := false
+	for _, kv := range branches.Attributes() {
+		if kv.Key == instrument.ProjectKey && kv.Value.AsString() == "my/project" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Span attributes %v don't contain project my/project", branches.Attributes())
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	client, teardown := setup(t)
+	defer teardown()
+
+	metrics := instrument.NewMetrics("test")
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics)
+	client.Use(metrics.Middleware())
+
+	changeID := "myProject~master~I8473b95934b5732ac55d26311a706c9c2bde9940"
+	for i := 0; i < 2; i++ {
+		if _, _, err := client.Changes.SetReview(changeID, "current", &gerrit.ReviewInput{}); err != nil {
+			t.Fatalf("Changes.SetReview returned error: %v", err)
+		}
+	}
+	client.Projects.ListBranches("my/project", nil)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]float64{}
+	observations := map[string]uint64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			switch family.GetName() {
+			case "test_gerrit_client_requests_total":
+				counts[labels["operation"]+" "+labels["method"]+" "+labels["code"]] = m.GetCounter().GetValue()
+			case "test_gerrit_client_request_duration_seconds":
+				observations[labels["operation"]+" "+labels["method"]] = m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+
+	wantCounts := map[string]float64{
+		"Changes.SetReview POST 200":    2,
+		"Projects.ListBranches GET 404": 1,
+	}
+	for k, want := range wantCounts {
+		if got := counts[k]; got != want {
+			t.Errorf("requests_total{%s} is %v, want %v", k, got, want)
+		}
+	}
+	if got := observations["Changes.SetReview POST"]; got != 2 {
+		t.Errorf("request_duration_seconds{Changes.SetReview POST} has %d observations, want 2", got)
+	}
+}
@@ -0,0 +1,80 @@
+package instrument
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/andygrunwald/go-gerrit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics collects Prometheus metrics about the calls to the Gerrit API.
+// All metrics are partitioned by operation, e.g. "Changes.SetReview", and HTTP method.
+//
+//	<namespace>_gerrit_client_requests_total{operation,method,code}
+//	<namespace>_gerrit_client_request_duration_seconds{operation,method}
+//
+// The code label is the HTTP status code, or "error" if no response was received.
+//
+// Metrics implements prometheus.Collector and has to be registered to be exported.
+type Metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewMetrics returns new Metrics.
+// namespace is prepended to all metric names and may be empty.
+func NewMetrics(namespace string) *Metrics {
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "gerrit_client",
+			Name:      "requests_total",
+			Help:      "Number of requests to the Gerrit API.",
+		}, []string{"operation", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "gerrit_client",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests to the Gerrit API.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "method"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// Middleware returns a gerrit.Middleware that records every API call in m.
+func (m *Metrics) Middleware() gerrit.Middleware {
+	return func(next gerrit.Handler) gerrit.Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			operation := gerrit.OperationFromContext(req.Context())
+			if operation == "" {
+				operation = unknownOperation
+			}
+
+			start := time.Now()
+			resp, err := next(req)
+			m.duration.WithLabelValues(operation, req.Method).Observe(time.Since(start).Seconds())
+
+			code := "error"
+			if err == nil {
+				code = strconv.Itoa(resp.StatusCode)
+			}
+			m.requests.WithLabelValues(operation, req.Method, code).Inc()
+
+			return resp, err
+		}
+	}
+}
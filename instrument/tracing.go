@@ -0,0 +1,83 @@
+package instrument
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andygrunwald/go-gerrit"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer used by TracingMiddleware.
+const TracerName = "github.com/andygrunwald/go-gerrit/instrument"
+
+// Attribute keys that are set on every span.
+const (
+	OperationKey  = attribute.Key("gerrit.operation")
+	ProjectKey    = attribute.Key("gerrit.project")
+	ChangeKey     = attribute.Key("gerrit.change")
+	MethodKey     = attribute.Key("http.request.method")
+	URLKey        = attribute.Key("url.full")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+)
+
+// TracingMiddleware returns a gerrit.Middleware that creates a client span for every API call.
+// The span is named after the service method, e.g. "Changes.SetReview",
+// and carries the project, change and HTTP status code of the call.
+// The span context is propagated to Gerrit with the global propagator.
+//
+// If tp is nil, the global TracerProvider is used.
+func TracingMiddleware(tp trace.TracerProvider) gerrit.Middleware {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	tracer := tp.Tracer(TracerName)
+
+	return func(next gerrit.Handler) gerrit.Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			operation := gerrit.OperationFromContext(req.Context())
+			if operation == "" {
+				operation = unknownOperation
+			}
+
+			attrs := []attribute.KeyValue{
+				OperationKey.String(operation),
+				MethodKey.String(req.Method),
+				URLKey.String(req.URL.Redacted()),
+			}
+			t := requestTarget(req)
+			if t.project != "" {
+				attrs = append(attrs, ProjectKey.String(t.project))
+			}
+			if t.change != "" {
+				attrs = append(attrs, ChangeKey.String(t.change))
+			}
+
+			ctx, span := tracer.Start(req.Context(), operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			req = req.WithContext(ctx)
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+			resp, err := next(req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return resp, err
+			}
+
+			span.SetAttributes(StatusCodeKey.Int(resp.StatusCode))
+			if resp.StatusCode >= 400 {
+				span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", resp.StatusCode))
+			}
+			return resp, nil
+		}
+	}
+}
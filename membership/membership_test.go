@@ -0,0 +1,145 @@
+package membership_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/andygrunwald/go-gerrit"
+	"github.com/andygrunwald/go-gerrit/gerrittest"
+	"github.com/andygrunwald/go-gerrit/membership"
+)
+
+// setup starts a fake server with groups with cyclic includes and the access rights of my/project and All-Projects.
+func setup(t *testing.T) (*gerrittest.Server, *membership.Resolver) {
+	server := gerrittest.NewServer()
+	server.AddProject(gerrit.ProjectInfo{Name: "my/project"})
+
+	accounts := map[string]gerrit.AccountInfo{}
+	for i, username := range []string{"admin", "john", "jane", "joe", "bob"} {
+		accounts[username] = server.AddAccount(gerrit.AccountInfo{AccountID: i + 1, Username: username}, "")
+	}
+	group := func(id string, members []string, includes ...string) {
+		info := gerrit.GroupInfo{ID: id, Name: id}
+		for _, m := range members {
+			info.Members = append(info.Members, accounts[m])
+		}
+		for _, inc := range includes {
+			info.Includes = append(info.Includes, gerrit.GroupInfo{ID: inc})
+		}
+		server.AddGroup(info)
+	}
+	group("admins", []string{"admin"})
+	group("maintainers", []string{"john"}, "devs")
+	group("devs", []string{"jane", "joe"}, "maintainers", "ldap:cn=eng")
+	group("contractors", []string{"bob"})
+
+	submit := func(rules map[string]string) gerrit.AccessSectionInfo {
+		section := gerrit.AccessSectionInfo{Permissions: map[string]gerrit.PermissionInfo{
+			"submit": {Rules: map[string]gerrit.PermissionRuleInfo{}},
+		}}
+		for uuid, action := range rules {
+			section.Permissions["submit"].Rules[uuid] = gerrit.PermissionRuleInfo{Action: action}
+		}
+		return section
+	}
+	server.SetProjectAccess("my/project", map[string]gerrit.AccessSectionInfo{
+		"refs/heads/main":  submit(map[string]string{"devs": "ALLOW", "contractors": "DENY"}),
+		"refs/heads/*":     submit(map[string]string{"contractors": "ALLOW"}),
+		"refs/meta/config": submit(map[string]string{"contractors": "ALLOW"}),
+	})
+	server.SetProjectAccess("All-Projects", map[string]gerrit.AccessSectionInfo{
+		"refs/*":       submit(map[string]string{"admins": "ALLOW"}),
+		"refs/heads/*": submit(map[string]string{"global:Registered-Users": "BLOCK", "maintainers": "ALLOW"}),
+	})
+
+	return server, membership.NewResolver(server.Client())
+}
+
+func accountIDs(accounts []gerrit.AccountInfo) []int {
+	ids := []int{}
+	for _, a := range accounts {
+		ids = append(ids, a.AccountID)
+	}
+	return ids
+}
+
+func TestResolver_Members(t *testing.T) {
+	server, resolver := setup(t)
+	defer server.Close()
+
+	members, err := resolver.Members("maintainers")
+	if err != nil {
+		t.Fatalf("Members returned error: %v", err)
+	}
+	if got, want := accountIDs(members.Accounts), []int{2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Accounts are %v, want %v", got, want)
+	}
+	if want := []string{"devs", "maintainers"}; !reflect.DeepEqual(members.Groups, want) {
+		t.Errorf("Groups are %v, want %v", members.Groups, want)
+	}
+	if want := []string{"ldap:cn=eng"}; !reflect.DeepEqual(members.External, want) {
+		t.Errorf("External are %v, want %v", members.External, want)
+	}
+
+	for _, tt := range []struct {
+		account, group string
+		want           bool
+	}{
+		{"jane", "maintainers", true},
+		{"jane", "devs", true},
+		{"bob", "maintainers", false},
+	} {
+		got, err := resolver.IsMember(tt.account, tt.group)
+		if err != nil || got != tt.want {
+			t.Errorf("IsMember(%q, %q) = %v, %v, want %v", tt.account, tt.group, got, err, tt.want)
+		}
+	}
+
+	requests := map[string]int{}
+	for _, r := range server.Requests() {
+		requests[r.Path+"?"+r.Query.Encode()]++
+	}
+	for path, n := range requests {
+		if n > 1 {
+			t.Errorf("%s was requested %d times, want once", path, n)
+		}
+	}
+
+	if _, err := resolver.Members("unknown"); err == nil {
+		t.Error("Members returned no error for an unknown group")
+	}
+}
+
+func TestResolver_PermissionHolders(t *testing.T) {
+	server, resolver := setup(t)
+	defer server.Close()
+
+	tests := []struct {
+		ref      string
+		groups   []string
+		accounts []int
+	}{
+		// contractors are denied on main, admins are blocked, maintainers and devs are exempt from the block.
+		{"refs/heads/main", []string{"admins", "devs", "maintainers"}, []int{2, 3, 4}},
+		{"refs/heads/feature", []string{"admins", "contractors", "maintainers"}, []int{2, 3, 4}},
+		// The block on refs/heads/* doesn't apply.
+		{"refs/meta/config", []string{"admins", "contractors"}, []int{1, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			holders, err := resolver.PermissionHolders("my/project", tt.ref, "submit")
+			if err != nil {
+				t.Fatalf("PermissionHolders returned error: %v", err)
+			}
+			if !reflect.DeepEqual(holders.Groups, tt.groups) {
+				t.Errorf("Groups are %v, want %v", holders.Groups, tt.groups)
+			}
+			if got := accountIDs(holders.Accounts); !reflect.DeepEqual(got, tt.accounts) {
+				t.Errorf("Accounts are %v, want %v", got, tt.accounts)
+			}
+			if holders.Everyone {
+				t.Error("Everyone is set")
+			}
+		})
+	}
+}
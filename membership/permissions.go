@@ -0,0 +1,232 @@
+package membership
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// Permission rule actions.
+const (
+	actionAllow = "ALLOW"
+	actionDeny  = "DENY"
+	actionBlock = "BLOCK"
+)
+
+// Holders are the effective holders of a permission on a ref of a project.
+type Holders struct {
+	Project    string
+	Ref        string
+	Permission string
+	// Groups are the UUIDs of the groups with an effective ALLOW rule, sorted.
+	Groups []string
+	// BlockedGroups are the UUIDs of the groups with an effective BLOCK rule, sorted.
+	BlockedGroups []string
+	// Members are the effective members of Groups without the effective members of BlockedGroups.
+	// Accounts that are only covered by Everyone or External can't be listed.
+	Members
+}
+
+// section is an access section of a project that matches the ref.
+type section struct {
+	pattern string
+	info    gerrit.AccessSectionInfo
+}
+
+// PermissionHolders computes who holds permission, e.g. "submit", "push" or "label-Code-Review",
+// on ref of project, taking the access rights inherited from the parent projects into account.
+//
+// The evaluation follows the rules of Gerrit, but is simplified:
+// the most specific section of the project and its parents decides per group if it is allowed or denied,
+// an exclusive permission hides the ALLOW and DENY rules of less specific sections and of parent projects,
+// and BLOCK rules of any project remove the members of the blocked group,
+// except for the members of groups that are allowed in the same section.
+// Label ranges are not taken into account: any ALLOW rule for a label counts.
+// Ref patterns with parameters like ${username} are skipped.
+// Use ProjectsService.CheckAccess to check the permission of a single account authoritatively.
+func (r *Resolver) PermissionHolders(project, ref, permission string) (*Holders, error) {
+	sections, err := r.matchingSections(project, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	decided := map[string]string{}
+	var blocks []block
+	exclusive := false
+	for _, s := range sections {
+		p, ok := s.info.Permissions[permission]
+		if !ok {
+			continue
+		}
+
+		var blockedHere, allowedHere []string
+		for group, rule := range p.Rules {
+			group = unescape(group)
+			switch rule.Action {
+			case actionBlock:
+				blockedHere = append(blockedHere, group)
+			case actionAllow, actionDeny:
+				if rule.Action == actionAllow {
+					allowedHere = append(allowedHere, group)
+				}
+				if _, ok := decided[group]; !ok && !exclusive {
+					decided[group] = rule.Action
+				}
+			}
+		}
+		for _, group := range blockedHere {
+			blocks = append(blocks, block{group: group, exempt: allowedHere})
+		}
+		if p.Exclusive {
+			exclusive = true
+		}
+	}
+
+	h := &Holders{Project: project, Ref: ref, Permission: permission}
+	for group, action := range decided {
+		if action == actionAllow {
+			h.Groups = append(h.Groups, group)
+		}
+	}
+	sort.Strings(h.Groups)
+
+	allowed, err := r.members(h.Groups)
+	if err != nil {
+		return nil, err
+	}
+	h.Members = *allowed
+	for _, b := range blocks {
+		h.BlockedGroups = append(h.BlockedGroups, b.group)
+		if err := r.applyBlock(&h.Members, b); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(h.BlockedGroups)
+	return h, nil
+}
+
+// block is a BLOCK rule for group. Members of the groups that are allowed in the same section are exempt.
+type block struct {
+	group  string
+	exempt []string
+}
+
+// applyBlock removes the accounts that are blocked by b from m.
+func (r *Resolver) applyBlock(m *Members, b block) error {
+	blocked, err := r.members([]string{b.group})
+	if err != nil {
+		return err
+	}
+	exempt, err := r.members(b.exempt)
+	if err != nil {
+		return err
+	}
+	if exempt.Everyone {
+		return nil
+	}
+	if blocked.Everyone {
+		// Only the explicit members of the exempt groups are left.
+		m.Everyone = false
+	}
+
+	accounts := m.Accounts[:0]
+	for _, a := range m.Accounts {
+		if !blocked.Contains(a.AccountID) || exempt.Contains(a.AccountID) {
+			accounts = append(accounts, a)
+		}
+	}
+	m.Accounts = accounts
+	return nil
+}
+
+// matchingSections returns the access sections of project and its parents that match ref.
+// The sections of the project come first, then the ones of its parent and so on.
+// Within a project, the most specific section comes first.
+func (r *Resolver) matchingSections(project, ref string) ([]section, error) {
+	var sections []section
+	seen := map[string]bool{}
+	for name := project; name != "" && !seen[name]; {
+		seen[name] = true
+		access, err := r.projectAccess(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var local []section
+		for pattern, info := range access.Local {
+			ok, err := matchRef(pattern, ref)
+			if err != nil {
+				return nil, fmt.Errorf("project %s: %v", name, err)
+			}
+			if ok {
+				local = append(local, section{pattern: pattern, info: info})
+			}
+		}
+		sort.Slice(local, func(i, j int) bool { return moreSpecific(local[i].pattern, local[j].pattern) })
+		sections = append(sections, local...)
+
+		name = access.InheritsFrom.Name
+	}
+	return sections, nil
+}
+
+// projectAccess returns the access rights of project.
+func (r *Resolver) projectAccess(project string) (*gerrit.ProjectAccessInfo, error) {
+	if access, ok := r.access[project]; ok {
+		return access, nil
+	}
+	all, _, err := r.client.Access.ListAccessRights(&gerrit.ListAccessRightsOptions{Project: []string{project}})
+	if err != nil {
+		return nil, fmt.Errorf("access rights of %s: %v", project, err)
+	}
+	access, ok := (*all)[project]
+	if !ok {
+		return nil, fmt.Errorf("access rights of %s: project not found", project)
+	}
+	r.access[project] = &access
+	return &access, nil
+}
+
+// matchRef reports if the ref pattern of an access section matches ref.
+// Patterns are exact refs, prefixes ending with /* or regular expressions starting with ^.
+func matchRef(pattern, ref string) (bool, error) {
+	if strings.Contains(pattern, "${") {
+		return false, nil
+	}
+	if strings.HasPrefix(pattern, "^") {
+		re, err := regexp.Compile("^(?:" + pattern[1:] + ")$")
+		if err != nil {
+			return false, fmt.Errorf("invalid ref pattern %q: %v", pattern, err)
+		}
+		return re.MatchString(ref), nil
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(ref, strings.TrimSuffix(pattern, "*")), nil
+	}
+	return pattern == ref, nil
+}
+
+// moreSpecific reports if pattern a is more specific than pattern b:
+// exact refs before regular expressions before prefixes, longer patterns first.
+func moreSpecific(a, b string) bool {
+	rank := func(p string) int {
+		switch {
+		case strings.HasPrefix(p, "^"):
+			return 1
+		case strings.HasSuffix(p, "/*"):
+			return 2
+		default:
+			return 0
+		}
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra < rb
+	}
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a < b
+}
@@ -0,0 +1,205 @@
+// Package membership answers questions about effective group memberships and permissions on a Gerrit server,
+// like "is the user effectively a member of the group" or "which accounts can submit on refs/heads/main of a project".
+//
+// Gerrit only lists the direct members and directly included groups of a group.
+// The Resolver expands included groups recursively, detects cycles and caches every group it fetched,
+// so repeated questions against the same groups don't send the same requests again:
+//
+//	resolver := membership.NewResolver(client)
+//	ok, err := resolver.IsMember("john.doe@example.com", "Maintainers")
+//	holders, err := resolver.PermissionHolders("my/project", "refs/heads/main", "submit")
+//	for _, account := range holders.Accounts {
+//		fmt.Println(account.Email)
+//	}
+//
+// The cache is never invalidated, so a Resolver should only be used for a single report.
+package membership
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// System groups that contain every user.
+const (
+	AnonymousUsers  = "global:Anonymous-Users"
+	RegisteredUsers = "global:Registered-Users"
+)
+
+// Members are the effective members of a group.
+type Members struct {
+	// Accounts are the members of the group and of all groups included recursively, sorted by account ID.
+	Accounts []gerrit.AccountInfo
+	// Groups are the UUIDs of the group and all internal groups included recursively, sorted.
+	Groups []string
+	// Everyone reports if Anonymous Users or Registered Users are included, so every user is a member.
+	Everyone bool
+	// External are the UUIDs of included external groups, e.g. LDAP groups, sorted.
+	// Their members can't be listed through the Gerrit API and are not part of Accounts.
+	External []string
+}
+
+// Contains reports if the account with the ID accountID is an effective member.
+// It is true for every account if Everyone is set.
+func (m *Members) Contains(accountID int) bool {
+	if m.Everyone {
+		return true
+	}
+	for _, a := range m.Accounts {
+		if a.AccountID == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolver expands group memberships and computes permission holders.
+// It caches all groups, accounts and access rights it fetched. A Resolver is not safe for concurrent use.
+type Resolver struct {
+	client   *gerrit.Client
+	groups   map[string]*gerrit.GroupInfo
+	uuids    map[string]string
+	accounts map[string]*gerrit.AccountInfo
+	access   map[string]*gerrit.ProjectAccessInfo
+}
+
+// NewResolver returns a new Resolver for the Gerrit server of client.
+// The client must be allowed to see the members of the groups that are resolved.
+func NewResolver(client *gerrit.Client) *Resolver {
+	return &Resolver{
+		client:   client,
+		groups:   map[string]*gerrit.GroupInfo{},
+		uuids:    map[string]string{},
+		accounts: map[string]*gerrit.AccountInfo{},
+		access:   map[string]*gerrit.ProjectAccessInfo{},
+	}
+}
+
+// Members returns the effective members of group, which can be the name, UUID or legacy numeric ID of a group.
+// Included groups are expanded recursively. Cyclic includes are allowed and each group is only expanded once.
+func (r *Resolver) Members(group string) (*Members, error) {
+	uuid, err := r.groupUUID(group)
+	if err != nil {
+		return nil, err
+	}
+	return r.members([]string{uuid})
+}
+
+// IsMember reports if account, which can be anything that identifies an account, e.g. the account ID,
+// username or email, is an effective member of group.
+// Membership in included external groups can't be checked and is not taken into account.
+func (r *Resolver) IsMember(account, group string) (bool, error) {
+	info, err := r.account(account)
+	if err != nil {
+		return false, err
+	}
+	members, err := r.Members(group)
+	if err != nil {
+		return false, err
+	}
+	return members.Contains(info.AccountID), nil
+}
+
+// members returns the effective members of all groups given by UUID.
+func (r *Resolver) members(uuids []string) (*Members, error) {
+	m := &Members{}
+	accounts := map[int]gerrit.AccountInfo{}
+	visited := map[string]bool{}
+
+	queue := append([]string(nil), uuids...)
+	for len(queue) > 0 {
+		uuid := queue[0]
+		queue = queue[1:]
+		if visited[uuid] {
+			// Each group is only expanded once, which also breaks cycles.
+			continue
+		}
+		visited[uuid] = true
+
+		switch {
+		case uuid == AnonymousUsers || uuid == RegisteredUsers:
+			m.Everyone = true
+			continue
+		case gerrit.IsExternalGroupUUID(uuid):
+			m.External = append(m.External, uuid)
+			continue
+		}
+
+		group, err := r.group(uuid)
+		if err != nil {
+			return nil, err
+		}
+		m.Groups = append(m.Groups, uuid)
+		for _, a := range group.Members {
+			accounts[a.AccountID] = a
+		}
+		for _, included := range group.Includes {
+			queue = append(queue, unescape(included.ID))
+		}
+	}
+
+	for _, a := range accounts {
+		m.Accounts = append(m.Accounts, a)
+	}
+	sort.Slice(m.Accounts, func(i, j int) bool { return m.Accounts[i].AccountID < m.Accounts[j].AccountID })
+	sort.Strings(m.Groups)
+	sort.Strings(m.External)
+	return m, nil
+}
+
+// groupUUID returns the UUID of the group with the given name, UUID or legacy numeric ID.
+func (r *Resolver) groupUUID(group string) (string, error) {
+	if group == AnonymousUsers || group == RegisteredUsers || gerrit.IsExternalGroupUUID(group) {
+		return group, nil
+	}
+	if uuid, ok := r.uuids[group]; ok {
+		return uuid, nil
+	}
+	info, err := r.group(group)
+	if err != nil {
+		return "", err
+	}
+	uuid := unescape(info.ID)
+	r.uuids[group] = uuid
+	return uuid, nil
+}
+
+// group returns the group with its direct members and included groups.
+func (r *Resolver) group(group string) (*gerrit.GroupInfo, error) {
+	if info, ok := r.groups[group]; ok {
+		return info, nil
+	}
+	info, _, err := r.client.Groups.GetGroupDetail(url.QueryEscape(group))
+	if err != nil {
+		return nil, fmt.Errorf("group %s: %v", group, err)
+	}
+	r.groups[group] = info
+	r.groups[unescape(info.ID)] = info
+	return info, nil
+}
+
+// account returns the account identified by account.
+func (r *Resolver) account(account string) (*gerrit.AccountInfo, error) {
+	if info, ok := r.accounts[account]; ok {
+		return info, nil
+	}
+	info, _, err := r.client.Accounts.GetAccount(url.QueryEscape(account))
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %v", account, err)
+	}
+	r.accounts[account] = info
+	r.accounts[strconv.Itoa(info.AccountID)] = info
+	return info, nil
+}
+
+// unescape returns the group UUID of a GroupInfo ID, which Gerrit returns URL encoded.
+func unescape(id string) string {
+	if uuid, err := url.QueryUnescape(id); err == nil {
+		return uuid
+	}
+	return id
+}
@@ -0,0 +1,221 @@
+package gerrit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler sends a HTTP request to Gerrit and returns the raw response.
+// The innermost Handler of a Client is the underlying http.Client.
+type Handler func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps a Handler to run code before and after a request is sent.
+// A Middleware can modify the request, inspect the response
+// or stop the request entirely by returning an error without calling next.
+//
+// The logical operation of the request, e.g. "Changes.SetReview",
+// is available via OperationFromContext(req.Context()).
+type Middleware func(next Handler) Handler
+
+// operationContextKey is the context key of the operation name of a request.
+type operationContextKey struct{}
+
+// packagePath is the import path of this package.
+// It is used to find the service method that created a request.
+var packagePath = reflect.TypeOf(Client{}).PkgPath()
+
+// serviceMethodRegexp matches the symbol names of exported service methods,
+// e.g. "(*ChangesService).SetReview" or "(*ChangesService).SetReview.func1".
+// Unexported helpers like "(*ChangesService).getChangeInfoResponse" don't match.
+var serviceMethodRegexp = regexp.MustCompile(`^\(\*(\w+)Service\)\.([A-Z]\w*)`)
+
+// callerStackDepth is the number of frames callerOperation looks at.
+const callerStackDepth = 16
+
+// operationCache maps call stacks to the operation names that callerOperation found for them,
+// so the frames of a call stack are only resolved to function names once.
+var operationCache sync.Map
+
+// Use appends middleware to the middleware chain of the client.
+// Middleware is called in the order it was added:
+// The first middleware sees the request first and the response last.
+//
+// Middleware applies to all requests sent via Client.Do,
+// which includes every service of the client.
+// Use is safe to call while requests are in flight; they keep the chain they started with.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middlewareMu.Lock()
+	defer c.middlewareMu.Unlock()
+	c.middleware = append(c.middleware[:len(c.middleware):len(c.middleware)], middleware...)
+}
+
+// send sends req through the middleware chain of the client.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	c.middlewareMu.RLock()
+	middleware := c.middleware
+	c.middlewareMu.RUnlock()
+
+	h := Handler(c.client.Do)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h(req)
+}
+
+// ContextWithOperation returns a copy of ctx carrying the logical operation name.
+// This is useful to name requests that are created outside of the services:
+//
+//	req, err := client.NewRequest("GET", "plugins/my-plugin/status", nil)
+//	req = req.WithContext(gerrit.ContextWithOperation(req.Context(), "MyPlugin.Status"))
+func ContextWithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationContextKey{}, operation)
+}
+
+// OperationFromContext returns the logical operation name stored in ctx,
+// e.g. "Changes.SetReview" or "EventsLog.GetEvents".
+// It returns an empty string if ctx carries no operation.
+func OperationFromContext(ctx context.Context) string {
+	operation, _ := ctx.Value(operationContextKey{}).(string)
+	return operation
+}
+
+// callerOperation returns the name of the exported service method that is currently calling into the client.
+// The call stack is walked up, past unexported helpers, until the first exported service method of this package is found.
+// If the client was called from outside of this package, an empty string is returned.
+func callerOperation() string {
+	var pc [callerStackDepth]uintptr
+	n := runtime.Callers(3, pc[:])
+	if operation, ok := operationCache.Load(pc); ok {
+		return operation.(string)
+	}
+
+	operation := resolveOperation(pc[:n])
+	operationCache.Store(pc, operation)
+	return operation
+}
+
+// resolveOperation returns the operation name of the first exported service method in the call stack pc.
+func resolveOperation(pc []uintptr) string {
+	frames := runtime.CallersFrames(pc)
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePath+".") {
+			return ""
+		}
+
+		name := strings.TrimPrefix(frame.Function, packagePath+".")
+		if m := serviceMethodRegexp.FindStringSubmatch(name); m != nil {
+			return m[1] + "." + m[2]
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
+
+// RequestInterceptor returns a Middleware that calls f before a request is sent.
+// If f returns an error, the request is not sent and the error is returned to the caller.
+func RequestInterceptor(f func(operation string, req *http.Request) error) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if err := f(OperationFromContext(req.Context()), req); err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	}
+}
+
+// ResponseInterceptor returns a Middleware that calls f after a response was received.
+// f is not called if the request failed on the transport level.
+// If f returns an error, the error is returned to the caller.
+func ResponseInterceptor(f func(operation string, resp *http.Response) error) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			resp, err := next(req)
+			if err != nil {
+				return resp, err
+			}
+			if err := f(OperationFromContext(req.Context()), resp); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			return resp, nil
+		}
+	}
+}
+
+// LoggingMiddleware returns a Middleware that logs every request with
+// its operation, method, URL, status and duration to logger.
+// If logger is nil, the standard logger of the log package is used.
+// Request headers and bodies are not logged, so credentials don't end up in logs.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	logf := log.Printf
+	if logger != nil {
+		logf = logger.Printf
+	}
+
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			operation := OperationFromContext(req.Context())
+			if operation == "" {
+				operation = "-"
+			}
+
+			start := time.Now()
+			resp, err := next(req)
+			duration := time.Since(start)
+
+			if err != nil {
+				logf("gerrit: %s %s %s failed after %s: %v", operation, req.Method, req.URL, duration, err)
+				return resp, err
+			}
+			logf("gerrit: %s %s %s -> %s (%s)", operation, req.Method, req.URL, resp.Status, duration)
+			return resp, nil
+		}
+	}
+}
+
+// DefaultRequestIDHeader is the header used by RequestIDMiddleware if no header is given.
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// RequestIDMiddleware returns a Middleware that adds a unique ID to every request.
+// The ID is sent in header, or DefaultRequestIDHeader if header is empty.
+// IDs are created by generate, or are random hex strings if generate is nil.
+// Requests that already carry the header are not modified.
+func RequestIDMiddleware(header string, generate func() string) Middleware {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	if generate == nil {
+		generate = randomRequestID
+	}
+
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(header) == "" {
+				req.Header.Set(header, generate())
+			}
+			return next(req)
+		}
+	}
+}
+
+// randomRequestID returns a random 128 bit ID as hex string.
+func randomRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
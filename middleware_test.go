@@ -0,0 +1,214 @@
+package gerrit_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+func TestClient_Use_Operation(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/changes/123/revisions/456/review", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`{"labels":{"Code-Review":1}}`)
+	})
+	testMux.HandleFunc("/config/server/version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`"2.13.1"`)
+	})
+	testMux.HandleFunc("/plugins/events-log/events/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(fakeEvents)
+	})
+
+	var operations []string
+	testClient.Use(gerrit.RequestInterceptor(func(operation string, req *http.Request) error {
+		operations = append(operations, operation)
+		return nil
+	}))
+
+	if _, _, err := testClient.Changes.SetReview("123", "456", &gerrit.ReviewInput{}); err != nil {
+		t.Fatalf("Changes.SetReview returned error: %v", err)
+	}
+	if _, _, err := testClient.Config.GetVersion(); err != nil {
+		t.Fatalf("Config.GetVersion returned error: %v", err)
+	}
+	if _, _, err := testClient.EventsLog.GetEvents(&gerrit.EventsLogOptions{}); err != nil {
+		t.Fatalf("EventsLog.GetEvents returned error: %v", err)
+	}
+	req, _ := testClient.NewRequest("GET", "config/server/version", nil)
+	if _, err := testClient.Do(req, nil); err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+
+	want := []string{"Changes.SetReview", "Config.GetVersion", "EventsLog.GetEvents", ""}
+	if !reflect.DeepEqual(operations, want) {
+		t.Errorf("Operations %v, want %v", operations, want)
+	}
+}
+
+func TestClient_Use_OperationSkipsHelpers(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/changes/123", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`{"_number":123}`)
+	})
+	testMux.HandleFunc("/plugins/replication/gerrit~status", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`{"id":"replication"}`)
+	})
+	testMux.HandleFunc("/groups/developers", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`{"name":"developers"}`)
+	})
+	testMux.HandleFunc("/projects/my-project/submit_requirements/Verified", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`{"name":"Verified"}`)
+	})
+
+	var operations []string
+	testClient.Use(gerrit.RequestInterceptor(func(operation string, req *http.Request) error {
+		operations = append(operations, operation)
+		return nil
+	}))
+
+	// Each of these methods sends its request through an unexported helper.
+	for i := 0; i < 2; i++ {
+		testClient.Changes.GetChange("123", nil)
+		testClient.Plugins.GetPluginStatus("replication")
+		testClient.Groups.GetGroup("developers")
+		testClient.Projects.CreateSubmitRequirement("my-project", "Verified", &gerrit.SubmitRequirementInput{SubmittabilityExpression: "label:Verified=MAX"})
+	}
+
+	want := []string{"Changes.GetChange", "Plugins.GetPluginStatus", "Groups.GetGroup", "Projects.CreateSubmitRequirement"}
+	want = append(want, want...)
+	if !reflect.DeepEqual(operations, want) {
+		t.Errorf("Operations %v, want %v", operations, want)
+	}
+}
+
+func TestClient_Use_Order(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/config/server/version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`"2.13.1"`)
+	})
+
+	var calls []string
+	trace := func(name string) gerrit.Middleware {
+		return func(next gerrit.Handler) gerrit.Handler {
+			return func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, "before "+name)
+				resp, err := next(req)
+				calls = append(calls, "after "+name)
+				return resp, err
+			}
+		}
+	}
+	testClient.Use(trace("first"), trace("second"))
+
+	if _, _, err := testClient.Config.GetVersion(); err != nil {
+		t.Fatalf("Config.GetVersion returned error: %v", err)
+	}
+
+	want := []string{"before first", "before second", "after second", "after first"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Calls %v, want %v", calls, want)
+	}
+}
+
+func TestRequestInterceptor_Error(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/config/server/version", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Request was sent although the interceptor returned an error")
+	})
+
+	denied := errors.New("denied")
+	testClient.Use(gerrit.RequestInterceptor(func(operation string, req *http.Request) error {
+		return denied
+	}))
+
+	_, _, err := testClient.Config.GetVersion()
+	if err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("Config.GetVersion returned error %v, want %v", err, denied)
+	}
+}
+
+func TestResponseInterceptor(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/config/server/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Gerrit-Version", "2.13.1")
+		fmt.Fprint(w, `)]}'`+"\n"+`"2.13.1"`)
+	})
+
+	var header string
+	testClient.Use(gerrit.ResponseInterceptor(func(operation string, resp *http.Response) error {
+		header = operation + " " + resp.Header.Get("X-Gerrit-Version")
+		return nil
+	}))
+
+	if _, _, err := testClient.Config.GetVersion(); err != nil {
+		t.Fatalf("Config.GetVersion returned error: %v", err)
+	}
+	if want := "Config.GetVersion 2.13.1"; header != want {
+		t.Errorf("Intercepted %q, want %q", header, want)
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/a/config/server/version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`"2.13.1"`)
+	})
+
+	buf := new(bytes.Buffer)
+	testClient.Use(gerrit.LoggingMiddleware(log.New(buf, "", 0)))
+	testClient.Authentication.SetBasicAuth("admin", "secret")
+
+	if _, _, err := testClient.Config.GetVersion(); err != nil {
+		t.Fatalf("Config.GetVersion returned error: %v", err)
+	}
+
+	got := buf.String()
+	want := "gerrit: Config.GetVersion GET " + testServer.URL + "/a/config/server/version -> 200 OK"
+	if !strings.HasPrefix(got, want) {
+		t.Errorf("Logged %q, want prefix %q", got, want)
+	}
+	if strings.Contains(got, "secret") {
+		t.Errorf("Log contains credentials: %q", got)
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var ids []string
+	testMux.HandleFunc("/config/server/version", func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get(gerrit.DefaultRequestIDHeader))
+		fmt.Fprint(w, `)]}'`+"\n"+`"2.13.1"`)
+	})
+
+	testClient.Use(gerrit.RequestIDMiddleware("", nil))
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := testClient.Config.GetVersion(); err != nil {
+			t.Fatalf("Config.GetVersion returned error: %v", err)
+		}
+	}
+
+	if len(ids) != 2 || len(ids[0]) != 32 || ids[0] == ids[1] {
+		t.Errorf("Request IDs %v, want two different IDs", ids)
+	}
+}
@@ -0,0 +1,256 @@
+package offboarding
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// changePageSize is the number of changes requested per query.
+const changePageSize = 100
+
+// Offboarder plans and applies the offboarding of accounts against a Gerrit server.
+type Offboarder struct {
+	// Successor is the account (ID, username or email) that takes over reviews and owned open changes.
+	// If empty, the account is only removed as reviewer and owned changes are left as they are.
+	Successor string
+
+	client *gerrit.Client
+}
+
+// NewOffboarder returns a new Offboarder for the Gerrit server of client.
+// The client must be authenticated as administrator.
+func NewOffboarder(client *gerrit.Client) *Offboarder {
+	return &Offboarder{client: client}
+}
+
+// Plan collects everything that will be touched to offboard account, but changes nothing.
+// account can be anything that identifies an account, e.g. the account ID, username or email.
+//
+// The steps are ordered so the account loses its access first:
+// deactivation, group memberships, SSH and GPG keys and finally the hand-off of open changes.
+func (o *Offboarder) Plan(account string) (*Plan, error) {
+	info, _, err := o.client.Accounts.GetAccount(account)
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %v", account, err)
+	}
+	id := strconv.Itoa(info.AccountID)
+
+	plan := &Plan{
+		Account:   *info,
+		Successor: o.Successor,
+		Steps:     []Step{{Kind: Deactivate, Target: id, Status: Pending}},
+	}
+
+	groups, _, err := o.client.Accounts.ListGroups(id)
+	if err != nil {
+		return nil, fmt.Errorf("groups: %v", err)
+	}
+	for _, group := range *groups {
+		// Gerrit returns URL encoded UUIDs, e.g. "global%3ARegistered-Users".
+		uuid, err := url.QueryUnescape(group.ID)
+		if err != nil {
+			uuid = group.ID
+		}
+		// Only internal groups can be edited. External and system groups have a scheme prefix, e.g. ldap: or global:.
+		if gerrit.IsExternalGroupUUID(uuid) {
+			continue
+		}
+		plan.Steps = append(plan.Steps, Step{Kind: RemoveFromGroup, Target: uuid, Description: group.Name, Status: Pending})
+	}
+
+	sshKeys, _, err := o.client.Accounts.ListSSHKeys(id)
+	if err != nil {
+		return nil, fmt.Errorf("SSH keys: %v", err)
+	}
+	for _, key := range *sshKeys {
+		plan.Steps = append(plan.Steps, Step{Kind: DeleteSSHKey, Target: strconv.Itoa(key.Seq), Description: key.Comment, Status: Pending})
+	}
+
+	gpgKeys, _, err := o.client.Accounts.ListGPGKeys(id)
+	if err != nil {
+		return nil, fmt.Errorf("GPG keys: %v", err)
+	}
+	gpgIDs := make([]string, 0, len(*gpgKeys))
+	for keyID := range *gpgKeys {
+		gpgIDs = append(gpgIDs, keyID)
+	}
+	sort.Strings(gpgIDs)
+	for _, keyID := range gpgIDs {
+		plan.Steps = append(plan.Steps, Step{Kind: DeleteGPGKey, Target: keyID, Description: (*gpgKeys)[keyID].Fingerprint, Status: Pending})
+	}
+
+	reviewing, err := o.queryChanges(fmt.Sprintf("reviewer:%s status:open", id))
+	if err != nil {
+		return nil, fmt.Errorf("reviewed changes: %v", err)
+	}
+	for _, change := range reviewing {
+		// Owners are reviewers of their own changes in some Gerrit versions; they are handed off below.
+		if change.Owner.AccountID == info.AccountID {
+			continue
+		}
+		plan.Steps = append(plan.Steps, Step{Kind: ReplaceReviewer, Target: change.ID, Description: describeChange(change), Status: Pending})
+	}
+
+	if o.Successor != "" {
+		owned, err := o.queryChanges(fmt.Sprintf("owner:%s status:open", id))
+		if err != nil {
+			return nil, fmt.Errorf("owned changes: %v", err)
+		}
+		for _, change := range owned {
+			plan.Steps = append(plan.Steps, Step{Kind: HandOffChange, Target: change.ID, Description: describeChange(change), Status: Pending})
+		}
+	}
+
+	return plan, nil
+}
+
+// queryChanges returns all changes matching query.
+func (o *Offboarder) queryChanges(query string) ([]gerrit.ChangeInfo, error) {
+	var changes []gerrit.ChangeInfo
+	opt := &gerrit.QueryChangeOptions{}
+	opt.Query = []string{query}
+	opt.Limit = changePageSize
+	for {
+		page, _, err := o.client.Changes.QueryChanges(opt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *page...)
+		if len(*page) == 0 || !(*page)[len(*page)-1].MoreChanges {
+			return changes, nil
+		}
+		opt.Start += len(*page)
+	}
+}
+
+// describeChange formats a change as "project~number: subject".
+func describeChange(change gerrit.ChangeInfo) string {
+	return fmt.Sprintf("%s~%d: %s", change.Project, change.Number, change.Subject)
+}
+
+// Apply executes all steps of the plan that are not done yet and updates their status.
+// A failing step doesn't stop the other steps, as they are independent of each other.
+// If any step failed, an error is returned and Apply can be run again with the same plan
+// to retry the failed steps.
+//
+// Steps are idempotent: if there is nothing left to do, e.g. because a key was already deleted,
+// the step is done. A group the account is only an indirect member of, through an included group,
+// is only done once the account lost that membership as well, e.g. by a step for the included group.
+// Otherwise the step fails with an error that reports the indirect membership.
+func (o *Offboarder) Apply(plan *Plan) error {
+	id := strconv.Itoa(plan.Account.AccountID)
+	for i := range plan.Steps {
+		step := &plan.Steps[i]
+		if step.Status == Done {
+			continue
+		}
+
+		if err := o.apply(id, plan.Successor, step); err != nil {
+			step.Status = Failed
+			step.Error = err.Error()
+		} else {
+			step.Status = Done
+			step.Error = ""
+		}
+	}
+
+	if failed := plan.Count(Failed); failed > 0 {
+		return fmt.Errorf("offboarding of account %s: %d of %d steps failed", id, failed, len(plan.Steps))
+	}
+	return nil
+}
+
+// apply executes a single step for the account id.
+func (o *Offboarder) apply(id, successor string, step *Step) error {
+	var resp *gerrit.Response
+	var err error
+
+	switch step.Kind {
+	case Deactivate:
+		resp, err = o.client.Accounts.DeleteActive(id)
+		// The account is already inactive.
+		if isStatus(resp, http.StatusConflict) {
+			return nil
+		}
+	case RemoveFromGroup:
+		resp, err = o.client.Groups.DeleteGroupMember(url.QueryEscape(step.Target), id)
+		// Gerrit answers 404 if the account is no direct member, e.g. because it is only a member of an included group.
+		if isStatus(resp, http.StatusNotFound) {
+			return o.checkRemovedFromGroup(step.Target, id)
+		}
+		return err
+	case DeleteSSHKey:
+		resp, err = o.client.Accounts.DeleteSSHKey(id, step.Target)
+	case DeleteGPGKey:
+		resp, err = o.client.Accounts.DeleteGPGKey(id, step.Target)
+	case ReplaceReviewer:
+		if successor != "" {
+			if err := o.addReviewer(step.Target, successor); err != nil {
+				return err
+			}
+		}
+		resp, err = o.client.Changes.DeleteReviewer(step.Target, id)
+	case HandOffChange:
+		if successor == "" {
+			return fmt.Errorf("no successor to hand off the change to")
+		}
+		return o.addReviewer(step.Target, successor)
+	default:
+		return fmt.Errorf("unknown step %q", step.Kind)
+	}
+
+	// The target is already gone, e.g. the account is no member of the group anymore.
+	if isStatus(resp, http.StatusNotFound) {
+		return nil
+	}
+	return err
+}
+
+// checkRemovedFromGroup checks that the account id is no member of the group with the given UUID anymore,
+// neither directly nor through an included group.
+// An error is returned if the group doesn't exist, as the membership can't be checked then.
+func (o *Offboarder) checkRemovedFromGroup(uuid, id string) error {
+	accountID, _ := strconv.Atoi(id)
+	for _, recursive := range []bool{false, true} {
+		members, resp, err := o.client.Groups.ListGroupMembers(url.QueryEscape(uuid), &gerrit.ListGroupMembersOptions{Recursive: recursive})
+		if isStatus(resp, http.StatusNotFound) {
+			return fmt.Errorf("group %s doesn't exist", uuid)
+		}
+		if err != nil {
+			return err
+		}
+		for _, member := range *members {
+			if member.AccountID != accountID {
+				continue
+			}
+			if recursive {
+				return fmt.Errorf("account %s is an indirect member of group %s through an included group", id, uuid)
+			}
+			return fmt.Errorf("account %s is still a member of group %s", id, uuid)
+		}
+	}
+	return nil
+}
+
+// addReviewer adds reviewer to a change.
+// Adding an existing reviewer succeeds, so it is safe to retry.
+func (o *Offboarder) addReviewer(changeID, reviewer string) error {
+	result, _, err := o.client.Changes.AddReviewer(changeID, &gerrit.ReviewerInput{Reviewer: reviewer, Confirmed: true})
+	if err != nil {
+		return err
+	}
+	if result.Error != "" {
+		return fmt.Errorf("add reviewer %s: %s", reviewer, result.Error)
+	}
+	return nil
+}
+
+// isStatus reports if resp has the given status code.
+func isStatus(resp *gerrit.Response, code int) bool {
+	return resp != nil && resp.Response != nil && resp.StatusCode == code
+}
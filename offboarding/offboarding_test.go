@@ -0,0 +1,244 @@
+package offboarding_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andygrunwald/go-gerrit"
+	"github.com/andygrunwald/go-gerrit/gerrittest"
+	"github.com/andygrunwald/go-gerrit/offboarding"
+)
+
+// UUIDs of the internal groups of account john: he is a direct member of Maintainers,
+// and an indirect member of Release Managers, which includes Maintainers.
+const (
+	maintainersUUID     = "6a1e70e1a88782771a91808c8af9bbb7a9871389"
+	releaseManagersUUID = "2f3c5e7a9b1d4f6a8c0e2b4d6f8a0c2e4b6d8f0a"
+)
+
+// setup starts a fake server with account john (1000096), who is member of an internal, an external and
+// the system groups and an indirect member of another internal group, has an SSH and a GPG key,
+// reviews two changes and owns one open change.
+func setup(t *testing.T) (*gerrittest.Server, *gerrit.Client, *offboarding.Offboarder) {
+	server := gerrittest.NewServer()
+	server.AddProject(gerrit.ProjectInfo{Name: "go"})
+
+	server.AddAccount(gerrit.AccountInfo{AccountID: 1000000, Name: "Administrator", Username: "admin"}, "admin-secret")
+	john := server.AddAccount(gerrit.AccountInfo{AccountID: 1000096, Name: "John Doe", Email: "john@example.com", Username: "john"}, "")
+	server.AddAccount(gerrit.AccountInfo{AccountID: 1000097, Name: "Alice", Username: "alice"}, "")
+	server.AddAccount(gerrit.AccountInfo{AccountID: 1000098, Name: "Bob", Username: "bob"}, "")
+	server.AddAccount(gerrit.AccountInfo{AccountID: 1000099, Name: "Jane Roe", Username: "jane"}, "")
+
+	maintainers := server.AddGroup(gerrit.GroupInfo{ID: maintainersUUID, Name: "Maintainers", Members: []gerrit.AccountInfo{john}})
+	server.AddGroup(gerrit.GroupInfo{ID: releaseManagersUUID, Name: "Release Managers", Includes: []gerrit.GroupInfo{maintainers}})
+	server.AddGroup(gerrit.GroupInfo{ID: "ldap:cn=eng", Name: "eng", Members: []gerrit.AccountInfo{john}})
+
+	server.AddSSHKey(john.AccountID, gerrit.SSHKeyInfo{SSHPublicKey: "ssh-rsa AAAA john@laptop", Comment: "john@laptop", Valid: true})
+	server.AddGPGKey(john.AccountID, gerrit.GpgKeyInfo{ID: "AFC8A49B", Fingerprint: "0192 723D 42D1 0C5B 32A6  E1E0 9350 9E4B AFC8 A49B"})
+
+	// Changes are listed most recently updated first.
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	server.Now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	changes := []gerrit.ChangeInfo{
+		{Number: 3, ChangeID: "I3", Subject: "Add docs", Owner: gerrit.AccountInfo{AccountID: 1000098}},
+		{Number: 2, ChangeID: "I2", Subject: "Own change", Owner: john},
+		{Number: 1, ChangeID: "I1", Subject: "Fix build", Owner: gerrit.AccountInfo{AccountID: 1000097}},
+	}
+	client := server.Client()
+	client.Authentication.SetBasicAuth("admin", "admin-secret")
+	for _, change := range changes {
+		change.Project = "go"
+		change.Branch = "master"
+		info, err := server.AddChange(change)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := client.Changes.AddReviewer(info.ID, &gerrit.ReviewerInput{Reviewer: "john"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	offboarder := offboarding.NewOffboarder(client)
+	offboarder.Successor = "jane"
+	return server, client, offboarder
+}
+
+// writeRequests returns all requests after the first skip ones, that are not GET requests.
+func writeRequests(server *gerrittest.Server, skip int) []string {
+	var requests []string
+	for _, r := range server.Requests()[skip:] {
+		if r.Method != "GET" {
+			requests = append(requests, r.Method+" "+r.Path)
+		}
+	}
+	return requests
+}
+
+func TestOffboarder_Plan(t *testing.T) {
+	server, _, offboarder := setup(t)
+	defer server.Close()
+
+	before := len(server.Requests())
+	plan, err := offboarder.Plan("john")
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	if requests := writeRequests(server, before); len(requests) != 0 {
+		t.Errorf("Plan sent write requests %v", requests)
+	}
+
+	var buf bytes.Buffer
+	if err := plan.Print(&buf); err != nil {
+		t.Fatalf("Print returned error: %v", err)
+	}
+	want := `Offboarding John Doe <john@example.com> (1000096)
+Successor: jane
+  [pending] deactivate 1000096
+  [pending] remove-from-group 6a1e70e1a88782771a91808c8af9bbb7a9871389: Maintainers
+  [pending] remove-from-group 2f3c5e7a9b1d4f6a8c0e2b4d6f8a0c2e4b6d8f0a: Release Managers
+  [pending] delete-ssh-key 1: john@laptop
+  [pending] delete-gpg-key AFC8A49B: 0192 723D 42D1 0C5B 32A6  E1E0 9350 9E4B AFC8 A49B
+  [pending] replace-reviewer go~master~I1: go~1: Fix build
+  [pending] replace-reviewer go~master~I3: go~3: Add docs
+  [pending] hand-off-change go~master~I2: go~2: Own change
+8 steps: 0 done, 0 failed, 8 pending
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Print wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestOffboarder_Apply_Resume(t *testing.T) {
+	server, client, offboarder := setup(t)
+	defer server.Close()
+
+	plan, err := offboarder.Plan("john")
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+
+	// The user deleted the key in the meantime.
+	if _, err := client.Accounts.DeleteSSHKey("1000096", "1"); err != nil {
+		t.Fatal(err)
+	}
+	server.InjectFault(gerrittest.Fault{Method: "DELETE", Path: "/groups/" + maintainersUUID + "/members/1000096", Status: 500, Times: 1})
+
+	before := len(server.Requests())
+	if err := offboarder.Apply(plan); err == nil {
+		t.Fatal("Apply returned no error for a failed step")
+	}
+	if got := plan.Count(offboarding.Failed); got != 2 {
+		t.Errorf("%d failed steps, want 2", got)
+	}
+	if step := plan.Steps[1]; step.Status != offboarding.Failed || step.Error == "" {
+		t.Errorf("Step %v, want failed with error", step)
+	}
+	// john is still a member of Maintainers, so the 404 for Release Managers doesn't mean he lost the membership.
+	if step := plan.Steps[2]; step.Status != offboarding.Failed || !strings.Contains(step.Error, "indirect member") {
+		t.Errorf("Step %v, want failed because of the indirect membership", step)
+	}
+
+	wantRequests := []string{
+		"DELETE /accounts/1000096/active",
+		"DELETE /groups/" + maintainersUUID + "/members/1000096",
+		"DELETE /groups/" + releaseManagersUUID + "/members/1000096",
+		"DELETE /accounts/1000096/sshkeys/1",
+		"DELETE /accounts/1000096/gpgkeys/AFC8A49B",
+		"POST /changes/go~master~I1/reviewers",
+		"DELETE /changes/go~master~I1/reviewers/1000096",
+		"POST /changes/go~master~I3/reviewers",
+		"DELETE /changes/go~master~I3/reviewers/1000096",
+		"POST /changes/go~master~I2/reviewers",
+	}
+	if got := writeRequests(server, before); !reflect.DeepEqual(got, wantRequests) {
+		t.Errorf("Requests\n%v\nwant\n%v", got, wantRequests)
+	}
+
+	// Store and load the plan, as a new process would do to resume.
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed := new(offboarding.Plan)
+	if err := json.Unmarshal(data, resumed); err != nil {
+		t.Fatal(err)
+	}
+
+	before = len(server.Requests())
+	if err := offboarder.Apply(resumed); err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if !resumed.Complete() {
+		t.Errorf("Plan not complete after resume: %+v", resumed.Steps)
+	}
+	wantRequests = []string{
+		"DELETE /groups/" + maintainersUUID + "/members/1000096",
+		"DELETE /groups/" + releaseManagersUUID + "/members/1000096",
+	}
+	if got := writeRequests(server, before); !reflect.DeepEqual(got, wantRequests) {
+		t.Errorf("Requests on resume %v, want %v", got, wantRequests)
+	}
+
+	groups, _, err := client.Accounts.ListGroups("1000096")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, group := range *groups {
+		if group.Name == "Maintainers" || group.Name == "Release Managers" {
+			t.Errorf("Account is still member of %+v", group)
+		}
+	}
+	reviewers, _, err := client.Changes.ListReviewers("go~master~I1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*reviewers) != 1 || (*reviewers)[0].AccountID != 1000099 {
+		t.Errorf("Reviewers of go~master~I1 are %+v, want only the successor", *reviewers)
+	}
+}
+
+func TestOffboarder_Plan_WithoutSuccessor(t *testing.T) {
+	server, _, offboarder := setup(t)
+	defer server.Close()
+
+	offboarder.Successor = ""
+	plan, err := offboarder.Plan("john")
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	for _, step := range plan.Steps {
+		if step.Kind == offboarding.HandOffChange {
+			t.Errorf("Plan contains hand-off step %v without successor", step)
+		}
+	}
+}
+
+func TestOffboarder_Apply_DeletedGroup(t *testing.T) {
+	server, client, offboarder := setup(t)
+	defer server.Close()
+
+	plan, err := offboarder.Plan("john")
+	if err != nil {
+		t.Fatalf("Plan returned error: %v", err)
+	}
+	if _, err := client.Groups.DeleteGroup(releaseManagersUUID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := offboarder.Apply(plan); err == nil {
+		t.Fatal("Apply returned no error for a deleted group")
+	}
+	if step := plan.Steps[2]; step.Status != offboarding.Failed || !strings.Contains(step.Error, "doesn't exist") {
+		t.Errorf("Step %v, want failed because the group doesn't exist", step)
+	}
+	if got := plan.Count(offboarding.Failed); got != 1 {
+		t.Errorf("%d failed steps, want 1", got)
+	}
+}
@@ -0,0 +1,140 @@
+// Package offboarding deactivates the Gerrit account of a leaving user and hands off their work.
+//
+// Offboarding is done in two phases. Offboarder.Plan collects everything that will be touched
+// (groups, open changes, SSH and GPG keys) without changing anything, so the plan can be reviewed
+// as dry-run report. Offboarder.Apply then executes the steps of the plan and records the result of each step.
+//
+// Plans can be stored as JSON. If Apply fails part-way, it can be run again with the same plan
+// (e.g. loaded from disk in a new process): steps that are already done are skipped.
+//
+//	offboarder := offboarding.NewOffboarder(client)
+//	offboarder.Successor = "jane.roe@example.com"
+//	plan, err := offboarder.Plan("john.doe@example.com")
+//	if err != nil {
+//		...
+//	}
+//	plan.Print(os.Stdout)
+//	err = offboarder.Apply(plan)
+package offboarding
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// StepKind is the kind of a step of an offboarding plan.
+type StepKind string
+
+const (
+	// Deactivate deactivates the account.
+	Deactivate StepKind = "deactivate"
+	// RemoveFromGroup removes the account from an internal group.
+	// Target is the UUID of the group. Plans also contain groups the account is only an indirect member of.
+	RemoveFromGroup StepKind = "remove-from-group"
+	// ReplaceReviewer adds the successor as reviewer of an open change and removes the account.
+	// If there is no successor, the account is only removed. Target is the ID of the change.
+	ReplaceReviewer StepKind = "replace-reviewer"
+	// HandOffChange adds the successor as reviewer of an open change that is owned by the account.
+	// Gerrit can't transfer the ownership of a change. Target is the ID of the change.
+	HandOffChange StepKind = "hand-off-change"
+	// DeleteSSHKey deletes an SSH key of the account.
+	// Target is the sequence number of the key.
+	DeleteSSHKey StepKind = "delete-ssh-key"
+	// DeleteGPGKey deletes a GPG key of the account.
+	// Target is the ID of the key.
+	DeleteGPGKey StepKind = "delete-gpg-key"
+)
+
+// Status is the state of a step.
+type Status string
+
+const (
+	// Pending steps have not been executed yet.
+	Pending Status = "pending"
+	// Done steps have been executed successfully, or there was nothing left to do.
+	Done Status = "done"
+	// Failed steps have been executed, but failed. They are retried by the next Apply.
+	Failed Status = "failed"
+)
+
+// Step is a single action of an offboarding plan.
+type Step struct {
+	Kind   StepKind `json:"kind"`
+	Target string   `json:"target,omitempty"`
+	// Description describes the target for humans, e.g. the name of a group or the subject of a change.
+	Description string `json:"description,omitempty"`
+
+	Status Status `json:"status"`
+	// Error is the error of the last execution of a failed step.
+	Error string `json:"error,omitempty"`
+}
+
+// String formats the step as a single line of a report.
+func (s Step) String() string {
+	line := fmt.Sprintf("[%s] %s", s.Status, s.Kind)
+	if s.Target != "" {
+		line += " " + s.Target
+	}
+	if s.Description != "" {
+		line += ": " + s.Description
+	}
+	if s.Error != "" {
+		line += " (" + s.Error + ")"
+	}
+	return line
+}
+
+// Plan lists the steps to offboard an account.
+type Plan struct {
+	Account gerrit.AccountInfo `json:"account"`
+	// Successor is the account that takes over reviews and owned changes. It may be empty.
+	Successor string `json:"successor,omitempty"`
+	Steps     []Step `json:"steps"`
+}
+
+// Count returns the number of steps with the given status.
+func (p *Plan) Count(status Status) int {
+	n := 0
+	for _, step := range p.Steps {
+		if step.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Complete reports if all steps are done.
+func (p *Plan) Complete() bool {
+	return p.Count(Done) == len(p.Steps)
+}
+
+// Print writes a human readable report of the plan to w, one step per line.
+// Before Apply, it is the dry-run report of everything that will be touched.
+func (p *Plan) Print(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "Offboarding %s\n", formatAccount(p.Account))
+	if p.Successor != "" {
+		fmt.Fprintf(b, "Successor: %s\n", p.Successor)
+	}
+	for _, step := range p.Steps {
+		fmt.Fprintf(b, "  %s\n", step)
+	}
+	fmt.Fprintf(b, "%d steps: %d done, %d failed, %d pending\n",
+		len(p.Steps), p.Count(Done), p.Count(Failed), p.Count(Pending))
+	return b.Flush()
+}
+
+// formatAccount formats an account as "Name <email> (id)".
+func formatAccount(a gerrit.AccountInfo) string {
+	s := a.Name
+	if s == "" {
+		s = a.Username
+	}
+	if a.Email != "" {
+		s += " <" + a.Email + ">"
+	}
+	return fmt.Sprintf("%s (%d)", s, a.AccountID)
+}
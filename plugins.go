@@ -78,8 +78,9 @@ func (s *PluginsService) GetPluginStatus(pluginID string) (*PluginInfo, *Respons
 //
 // As response a PluginInfo entity is returned that describes the plugin.
 // If an existing plugin was overwritten the response is “200 OK”.
+// To upload a local plugin file as binary data, use UploadPlugin.
 //
-// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#set-dashboard
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-plugins.html#install-plugin
 func (s *PluginsService) InstallPlugin(pluginID string, input *PluginInput) (*PluginInfo, *Response, error) {
 	u := fmt.Sprintf("plugins/%s", pluginID)
 	return s.requestWithPluginInfoResponse("PUT", u, input)
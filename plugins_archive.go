@@ -0,0 +1,270 @@
+package gerrit
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PluginArchive is a local plugin file, either a .jar or a .js plugin, that was verified before upload.
+type PluginArchive struct {
+	// Path is the path of the plugin file.
+	Path string
+	// Name is the name of the plugin: the Gerrit-PluginName of the manifest for jar plugins,
+	// or the file name without extension for JavaScript plugins.
+	Name string
+	// Version is the Implementation-Version of the manifest. It is empty for JavaScript plugins.
+	Version string
+	// APIVersion is the Gerrit-ApiVersion of the manifest, e.g. "3.9.1". It is empty for JavaScript plugins.
+	APIVersion string
+	// SHA256 is the hex encoded SHA-256 checksum of the file.
+	SHA256 string
+	// JavaScript reports if the plugin is a JavaScript plugin.
+	JavaScript bool
+}
+
+// EnsurePluginResult describes what PluginsService.EnsurePlugin did.
+type EnsurePluginResult string
+
+const (
+	// PluginInstalled means the plugin was not installed and has been uploaded.
+	PluginInstalled EnsurePluginResult = "installed"
+	// PluginReplaced means a different version of the plugin was installed.
+	// It has been overwritten by the upload, which makes Gerrit reload the plugin.
+	PluginReplaced EnsurePluginResult = "replaced"
+	// PluginEnabled means the same version of the plugin was installed, but disabled. It has been enabled.
+	PluginEnabled EnsurePluginResult = "enabled"
+	// PluginUnchanged means the same version of the plugin was installed and enabled already.
+	PluginUnchanged EnsurePluginResult = "unchanged"
+)
+
+// OpenPluginArchive reads and verifies the plugin file at path.
+// Jar plugins must contain a manifest with the Gerrit-PluginName attribute.
+// Files with the extension .js are treated as JavaScript plugins, which have no manifest.
+func OpenPluginArchive(path string) (*PluginArchive, error) {
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &PluginArchive{Path: path, SHA256: sum}
+	base := filepath.Base(path)
+	switch ext := filepath.Ext(base); ext {
+	case ".js":
+		a.JavaScript = true
+		a.Name = strings.TrimSuffix(base, ext)
+	case ".jar":
+		if err := a.readManifest(); err != nil {
+			return nil, fmt.Errorf("plugin %s: %v", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("plugin %s: unsupported file extension %q, want .jar or .js", path, ext)
+	}
+	return a, nil
+}
+
+// ID returns the plugin ID the plugin is installed as.
+// JavaScript plugins are installed with the extension .js.
+func (a *PluginArchive) ID() string {
+	if a.JavaScript {
+		return a.Name + ".js"
+	}
+	return a.Name
+}
+
+// VerifyChecksum returns an error if the SHA-256 checksum of the plugin file is not the hex encoded sum.
+func (a *PluginArchive) VerifyChecksum(sum string) error {
+	if !strings.EqualFold(strings.TrimSpace(sum), a.SHA256) {
+		return fmt.Errorf("plugin %s: SHA-256 checksum is %s, want %s", a.Path, a.SHA256, sum)
+	}
+	return nil
+}
+
+// CheckAPIVersion returns an error if the Gerrit-ApiVersion of the plugin was built for another
+// Gerrit release than serverVersion, e.g. "3.9.1-12-gabcdef" as returned by ConfigService.GetVersion.
+// Only the major and minor version are compared. Plugins without an API version are compatible.
+func (a *PluginArchive) CheckAPIVersion(serverVersion string) error {
+	if a.APIVersion == "" {
+		return nil
+	}
+	if got, want := minorVersion(a.APIVersion), minorVersion(serverVersion); got != want {
+		return fmt.Errorf("plugin %s is built for Gerrit %s, but the server runs %s", a.Name, a.APIVersion, serverVersion)
+	}
+	return nil
+}
+
+// readManifest reads the name and versions of a jar plugin from META-INF/MANIFEST.MF.
+func (a *PluginArchive) readManifest() error {
+	r, err := zip.OpenReader(a.Path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name != "META-INF/MANIFEST.MF" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		attrs, err := parseManifest(rc)
+		if err != nil {
+			return fmt.Errorf("manifest: %v", err)
+		}
+		a.Name = attrs["Gerrit-PluginName"]
+		a.Version = attrs["Implementation-Version"]
+		a.APIVersion = attrs["Gerrit-ApiVersion"]
+		if a.Name == "" {
+			return fmt.Errorf("manifest has no Gerrit-PluginName")
+		}
+		return nil
+	}
+	return fmt.Errorf("no META-INF/MANIFEST.MF found")
+}
+
+// parseManifest parses the main section of a jar manifest.
+// Lines starting with a space continue the value of the previous line.
+func parseManifest(r io.Reader) (map[string]string, error) {
+	attrs := make(map[string]string)
+	var last string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			// The main section ends with the first empty line.
+			break
+		}
+		if strings.HasPrefix(line, " ") {
+			if last == "" {
+				return nil, fmt.Errorf("continuation line without attribute: %q", line)
+			}
+			attrs[last] += line[1:]
+			continue
+		}
+		i := strings.Index(line, ": ")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid line: %q", line)
+		}
+		last = line[:i]
+		attrs[last] = line[i+2:]
+	}
+	return attrs, scanner.Err()
+}
+
+// minorVersion returns the major and minor version of a Gerrit version, e.g. "3.9" for "3.9.1-SNAPSHOT".
+func minorVersion(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+}
+
+// fileSHA256 returns the hex encoded SHA-256 checksum of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// UploadPlugin installs the plugin file of archive on the Gerrit server as plugin archive.ID().
+// If a plugin with the same ID already exists it is overwritten.
+//
+// The checksum of the file is verified against archive.SHA256 before the upload,
+// so a file that changed after it was opened is not installed.
+// The file is streamed as request body and its checksum is computed again while it is sent,
+// so an error is also returned if the file changed during the upload.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-plugins.html#install-plugin
+func (s *PluginsService) UploadPlugin(archive *PluginArchive) (*PluginInfo, *Response, error) {
+	sum, err := fileSHA256(archive.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sum != archive.SHA256 {
+		return nil, nil, fmt.Errorf("plugin %s changed after it was opened: SHA-256 checksum is %s, want %s", archive.Path, sum, archive.SHA256)
+	}
+
+	f, err := os.Open(archive.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	u := fmt.Sprintf("plugins/%s", archive.ID())
+	v, resp, err := s.requestWithPluginInfoResponse("PUT", u, RawBody("application/octet-stream", io.TeeReader(f, h)))
+	if err != nil {
+		return nil, resp, err
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != archive.SHA256 {
+		return v, resp, fmt.Errorf("plugin %s changed during upload: SHA-256 checksum is %s, want %s", archive.Path, sum, archive.SHA256)
+	}
+
+	return v, resp, err
+}
+
+// EnsurePlugin makes sure that the version of archive is installed and enabled on the Gerrit server.
+// It is idempotent: the plugin is only uploaded if it is not installed yet or another version is installed,
+// and only enabled if the same version is installed, but disabled.
+//
+// The installed plugin is compared by the Implementation-Version of the manifest only,
+// as Gerrit doesn't expose a checksum of installed plugins.
+// Plugins without a version, which includes all JavaScript plugins, can't be compared,
+// so they are uploaded on every call and the result is PluginReplaced, which makes Gerrit reload them.
+// Callers that need idempotent deployments of such plugins have to track the deployed checksum themselves.
+//
+// Before anything is changed, the Gerrit-ApiVersion of the plugin is checked against the server version.
+//
+// The returned Response is the one of the last request.
+func (s *PluginsService) EnsurePlugin(archive *PluginArchive) (*PluginInfo, EnsurePluginResult, *Response, error) {
+	serverVersion, resp, err := s.client.Config.GetVersion()
+	if err != nil {
+		return nil, "", resp, err
+	}
+	if err := archive.CheckAPIVersion(serverVersion); err != nil {
+		return nil, "", resp, err
+	}
+
+	installed, resp, err := s.GetPluginStatus(archive.ID())
+	if err != nil && (resp == nil || resp.Response == nil || resp.StatusCode != http.StatusNotFound) {
+		return nil, "", resp, err
+	}
+
+	result := PluginUnchanged
+	switch {
+	case installed == nil:
+		result = PluginInstalled
+		installed, resp, err = s.UploadPlugin(archive)
+	case archive.Version == "" || installed.Version != archive.Version:
+		result = PluginReplaced
+		installed, resp, err = s.UploadPlugin(archive)
+	case installed.Disabled:
+		result = PluginEnabled
+		installed, resp, err = s.EnablePlugin(archive.ID())
+	}
+	if err != nil {
+		return nil, "", resp, err
+	}
+
+	return installed, result, resp, nil
+}
@@ -0,0 +1,209 @@
+package gerrit_test
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andygrunwald/go-gerrit"
+)
+
+// writePluginJar writes a plugin jar with the given manifest to a temporary directory.
+func writePluginJar(t *testing.T, manifest string) string {
+	path := filepath.Join(t.TempDir(), "plugin.jar")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("META-INF/MANIFEST.MF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(w, manifest)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testPluginManifest = "Manifest-Version: 1.0\r\n" +
+	"Gerrit-PluginName: delete-pro\r\n ject\r\n" +
+	"Implementation-Version: v3.9.1\r\n" +
+	"Gerrit-ApiVersion: 3.9.1-SNAPSHOT\r\n\r\n" +
+	"Name: com/example/\r\nGerrit-PluginName: ignored\r\n"
+
+func TestOpenPluginArchive(t *testing.T) {
+	path := writePluginJar(t, testPluginManifest)
+
+	archive, err := gerrit.OpenPluginArchive(path)
+	if err != nil {
+		t.Fatalf("OpenPluginArchive returned error: %v", err)
+	}
+	if archive.ID() != "delete-project" || archive.Version != "v3.9.1" || archive.APIVersion != "3.9.1-SNAPSHOT" {
+		t.Errorf("OpenPluginArchive returned %+v", archive)
+	}
+
+	if err := archive.VerifyChecksum(archive.SHA256); err != nil {
+		t.Errorf("VerifyChecksum returned error: %v", err)
+	}
+	if err := archive.VerifyChecksum("0000"); err == nil {
+		t.Error("VerifyChecksum returned no error for a wrong checksum")
+	}
+
+	if err := archive.CheckAPIVersion("3.9.4-12-gabcdef"); err != nil {
+		t.Errorf("CheckAPIVersion returned error: %v", err)
+	}
+	if err := archive.CheckAPIVersion("3.10.0"); err == nil {
+		t.Error("CheckAPIVersion returned no error for another release")
+	}
+
+	if _, err := gerrit.OpenPluginArchive(writePluginJar(t, "Manifest-Version: 1.0\r\n")); err == nil {
+		t.Error("OpenPluginArchive returned no error for a manifest without Gerrit-PluginName")
+	}
+
+	js := filepath.Join(t.TempDir(), "my-plugin.js")
+	ioutil.WriteFile(js, []byte("Gerrit.install(function(self) {});"), 0644)
+	archive, err = gerrit.OpenPluginArchive(js)
+	if err != nil || archive.ID() != "my-plugin.js" || !archive.JavaScript {
+		t.Errorf("OpenPluginArchive returned %+v, %v", archive, err)
+	}
+}
+
+func TestPluginsService_EnsurePlugin(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed string
+		want      gerrit.EnsurePluginResult
+		uploaded  bool
+		enabled   bool
+	}{
+		{"not installed", "", gerrit.PluginInstalled, true, false},
+		{"other version", `{"id":"delete-project","version":"v3.8.0"}`, gerrit.PluginReplaced, true, false},
+		{"disabled", `{"id":"delete-project","version":"v3.9.1","disabled":true}`, gerrit.PluginEnabled, false, true},
+		{"up to date", `{"id":"delete-project","version":"v3.9.1"}`, gerrit.PluginUnchanged, false, false},
+	}
+
+	path := writePluginJar(t, testPluginManifest)
+	jar, _ := ioutil.ReadFile(path)
+	archive, err := gerrit.OpenPluginArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			uploaded, enabled := false, false
+			testMux.HandleFunc("/config/server/version", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `)]}'`+"\n"+`"3.9.4"`)
+			})
+			testMux.HandleFunc("/plugins/delete-project/gerrit~status", func(w http.ResponseWriter, r *http.Request) {
+				if tt.installed == "" {
+					http.NotFound(w, r)
+					return
+				}
+				fmt.Fprint(w, `)]}'`+"\n"+tt.installed)
+			})
+			testMux.HandleFunc("/plugins/delete-project", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "PUT")
+				if ct := r.Header.Get("Content-Type"); ct != "application/octet-stream" {
+					t.Errorf("Content-Type %q, want application/octet-stream", ct)
+				}
+				if body, _ := ioutil.ReadAll(r.Body); string(body) != string(jar) {
+					t.Error("Request body is not the plugin jar")
+				}
+				uploaded = true
+				fmt.Fprint(w, `)]}'`+"\n"+`{"id":"delete-project","version":"v3.9.1"}`)
+			})
+			testMux.HandleFunc("/plugins/delete-project/gerrit~enable", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				enabled = true
+				fmt.Fprint(w, `)]}'`+"\n"+`{"id":"delete-project","version":"v3.9.1"}`)
+			})
+
+			plugin, result, _, err := testClient.Plugins.EnsurePlugin(archive)
+			if err != nil {
+				t.Fatalf("Plugins.EnsurePlugin returned error: %v", err)
+			}
+			if result != tt.want || plugin.Version != "v3.9.1" {
+				t.Errorf("Plugins.EnsurePlugin returned %+v, %q, want %q", plugin, result, tt.want)
+			}
+			if uploaded != tt.uploaded || enabled != tt.enabled {
+				t.Errorf("uploaded = %v, enabled = %v, want %v, %v", uploaded, enabled, tt.uploaded, tt.enabled)
+			}
+		})
+	}
+}
+
+func TestPluginsService_UploadPlugin_Changed(t *testing.T) {
+	setup()
+	defer teardown()
+
+	uploaded := false
+	testMux.HandleFunc("/plugins/delete-project", func(w http.ResponseWriter, r *http.Request) {
+		uploaded = true
+		fmt.Fprint(w, `)]}'`+"\n"+`{"id":"delete-project","version":"v3.9.1"}`)
+	})
+
+	path := writePluginJar(t, testPluginManifest)
+	archive, err := gerrit.OpenPluginArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(path, []byte("not the verified plugin"), 0644)
+
+	if _, _, err := testClient.Plugins.UploadPlugin(archive); err == nil {
+		t.Error("Plugins.UploadPlugin returned no error for a changed plugin file")
+	}
+	if uploaded {
+		t.Error("Plugins.UploadPlugin uploaded a changed plugin file")
+	}
+}
+
+func TestPluginsService_EnsurePlugin_JavaScript(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/config/server/version", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`"3.9.4"`)
+	})
+	testMux.HandleFunc("/plugins/my-plugin.js/gerrit~status", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `)]}'`+"\n"+`{"id":"my-plugin"}`)
+	})
+	uploads := 0
+	testMux.HandleFunc("/plugins/my-plugin.js", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		uploads++
+		fmt.Fprint(w, `)]}'`+"\n"+`{"id":"my-plugin"}`)
+	})
+
+	js := filepath.Join(t.TempDir(), "my-plugin.js")
+	ioutil.WriteFile(js, []byte("Gerrit.install(function(self) {});"), 0644)
+	archive, err := gerrit.OpenPluginArchive(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Plugins without a version can't be compared, so they are uploaded every time.
+	for i := 0; i < 2; i++ {
+		_, result, _, err := testClient.Plugins.EnsurePlugin(archive)
+		if err != nil {
+			t.Fatalf("Plugins.EnsurePlugin returned error: %v", err)
+		}
+		if result != gerrit.PluginReplaced {
+			t.Errorf("Plugins.EnsurePlugin returned %q, want %q", result, gerrit.PluginReplaced)
+		}
+	}
+	if uploads != 2 {
+		t.Errorf("Plugin was uploaded %d times, want 2", uploads)
+	}
+}
@@ -26,20 +26,23 @@ type ProjectInfo struct {
 }
 
 // ProjectInput entity contains information for the creation of a new project.
+//
+// Boolean options are pointers, so unset options are not sent and the server defaults apply.
+// Use Bool to set them.
 type ProjectInput struct {
 	Name                             string                       `json:"name,omitempty"`
 	Parent                           string                       `json:"parent,omitempty"`
 	Description                      string                       `json:"description,omitempty"`
-	PermissionsOnly                  bool                         `json:"permissions_only"`
-	CreateEmptyCommit                bool                         `json:"create_empty_commit"`
+	PermissionsOnly                  *bool                        `json:"permissions_only,omitempty"`
+	CreateEmptyCommit                *bool                        `json:"create_empty_commit,omitempty"`
 	SubmitType                       string                       `json:"submit_type,omitempty"`
 	Branches                         []string                     `json:"branches,omitempty"`
 	Owners                           []string                     `json:"owners,omitempty"`
-	UseContributorAgreements         string                       `json:"use_contributor_agreements"`
-	UseSignedOffBy                   string                       `json:"use_signed_off_by"`
-	CreateNewChangeForAllNotInTarget string                       `json:"create_new_change_for_all_not_in_target"`
-	UseContentMerge                  string                       `json:"use_content_merge"`
-	RequireChangeID                  string                       `json:"require_change_id"`
+	UseContributorAgreements         InheritableBoolean           `json:"use_contributor_agreements,omitempty"`
+	UseSignedOffBy                   InheritableBoolean           `json:"use_signed_off_by,omitempty"`
+	CreateNewChangeForAllNotInTarget InheritableBoolean           `json:"create_new_change_for_all_not_in_target,omitempty"`
+	UseContentMerge                  InheritableBoolean           `json:"use_content_merge,omitempty"`
+	RequireChangeID                  InheritableBoolean           `json:"require_change_id,omitempty"`
 	MaxObjectSizeLimit               string                       `json:"max_object_size_limit,omitempty"`
 	PluginConfigValues               map[string]map[string]string `json:"plugin_config_values,omitempty"`
 }
@@ -102,9 +105,9 @@ type RepositoryStatisticsInfo struct {
 
 // InheritedBooleanInfo entity represents a boolean value that can also be inherited.
 type InheritedBooleanInfo struct {
-	Value           bool   `json:"value"`
-	ConfiguredValue string `json:"configured_value"`
-	InheritedValue  bool   `json:"inherited_value,omitempty"`
+	Value           bool               `json:"value"`
+	ConfiguredValue InheritableBoolean `json:"configured_value"`
+	InheritedValue  bool               `json:"inherited_value,omitempty"`
 }
 
 // MaxObjectSizeLimitInfo entity contains information about the max object size limit of a project.
@@ -153,12 +156,13 @@ type ConfigInfo struct {
 // ConfigInput entity describes a new project configuration.
 type ConfigInput struct {
 	Description                      string                       `json:"description,omitempty"`
-	UseContributorAgreements         string                       `json:"use_contributor_agreements,omitempty"`
-	UseContentMerge                  string                       `json:"use_content_merge,omitempty"`
-	UseSignedOffBy                   string                       `json:"use_signed_off_by,omitempty"`
-	CreateNewChangeForAllNotInTarget string                       `json:"create_new_change_for_all_not_in_target,omitempty"`
-	RequireChangeID                  string                       `json:"require_change_id,omitempty"`
-	MaxObjectSizeLimit               MaxObjectSizeLimitInfo       `json:"max_object_size_limit,omitempty"`
+	UseContributorAgreements         InheritableBoolean           `json:"use_contributor_agreements,omitempty"`
+	UseContentMerge                  InheritableBoolean           `json:"use_content_merge,omitempty"`
+	UseSignedOffBy                   InheritableBoolean           `json:"use_signed_off_by,omitempty"`
+	CreateNewChangeForAllNotInTarget InheritableBoolean           `json:"create_new_change_for_all_not_in_target,omitempty"`
+	RequireChangeID                  InheritableBoolean           `json:"require_change_id,omitempty"`
+	EnableSignedPush                 InheritableBoolean           `json:"enable_signed_push,omitempty"`
+	MaxObjectSizeLimit               string                       `json:"max_object_size_limit,omitempty"`
 	SubmitType                       string                       `json:"submit_type,omitempty"`
 	State                            string                       `json:"state,omitempty"`
 	PluginConfigValues               map[string]map[string]string `json:"plugin_config_values,omitempty"`
@@ -239,6 +243,10 @@ func (s *ProjectsService) GetProject(projectName string) (*ProjectInfo, *Respons
 //
 // Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#create-project
 func (s *ProjectsService) CreateProject(projectName string, input *ProjectInput) (*ProjectInfo, *Response, error) {
+	if err := input.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	u := fmt.Sprintf("projects/%s/", url.QueryEscape(projectName))
 
 	v := new(ProjectInfo)
@@ -298,7 +306,7 @@ func (s *ProjectsService) GetRepositoryStatistics(projectName string) (*Reposito
 //
 // Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-config
 func (s *ProjectsService) GetConfig(projectName string) (*ConfigInfo, *Response, error) {
-	u := fmt.Sprintf("projects/%s/config'", url.QueryEscape(projectName))
+	u := fmt.Sprintf("projects/%s/config", url.QueryEscape(projectName))
 
 	req, err := s.client.NewRequest("GET", u, nil)
 	if err != nil {
@@ -382,7 +390,11 @@ func (s *ProjectsService) BanCommit(projectName string, input *BanInput) (*BanRe
 //
 // Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#set-config
 func (s *ProjectsService) SetConfig(projectName string, input *ConfigInput) (*ConfigInfo, *Response, error) {
-	u := fmt.Sprintf("projects/%s/config'", url.QueryEscape(projectName))
+	if err := input.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	u := fmt.Sprintf("projects/%s/config", url.QueryEscape(projectName))
 
 	req, err := s.client.NewRequest("PUT", u, input)
 	if err != nil {
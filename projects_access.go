@@ -0,0 +1,195 @@
+package gerrit
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// ProjectAccessInput describes changes that should be applied to a project access config.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#project-access-input
+type ProjectAccessInput struct {
+	// A list of deductions to be applied to the project access as AccessSectionInput entities.
+	// A section without permissions removes the whole section,
+	// a permission without rules removes the whole permission.
+	Remove map[string]AccessSectionInput `json:"remove,omitempty"`
+	// A list of additions to be applied to the project access as AccessSectionInput entities.
+	Add map[string]AccessSectionInput `json:"add,omitempty"`
+	// A commit message for this change.
+	Message string `json:"message,omitempty"`
+	// A new parent for the project to inherit from. Changing the parent project requires administrative privileges.
+	Parent string `json:"parent,omitempty"`
+}
+
+// AccessSectionInput is an access section of a ProjectAccessInput.
+// Unlike AccessSectionInfo, unset fields are not sent, so they don't overwrite the values on the server.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-access.html#access-section-info
+type AccessSectionInput struct {
+	Permissions map[string]PermissionInput `json:"permissions,omitempty"`
+}
+
+// PermissionInput is a permission of an AccessSectionInput.
+// If Exclusive is nil, the exclusive flag of an existing permission is left as it is.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-access.html#permission-info
+type PermissionInput struct {
+	Label     string                         `json:"label,omitempty"`
+	Exclusive *bool                          `json:"exclusive,omitempty"`
+	Rules     map[string]PermissionRuleInput `json:"rules,omitempty"`
+}
+
+// PermissionRuleInput is a permission rule of a PermissionInput, keyed by group UUID.
+// Min and Max are only meaningful for label permissions and are not sent if nil.
+// In the removals of a ProjectAccessInput only the group UUID matters and the rule can be empty.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-access.html#permission-rule-info
+type PermissionRuleInput struct {
+	// ALLOW, DENY, BLOCK, INTERACTIVE or BATCH.
+	Action string `json:"action,omitempty"`
+	Force  *bool  `json:"force,omitempty"`
+	Min    *int   `json:"min,omitempty"`
+	Max    *int   `json:"max,omitempty"`
+}
+
+// AccessCheckInfo entity is the result of an access check.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#access-check-info
+type AccessCheckInfo struct {
+	// The HTTP status code for the access. 200 means success and 403 means denied.
+	Status int `json:"status"`
+	// A clarifying message if status is not 200.
+	Message string `json:"message,omitempty"`
+}
+
+// CheckAccessOptions specifies the parameters to ProjectsService.CheckAccess.
+type CheckAccessOptions struct {
+	// The account for which to check access. Mandatory.
+	Account string `url:"account,omitempty"`
+	// The ref permission for which to check access.
+	// If not specified, read access to at least branch is checked.
+	Permission string `url:"perm,omitempty"`
+	// The branch for which to check access. This must be given if perm is specified.
+	Ref string `url:"ref,omitempty"`
+}
+
+// GetAccess lists the access rights for a single project.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-access
+func (s *ProjectsService) GetAccess(projectName string) (*ProjectAccessInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/access", url.QueryEscape(projectName))
+
+	v := new(ProjectAccessInfo)
+	resp, err := s.client.Call("GET", u, nil, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// ListAccessOwners lists the groups that own a project or parts of it, keyed by ref pattern.
+// Owners are the groups with an ALLOW rule of the owner permission in the local access rights of the project.
+// Owners inherited from parent projects and administrators are not included.
+// The groups are sorted by name and their ID is the plain group UUID.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/access-control.html#category_owner
+func (s *ProjectsService) ListAccessOwners(projectName string) (map[string][]GroupInfo, *Response, error) {
+	access, resp, err := s.GetAccess(projectName)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	owners := make(map[string][]GroupInfo)
+	for ref, section := range access.Local {
+		for uuid, rule := range section.Permissions["owner"].Rules {
+			if rule.Action != "" && rule.Action != "ALLOW" {
+				continue
+			}
+			group := access.Groups[uuid]
+			group.ID = uuid
+			owners[ref] = append(owners[ref], group)
+		}
+	}
+	for _, groups := range owners {
+		sort.Slice(groups, func(i, j int) bool {
+			if groups[i].Name != groups[j].Name {
+				return groups[i].Name < groups[j].Name
+			}
+			return groups[i].ID < groups[j].ID
+		})
+	}
+
+	return owners, resp, nil
+}
+
+// SetAccess sets access rights for the project using the diff schema provided by ProjectAccessInput.
+// Deductions are used to remove access sections, permissions or permission rules.
+// The backend will remove the entity with the finest granularity in the request,
+// meaning that if an access section without permissions is posted, the access section will be removed;
+// if an access section with a permission but no permission rules is posted, the permission will be removed;
+// if an access section with a permission and a permission rule is posted, the permission rule will be removed.
+//
+// Additionally, access sections and permissions will be cleaned up after applying the deductions
+// by removing items that have no child elements.
+//
+// After removals have been applied, additions will be applied.
+//
+// As result a ProjectAccessInfo entity is returned that describes the access rights of the project.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#set-access
+func (s *ProjectsService) SetAccess(projectName string, input *ProjectAccessInput) (*ProjectAccessInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/access", url.QueryEscape(projectName))
+
+	v := new(ProjectAccessInfo)
+	resp, err := s.client.Call("POST", u, input, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// CreateAccessChange sets access rights for the project using the diff schema provided by ProjectAccessInput.
+// Unlike SetAccess the access rights are not applied directly.
+// Instead a change for review is created on refs/meta/config.
+//
+// As result a ChangeInfo entity describing the created change is returned.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#create-access-change
+func (s *ProjectsService) CreateAccessChange(projectName string, input *ProjectAccessInput) (*ChangeInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/access:review", url.QueryEscape(projectName))
+
+	v := new(ChangeInfo)
+	resp, err := s.client.Call("PUT", u, input, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// CheckAccess runs access checks for other users.
+// This requires the View Access global capability.
+//
+// As result an AccessCheckInfo entity is returned.
+// A denied access is not an error, it is reported by the Status of the AccessCheckInfo.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#check-access
+func (s *ProjectsService) CheckAccess(projectName string, opt *CheckAccessOptions) (*AccessCheckInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/check.access", url.QueryEscape(projectName))
+
+	u, err := addOptions(u, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(AccessCheckInfo)
+	resp, err := s.client.Call("GET", u, nil, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
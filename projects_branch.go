@@ -3,6 +3,8 @@ package gerrit
 import (
 	"fmt"
 	"net/url"
+	"strconv"
+	"time"
 )
 
 // BranchInfo entity contains information about a branch.
@@ -14,16 +16,50 @@ type BranchInfo struct {
 }
 
 // BranchInput entity contains information for the creation of a new branch.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#branch-input
 type BranchInput struct {
 	Ref      string `json:"ref,omitempty"`
 	Revision string `json:"revision,omitempty"`
+	// ValidationOptions are passed as push options to the commit validators of the server (e.g. of plugins).
+	ValidationOptions map[string]string `json:"validation_options,omitempty"`
 }
 
 // DeleteBranchesInput entity contains information about branches that should be deleted.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#delete-branches-input
 type DeleteBranchesInput struct {
-	Branches []string `json:"DeleteBranchesInput"`
+	Branches []string `json:"branches"`
+}
+
+// ReflogOptions specifies the parameters for ProjectsService.GetReflogWithOptions.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-reflog
+type ReflogOptions struct {
+	// Limit the number of reflog entries to be returned.
+	Limit int `url:"n,omitempty"`
+
+	// From and To limit the results to the entries in the time range.
+	// The server interprets the timestamps in its own time zone, with minute precision.
+	From time.Time `url:"from,omitempty" layout:"20060102_1504"`
+	To   time.Time `url:"to,omitempty" layout:"20060102_1504"`
 }
 
+// BranchMergeableOptions specifies the parameters for ProjectsService.GetMergeableInformation.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-mergeable-info
+type BranchMergeableOptions struct {
+	// Source is required and can be anything that resolves to a commit, e.g. a branch name or a SHA-1.
+	Source string `url:"source"`
+
+	// Strategy is the merge strategy, e.g. "recursive", "resolve", "simple-two-way-in-core", "ours" or "theirs".
+	// If not set, the strategy configured on the server is used.
+	Strategy string `url:"strategy,omitempty"`
+}
+
+// defaultBranchPageSize is the page size of ListAllBranches if no limit is set.
+const defaultBranchPageSize = 100
+
 // BranchOptions specifies the parameters to the branch API endpoints.
 //
 // Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#branch-options
@@ -88,13 +124,72 @@ func (s *ProjectsService) GetBranch(projectName, branchID string) (*BranchInfo,
 	return v, resp, err
 }
 
+// ListAllBranches lists all branches of a project by paging through ListBranches
+// until a page comes back empty. A short page doesn't end the listing,
+// as the server may return fewer branches than requested, e.g. if some are not visible to the caller.
+// opt.Limit is used as page size (100 if not set) and opt.Skip as offset of the first page.
+// The filters of opt apply to every page.
+// If a page starts with the same branch as the previous page, the server ignored the skip parameter
+// and an error is returned instead of requesting the same page again and again.
+//
+// The returned Response is the one of the last request.
+func (s *ProjectsService) ListAllBranches(projectName string, opt *BranchOptions) (*[]BranchInfo, *Response, error) {
+	pageOpt := BranchOptions{}
+	if opt != nil {
+		pageOpt = *opt
+	}
+	if pageOpt.Limit <= 0 {
+		pageOpt.Limit = defaultBranchPageSize
+	}
+	skip := 0
+	if pageOpt.Skip != "" {
+		var err error
+		if skip, err = strconv.Atoi(pageOpt.Skip); err != nil {
+			return nil, nil, fmt.Errorf("invalid skip %q: %v", pageOpt.Skip, err)
+		}
+	}
+
+	branches := []BranchInfo{}
+	previous := ""
+	for {
+		pageOpt.Skip = strconv.Itoa(skip)
+		page, resp, err := s.ListBranches(projectName, &pageOpt)
+		if err != nil {
+			return nil, resp, err
+		}
+
+		if len(*page) == 0 {
+			return &branches, resp, nil
+		}
+		if (*page)[0].Ref == previous {
+			return nil, resp, fmt.Errorf("page at skip %d repeats the previous page starting with %s: the server ignored the skip parameter", skip, previous)
+		}
+		previous = (*page)[0].Ref
+		branches = append(branches, *page...)
+		skip += len(*page)
+	}
+}
+
 // GetReflog gets the reflog of a certain branch.
 // The caller must be project owner.
 //
 // Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-reflog
 func (s *ProjectsService) GetReflog(projectName, branchID string) (*[]ReflogEntryInfo, *Response, error) {
+	return s.GetReflogWithOptions(projectName, branchID, nil)
+}
+
+// GetReflogWithOptions gets the reflog of a certain branch, like GetReflog.
+// opt can limit the number of entries and the time range and may be nil.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-reflog
+func (s *ProjectsService) GetReflogWithOptions(projectName, branchID string, opt *ReflogOptions) (*[]ReflogEntryInfo, *Response, error) {
 	u := fmt.Sprintf("projects/%s/branches/%s/reflog", url.QueryEscape(projectName), branchID)
 
+	u, err := addOptions(u, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	req, err := s.client.NewRequest("GET", u, nil)
 	if err != nil {
 		return nil, nil, err
@@ -144,7 +239,50 @@ func (s *ProjectsService) DeleteBranch(projectName, branchID string) (*Response,
 // Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#delete-branches
 func (s *ProjectsService) DeleteBranches(projectName string, input *DeleteBranchesInput) (*Response, error) {
 	u := fmt.Sprintf("projects/%s/branches:delete", url.QueryEscape(projectName))
-	return s.client.DeleteRequest(u, input)
+	return s.client.Call("POST", u, input, nil)
+}
+
+// SafeDeleteBranch deletes a branch, but only if it has no open changes.
+// The open changes are looked up via ChangesService.QueryChanges before the branch is deleted,
+// so a change uploaded in between is not detected.
+func (s *ProjectsService) SafeDeleteBranch(projectName, branchID string) (*Response, error) {
+	opt := &QueryChangeOptions{}
+	opt.Query = []string{QueryAnd(QueryOperator("project", projectName), QueryOperator("branch", branchID), QueryIsOpen).String()}
+	opt.Limit = 1
+	changes, resp, err := s.client.Changes.QueryChanges(opt)
+	if err != nil {
+		return resp, err
+	}
+	if len(*changes) > 0 {
+		return resp, fmt.Errorf("branch %s of project %s has open changes, e.g. change %d", branchID, projectName, (*changes)[0].Number)
+	}
+
+	return s.DeleteBranch(projectName, branchID)
+}
+
+// GetMergeableInformation gets whether the source is mergeable into the branch.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-mergeable-info
+func (s *ProjectsService) GetMergeableInformation(projectName, branchID string, opt *BranchMergeableOptions) (*MergeableInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/branches/%s/mergeable", url.QueryEscape(projectName), branchID)
+
+	u, err := addOptions(u, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(MergeableInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
 }
 
 // GetBranchContent gets the content of a file from the HEAD revision of a certain branch.
@@ -5,13 +5,35 @@ import (
 	"net/url"
 )
 
+// CommitFilesOptions specifies the parameters for ProjectsService.ListCommitFiles.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#list-files
+type CommitFilesOptions struct {
+	// Parent is the 1-based index of the parent to diff against, if the commit is a merge commit.
+	// If not set, the first parent is used.
+	Parent int `url:"parent,omitempty"`
+}
+
 // GetCommit retrieves a commit of a project.
 // The commit must be visible to the caller.
 //
 // Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-commit
 func (s *ProjectsService) GetCommit(projectName, commitID string) (*CommitInfo, *Response, error) {
+	return s.GetCommitWithOptions(projectName, commitID, nil)
+}
+
+// GetCommitWithOptions retrieves a commit of a project, like GetCommit.
+// With opt.Weblinks set, the web links of the commit are included.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-commit
+func (s *ProjectsService) GetCommitWithOptions(projectName, commitID string, opt *CommitOptions) (*CommitInfo, *Response, error) {
 	u := fmt.Sprintf("projects/%s/commits/%s", url.QueryEscape(projectName), commitID)
 
+	u, err := addOptions(u, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	req, err := s.client.NewRequest("GET", u, nil)
 	if err != nil {
 		return nil, nil, err
@@ -26,6 +48,75 @@ func (s *ProjectsService) GetCommit(projectName, commitID string) (*CommitInfo,
 	return v, resp, err
 }
 
+// GetCommitIncludedIn retrieves the branches and tags in which a commit is included.
+// Branches that are not visible to the calling user are not included in the result.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-included-in
+func (s *ProjectsService) GetCommitIncludedIn(projectName, commitID string) (*IncludedInInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/commits/%s/in", url.QueryEscape(projectName), commitID)
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(IncludedInInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// ListCommitFiles lists the files that were modified, added or deleted in a commit.
+// The result is a map with the path of the file as key.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#list-files
+func (s *ProjectsService) ListCommitFiles(projectName, commitID string, opt *CommitFilesOptions) (*map[string]FileInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/commits/%s/files/", url.QueryEscape(projectName), commitID)
+
+	u, err := addOptions(u, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(map[string]FileInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// CherryPickCommit cherry-picks a commit of a project to a destination branch.
+// The destination branch must be provided in the request body inside a CherryPickInput entity.
+// If the commit message is not set, the commit message of the source commit is used.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#cherry-pick-commit
+func (s *ProjectsService) CherryPickCommit(projectName, commitID string, input *CherryPickInput) (*ChangeInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/commits/%s/cherrypick", url.QueryEscape(projectName), commitID)
+
+	req, err := s.client.NewRequest("POST", u, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(ChangeInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
 // GetCommitContent gets the content of a file from the HEAD revision of a certain branch.
 // The content is returned as base64 encoded string.
 //
@@ -34,3 +125,12 @@ func (s *ProjectsService) GetCommitContent(projectName, branchID, fileID string)
 	u := fmt.Sprintf("projects/%s/branches/%s/files/%s/content", url.QueryEscape(projectName), branchID, fileID)
 	return getStringResponseWithoutOptions(s.client, u)
 }
+
+// GetCommitFileContent gets the content of a file from a certain commit.
+// The content is returned as base64 encoded string.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-content-from-commit
+func (s *ProjectsService) GetCommitFileContent(projectName, commitID, fileID string) (string, *Response, error) {
+	u := fmt.Sprintf("projects/%s/commits/%s/files/%s/content", url.QueryEscape(projectName), commitID, fileID)
+	return getStringResponseWithoutOptions(s.client, u)
+}
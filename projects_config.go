@@ -0,0 +1,174 @@
+package gerrit
+
+import (
+	"fmt"
+)
+
+// InheritableBoolean is a project setting that is either set explicitly or inherited from the parent project.
+//
+// The zero value means "not set". Input entities don't send unset values,
+// so the setting of the server is left untouched.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#inherited-boolean-info
+type InheritableBoolean string
+
+// Values of InheritableBoolean.
+const (
+	InheritableBooleanTrue    InheritableBoolean = "TRUE"
+	InheritableBooleanFalse   InheritableBoolean = "FALSE"
+	InheritableBooleanInherit InheritableBoolean = "INHERIT"
+)
+
+// Validate returns an error if b is not one of TRUE, FALSE, INHERIT or unset.
+func (b InheritableBoolean) Validate() error {
+	switch b {
+	case "", InheritableBooleanTrue, InheritableBooleanFalse, InheritableBooleanInherit:
+		return nil
+	}
+	return fmt.Errorf("invalid inheritable boolean %q: must be TRUE, FALSE or INHERIT", string(b))
+}
+
+// Resolve returns the effective value of b, with parent being the effective value of the parent project.
+func (b InheritableBoolean) Resolve(parent bool) bool {
+	switch b {
+	case InheritableBooleanTrue:
+		return true
+	case InheritableBooleanFalse:
+		return false
+	}
+	return parent
+}
+
+// validateInheritableBooleans validates the named values.
+func validateInheritableBooleans(values map[string]InheritableBoolean) error {
+	for name, value := range values {
+		if err := value.Validate(); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the input for values the server would reject.
+// It is called by ProjectsService.CreateProject before the request is sent.
+// A nil input is valid.
+func (input *ProjectInput) Validate() error {
+	if input == nil {
+		return nil
+	}
+	return validateInheritableBooleans(map[string]InheritableBoolean{
+		"use_contributor_agreements":              input.UseContributorAgreements,
+		"use_signed_off_by":                       input.UseSignedOffBy,
+		"create_new_change_for_all_not_in_target": input.CreateNewChangeForAllNotInTarget,
+		"use_content_merge":                       input.UseContentMerge,
+		"require_change_id":                       input.RequireChangeID,
+	})
+}
+
+// Validate checks the input for values the server would reject.
+// It is called by ProjectsService.SetConfig before the request is sent.
+// A nil input is valid.
+func (input *ConfigInput) Validate() error {
+	if input == nil {
+		return nil
+	}
+	return validateInheritableBooleans(map[string]InheritableBoolean{
+		"use_contributor_agreements":              input.UseContributorAgreements,
+		"use_content_merge":                       input.UseContentMerge,
+		"use_signed_off_by":                       input.UseSignedOffBy,
+		"create_new_change_for_all_not_in_target": input.CreateNewChangeForAllNotInTarget,
+		"require_change_id":                       input.RequireChangeID,
+		"enable_signed_push":                      input.EnableSignedPush,
+	})
+}
+
+// EffectiveBoolean is the resolved value of an inheritable project setting.
+type EffectiveBoolean struct {
+	Value bool
+	// Project is the project in which the value is configured.
+	// It is empty if no project in the hierarchy configures the setting and the default (false) applies.
+	Project string
+}
+
+// EffectiveConfig contains the inheritable boolean settings of a project,
+// resolved along the project hierarchy.
+type EffectiveConfig struct {
+	Project string
+	// Parents lists the ancestors of the project, starting with the direct parent and ending with the root project.
+	Parents []string
+
+	UseContributorAgreements         EffectiveBoolean
+	UseContentMerge                  EffectiveBoolean
+	UseSignedOffBy                   EffectiveBoolean
+	CreateNewChangeForAllNotInTarget EffectiveBoolean
+	RequireChangeID                  EffectiveBoolean
+	EnableSignedPush                 EffectiveBoolean
+}
+
+// maxProjectDepth limits the walk up the project hierarchy, in case the server reports a cycle.
+const maxProjectDepth = 64
+
+// GetEffectiveConfig resolves the inheritable boolean settings of a project.
+// It walks the project hierarchy via GetProjectParent up to the root project (usually All-Projects)
+// and uses the value of the nearest project that sets a setting to TRUE or FALSE.
+// Besides the value, the project that configures it is reported.
+//
+// This needs two requests per project in the hierarchy.
+// The returned Response is the one of the last request.
+func (s *ProjectsService) GetEffectiveConfig(projectName string) (*EffectiveConfig, *Response, error) {
+	effective := &EffectiveConfig{Project: projectName}
+
+	var configs []*ConfigInfo
+	var projects []string
+	var resp *Response
+	seen := map[string]bool{}
+	for name := projectName; name != ""; {
+		if seen[name] || len(projects) >= maxProjectDepth {
+			return nil, resp, fmt.Errorf("project hierarchy of %s contains a cycle at %s", projectName, name)
+		}
+		seen[name] = true
+
+		config, r, err := s.GetConfig(name)
+		resp = r
+		if err != nil {
+			return nil, resp, err
+		}
+		configs = append(configs, config)
+		projects = append(projects, name)
+
+		parent, r, err := s.GetProjectParent(name)
+		resp = r
+		if err != nil {
+			return nil, resp, err
+		}
+		if parent != "" {
+			effective.Parents = append(effective.Parents, parent)
+		}
+		name = parent
+	}
+
+	settings := []struct {
+		target *EffectiveBoolean
+		get    func(*ConfigInfo) InheritedBooleanInfo
+	}{
+		{&effective.UseContributorAgreements, func(c *ConfigInfo) InheritedBooleanInfo { return c.UseContributorAgreements }},
+		{&effective.UseContentMerge, func(c *ConfigInfo) InheritedBooleanInfo { return c.UseContentMerge }},
+		{&effective.UseSignedOffBy, func(c *ConfigInfo) InheritedBooleanInfo { return c.UseSignedOffBy }},
+		{&effective.CreateNewChangeForAllNotInTarget, func(c *ConfigInfo) InheritedBooleanInfo { return c.CreateNewChangeForAllNotInTarget }},
+		{&effective.RequireChangeID, func(c *ConfigInfo) InheritedBooleanInfo { return c.RequireChangeID }},
+		{&effective.EnableSignedPush, func(c *ConfigInfo) InheritedBooleanInfo { return c.EnableSignedPush }},
+	}
+	for _, setting := range settings {
+		// Resolve from the root down, so the nearest configured value wins.
+		value := EffectiveBoolean{}
+		for i := len(configs) - 1; i >= 0; i-- {
+			configured := setting.get(configs[i]).ConfiguredValue
+			if configured == InheritableBooleanTrue || configured == InheritableBooleanFalse {
+				value = EffectiveBoolean{Value: configured.Resolve(value.Value), Project: projects[i]}
+			}
+		}
+		*setting.target = value
+	}
+
+	return effective, resp, nil
+}
@@ -1,8 +1,11 @@
 package gerrit
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"net/url"
+	"strings"
 )
 
 // DashboardSectionInfo entity contains information about a section in a dashboard.
@@ -95,6 +98,20 @@ func (s *ProjectsService) SetDashboard(projectName, dashboardID string, input *D
 	return v, resp, err
 }
 
+// SetDefaultDashboard makes a project dashboard the default dashboard of a project via SetDashboard.
+// dashboardID is the ID of the dashboard, e.g. "main:review" for the file review in refs/meta/dashboards/main,
+// which has to be pushed before (see DashboardBuilder.WriteConfig).
+// message is used as commit message and may be empty.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#set-dashboard
+func (s *ProjectsService) SetDefaultDashboard(projectName, dashboardID, message string) (*DashboardInfo, *Response, error) {
+	input := &DashboardInput{
+		ID:            dashboardID,
+		CommitMessage: message,
+	}
+	return s.SetDashboard(projectName, "default", input)
+}
+
 // DeleteDashboard deletes a project dashboard.
 // Currently only supported for the default dashboard.
 //
@@ -106,3 +123,163 @@ func (s *ProjectsService) DeleteDashboard(projectName, dashboardID string, input
 	u := fmt.Sprintf("projects/%s/dashboards/%s", url.QueryEscape(projectName), url.QueryEscape(dashboardID))
 	return s.client.DeleteRequest(u, input)
 }
+
+// DashboardBuilder composes a dashboard from titled sections of change queries.
+// Create it with ProjectsService.NewDashboardBuilder.
+//
+// A dashboard can be shared as ad-hoc dashboard URL (see URL) or stored as project dashboard.
+// Project dashboards are files in a refs/meta/dashboards/* branch of the project (see WriteConfig),
+// which can be made the default dashboard with ProjectsService.SetDefaultDashboard.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/user-dashboards.html
+type DashboardBuilder struct {
+	client *Client
+
+	Title       string
+	Description string
+	// Foreach is a query that is combined with the query of each section.
+	// In project dashboards, ${project} and ${branch} are replaced by the project and branch the dashboard is shown for.
+	Foreach ChangeQuery
+
+	sections []DashboardSectionInfo
+}
+
+// NewDashboardBuilder returns a DashboardBuilder for a dashboard with the given title.
+func (s *ProjectsService) NewDashboardBuilder(title string) *DashboardBuilder {
+	return &DashboardBuilder{client: s.client, Title: title}
+}
+
+// Section appends a section with the given name and query to the dashboard.
+// It returns b, so calls can be chained.
+func (b *DashboardBuilder) Section(name string, query ChangeQuery) *DashboardBuilder {
+	b.sections = append(b.sections, DashboardSectionInfo{Name: name, Query: query.String()})
+	return b
+}
+
+// Sections returns the sections of the dashboard in the order they were added.
+func (b *DashboardBuilder) Sections() []DashboardSectionInfo {
+	return append([]DashboardSectionInfo(nil), b.sections...)
+}
+
+// Validate checks the dashboard and runs the query of each section, combined with Foreach, with a limit of 1
+// via ChangesService.QueryChanges, so queries the server can't parse are reported.
+// ${project} is replaced by projectName and ${branch} by branch.
+// If branch is empty and a query uses ${branch}, the branch HEAD of the project points to is used.
+//
+// The returned Response is the one of the last request.
+func (b *DashboardBuilder) Validate(projectName, branch string) (*Response, error) {
+	if b.Title == "" {
+		return nil, fmt.Errorf("dashboard has no title")
+	}
+	if len(b.sections) == 0 {
+		return nil, fmt.Errorf("dashboard %q has no sections", b.Title)
+	}
+
+	var resp *Response
+	if branch == "" && b.usesBranch() {
+		head, r, err := b.client.Projects.GetHEAD(projectName)
+		resp = r
+		if err != nil {
+			return resp, fmt.Errorf("resolving HEAD of project %q: %v", projectName, err)
+		}
+		branch = strings.TrimPrefix(head, "refs/heads/")
+	}
+
+	replacer := strings.NewReplacer("${project}", projectName, "${branch}", branch)
+	seen := map[string]bool{}
+	for _, section := range b.sections {
+		if section.Name == "" || section.Query == "" {
+			return resp, fmt.Errorf("dashboard %q has a section without name or query", b.Title)
+		}
+		if seen[section.Name] {
+			return resp, fmt.Errorf("dashboard %q has more than one section %q", b.Title, section.Name)
+		}
+		seen[section.Name] = true
+
+		query := QueryAnd(b.Foreach, ChangeQuery(section.Query))
+		opt := &QueryChangeOptions{}
+		opt.Query = []string{replacer.Replace(query.String())}
+		opt.Limit = 1
+		_, r, err := b.client.Changes.QueryChanges(opt)
+		resp = r
+		if err != nil {
+			return resp, fmt.Errorf("section %q: invalid query %q: %v", section.Name, opt.Query[0], err)
+		}
+	}
+	return resp, nil
+}
+
+// usesBranch reports whether Foreach or the query of a section contains ${branch}.
+func (b *DashboardBuilder) usesBranch() bool {
+	if strings.Contains(b.Foreach.String(), "${branch}") {
+		return true
+	}
+	for _, section := range b.sections {
+		if strings.Contains(section.Query, "${branch}") {
+			return true
+		}
+	}
+	return false
+}
+
+// URL returns the URL of the ad-hoc dashboard on the Gerrit instance of the client.
+// Ad-hoc dashboards are not stored on the server, so the URL can be shared without any setup.
+func (b *DashboardBuilder) URL() string {
+	params := []string{"title=" + url.QueryEscape(b.Title)}
+	if b.Foreach != "" {
+		params = append(params, "foreach="+url.QueryEscape(b.Foreach.String()))
+	}
+	// The order of the parameters is the order of the sections, so url.Values can't be used.
+	for _, section := range b.sections {
+		params = append(params, url.QueryEscape(section.Name)+"="+url.QueryEscape(section.Query))
+	}
+
+	base := b.client.baseURL.String()
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	return base + "dashboard/?" + strings.Join(params, "&")
+}
+
+// WriteConfig writes the dashboard as project dashboard file to w.
+// The file has to be pushed to a refs/meta/dashboards/* branch of the project,
+// e.g. as refs/meta/dashboards/main:review.
+// Gerrit has no REST endpoint to create project dashboard files.
+// Nothing is written if a section name contains a line break, which git config files can't represent.
+func (b *DashboardBuilder) WriteConfig(w io.Writer) error {
+	for _, section := range b.sections {
+		if strings.ContainsAny(section.Name, "\n\x00") {
+			return fmt.Errorf("section name %q can't be written to a git config file", section.Name)
+		}
+	}
+
+	buf := bufio.NewWriter(w)
+	fmt.Fprintln(buf, "[dashboard]")
+	fmt.Fprintf(buf, "\ttitle = %s\n", quoteConfigValue(b.Title))
+	if b.Description != "" {
+		fmt.Fprintf(buf, "\tdescription = %s\n", quoteConfigValue(b.Description))
+	}
+	if b.Foreach != "" {
+		fmt.Fprintf(buf, "\tforeach = %s\n", quoteConfigValue(b.Foreach.String()))
+	}
+	for _, section := range b.sections {
+		fmt.Fprintf(buf, "[section %s]\n", quoteConfigSubsection(section.Name))
+		fmt.Fprintf(buf, "\tquery = %s\n", quoteConfigValue(section.Query))
+	}
+	return buf.Flush()
+}
+
+// quoteConfigSubsection quotes the name of a subsection of a git config file.
+// Git only understands escaped quotes and backslashes in subsection names, so nothing else is escaped.
+func quoteConfigSubsection(name string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(name) + "\""
+}
+
+// quoteConfigValue quotes a value of a git config file if needed.
+func quoteConfigValue(value string) string {
+	if !strings.ContainsAny(value, "\"\\#;") && strings.TrimSpace(value) == value {
+		return value
+	}
+	value = strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(value)
+	return "\"" + value + "\""
+}
@@ -0,0 +1,284 @@
+package gerrit
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Label functions.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/config-labels.html#label_function
+const (
+	LabelFunctionMaxWithBlock = "MaxWithBlock"
+	LabelFunctionAnyWithBlock = "AnyWithBlock"
+	LabelFunctionMaxNoBlock   = "MaxNoBlock"
+	LabelFunctionNoBlock      = "NoBlock"
+	LabelFunctionNoOp         = "NoOp"
+	LabelFunctionPatchSetLock = "PatchSetLock"
+)
+
+// LabelDefinitionInfo entity describes a label.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#label-definition-info
+type LabelDefinitionInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	ProjectName string `json:"project_name,omitempty"`
+	Function    string `json:"function,omitempty"`
+	// Values maps the vote values (e.g. "+1", " 0", "-1") to their descriptions.
+	Values             map[string]string `json:"values"`
+	DefaultValue       int               `json:"default_value"`
+	Branches           []string          `json:"branches,omitempty"`
+	CanOverride        bool              `json:"can_override,omitempty"`
+	CopyCondition      string            `json:"copy_condition,omitempty"`
+	AllowPostSubmit    bool              `json:"allow_post_submit,omitempty"`
+	IgnoreSelfApproval bool              `json:"ignore_self_approval,omitempty"`
+}
+
+// LabelDefinitionInput entity describes a label.
+// Fields that are not set are not changed on update.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#label-definition-input
+type LabelDefinitionInput struct {
+	// Name is only needed to rename a label and in BatchLabelInput.Create.
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Function    string            `json:"function,omitempty"`
+	Values      map[string]string `json:"values,omitempty"`
+	// DefaultValue is a pointer, as 0 is a valid default value.
+	DefaultValue       *int     `json:"default_value,omitempty"`
+	Branches           []string `json:"branches,omitempty"`
+	CanOverride        *bool    `json:"can_override,omitempty"`
+	CopyCondition      string   `json:"copy_condition,omitempty"`
+	UnsetCopyCondition bool     `json:"unset_copy_condition,omitempty"`
+	AllowPostSubmit    *bool    `json:"allow_post_submit,omitempty"`
+	IgnoreSelfApproval *bool    `json:"ignore_self_approval,omitempty"`
+	CommitMessage      string   `json:"commit_message,omitempty"`
+}
+
+// DeleteLabelInput entity contains information for deleting a label.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#delete-label-input
+type DeleteLabelInput struct {
+	CommitMessage string `json:"commit_message,omitempty"`
+}
+
+// BatchLabelInput entity contains information for batch updating label definitions in a project.
+// All changes are applied in one commit.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#batch-label-input
+type BatchLabelInput struct {
+	CommitMessage string `json:"commit_message,omitempty"`
+	// Delete lists the names of the labels to delete.
+	Delete []string `json:"delete,omitempty"`
+	// Create lists the labels to create. The name of each label must be set.
+	Create []LabelDefinitionInput `json:"create,omitempty"`
+	// Update maps the names of the labels to update to their new definition.
+	Update map[string]LabelDefinitionInput `json:"update,omitempty"`
+}
+
+// LabelOptions specifies the parameters for ProjectsService.ListLabels.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#list-labels
+type LabelOptions struct {
+	// Inherited includes the labels of the parent projects.
+	Inherited bool `url:"inherited,omitempty"`
+}
+
+// Validate checks the input for values the server would reject.
+// It is called by ProjectsService.CreateLabel, ProjectsService.SetLabel and ProjectsService.BatchUpdateLabels
+// before the request is sent. A nil input is valid.
+func (input *LabelDefinitionInput) Validate() error {
+	if input == nil {
+		return nil
+	}
+
+	switch input.Function {
+	case "", LabelFunctionMaxWithBlock, LabelFunctionAnyWithBlock, LabelFunctionMaxNoBlock,
+		LabelFunctionNoBlock, LabelFunctionNoOp, LabelFunctionPatchSetLock:
+	default:
+		return fmt.Errorf("invalid label function %q", input.Function)
+	}
+
+	values := map[int]bool{}
+	for value, description := range input.Values {
+		v, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid label value %q: must be an integer", value)
+		}
+		if values[v] {
+			return fmt.Errorf("duplicate label value %q", value)
+		}
+		values[v] = true
+		if strings.TrimSpace(description) == "" {
+			return fmt.Errorf("label value %q has no description", value)
+		}
+	}
+	if input.DefaultValue != nil && len(values) > 0 && !values[*input.DefaultValue] {
+		return fmt.Errorf("default value %d is not a label value", *input.DefaultValue)
+	}
+	if input.CopyCondition != "" && input.UnsetCopyCondition {
+		return fmt.Errorf("copy_condition and unset_copy_condition are mutually exclusive")
+	}
+	return nil
+}
+
+// Validate checks the input for values the server would reject.
+// It is called by ProjectsService.BatchUpdateLabels before the request is sent.
+// A nil input is valid.
+func (input *BatchLabelInput) Validate() error {
+	if input == nil {
+		return nil
+	}
+
+	for i := range input.Create {
+		if input.Create[i].Name == "" {
+			return fmt.Errorf("create[%d]: name is required", i)
+		}
+		if err := input.Create[i].Validate(); err != nil {
+			return fmt.Errorf("create %s: %v", input.Create[i].Name, err)
+		}
+	}
+	names := make([]string, 0, len(input.Update))
+	for name := range input.Update {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		label := input.Update[name]
+		if err := label.Validate(); err != nil {
+			return fmt.Errorf("update %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// ListLabels lists the labels that are defined in a project.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#list-labels
+func (s *ProjectsService) ListLabels(projectName string, opt *LabelOptions) (*[]LabelDefinitionInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/labels/", url.QueryEscape(projectName))
+
+	u, err := addOptions(u, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new([]LabelDefinitionInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// GetLabel retrieves the definition of a label that is defined in a project.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-label
+func (s *ProjectsService) GetLabel(projectName, labelName string) (*LabelDefinitionInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/labels/%s", url.QueryEscape(projectName), url.QueryEscape(labelName))
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(LabelDefinitionInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// CreateLabel creates a new label definition in a project.
+// Values must be set in the input.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#create-label
+func (s *ProjectsService) CreateLabel(projectName, labelName string, input *LabelDefinitionInput) (*LabelDefinitionInfo, *Response, error) {
+	if input == nil || len(input.Values) == 0 {
+		return nil, nil, fmt.Errorf("label %s: values are required", labelName)
+	}
+	return s.SetLabel(projectName, labelName, input)
+}
+
+// SetLabel updates the definition of a label that is defined in a project.
+// Only the fields that are set in the input are updated.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#set-label
+func (s *ProjectsService) SetLabel(projectName, labelName string, input *LabelDefinitionInput) (*LabelDefinitionInfo, *Response, error) {
+	if err := input.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	u := fmt.Sprintf("projects/%s/labels/%s", url.QueryEscape(projectName), url.QueryEscape(labelName))
+
+	req, err := s.client.NewRequest("PUT", u, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(LabelDefinitionInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// DeleteLabel deletes the definition of a label that is defined in a project.
+// input may be nil.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#delete-label
+func (s *ProjectsService) DeleteLabel(projectName, labelName string, input *DeleteLabelInput) (*Response, error) {
+	u := fmt.Sprintf("projects/%s/labels/%s", url.QueryEscape(projectName), url.QueryEscape(labelName))
+	return s.client.DeleteRequest(u, input)
+}
+
+// BatchUpdateLabels creates, updates and deletes label definitions of a project in one commit.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#batch-update-labels
+func (s *ProjectsService) BatchUpdateLabels(projectName string, input *BatchLabelInput) (*Response, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	u := fmt.Sprintf("projects/%s/labels/", url.QueryEscape(projectName))
+	return s.client.Call("POST", u, input, nil)
+}
+
+// CreateLabelsChange creates a change for review that creates, updates and deletes label definitions of a project.
+// This is the review flow of BatchUpdateLabels.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#create-labels-change
+func (s *ProjectsService) CreateLabelsChange(projectName string, input *BatchLabelInput) (*ChangeInfo, *Response, error) {
+	if err := input.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	u := fmt.Sprintf("projects/%s/labels:review", url.QueryEscape(projectName))
+
+	req, err := s.client.NewRequest("POST", u, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(ChangeInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
@@ -0,0 +1,159 @@
+package gerrit
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Project states.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/config-project-config.html#project-section
+const (
+	// ProjectStateActive is the default state of a project.
+	ProjectStateActive = "ACTIVE"
+	// ProjectStateReadOnly makes a project visible, but refuses all writes.
+	ProjectStateReadOnly = "READ_ONLY"
+	// ProjectStateHidden hides a project from everyone except its owners.
+	ProjectStateHidden = "HIDDEN"
+)
+
+// DeleteOptionsInfo entity contains options for the deletion of a project.
+//
+// Gerrit API docs (plugin): https://gerrit.googlesource.com/plugins/delete-project/+/refs/heads/master/src/main/resources/Documentation/rest-api-projects.md#deleteoptionsinfo
+type DeleteOptionsInfo struct {
+	// If set the project is deleted even if it has open changes.
+	Force bool `json:"force,omitempty"`
+	// If set the Git repository is not deleted, only the project is removed from Gerrit.
+	Preserve bool `json:"preserve,omitempty"`
+}
+
+// IndexProjectInput contains parameters for indexing a project.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#index-project-input
+type IndexProjectInput struct {
+	// If set to true, child projects (recursively) are indexed as well.
+	IndexChildren bool `json:"index_children,omitempty"`
+}
+
+// archiveBlockedPermissions are the permissions that ArchiveProject blocks on all refs.
+var archiveBlockedPermissions = []string{
+	"abandon",
+	"create",
+	"createSignedTag",
+	"createTag",
+	"delete",
+	"push",
+	"pushMerge",
+	"submit",
+}
+
+// archiveRef and archiveGroup are the ref pattern and group UUID of the rules added by ArchiveProject.
+const (
+	archiveRef   = "refs/*"
+	archiveGroup = "global:Registered-Users"
+)
+
+// archiveAccess returns the access section with a rule for each of the archiveBlockedPermissions.
+func archiveAccess(rule PermissionRuleInput) map[string]AccessSectionInput {
+	permissions := make(map[string]PermissionInput)
+	for _, name := range archiveBlockedPermissions {
+		permissions[name] = PermissionInput{
+			Rules: map[string]PermissionRuleInput{archiveGroup: rule},
+		}
+	}
+	return map[string]AccessSectionInput{archiveRef: {Permissions: permissions}}
+}
+
+// DeleteProject deletes a project.
+// This requires the delete-project plugin to be installed on the server.
+//
+// Gerrit API docs (plugin): https://gerrit.googlesource.com/plugins/delete-project/+/refs/heads/master/src/main/resources/Documentation/rest-api-projects.md#delete-project
+func (s *ProjectsService) DeleteProject(projectName string, input *DeleteOptionsInfo) (*Response, error) {
+	u := fmt.Sprintf("projects/%s/delete-project~delete", url.QueryEscape(projectName))
+
+	return s.client.Call("POST", u, input, nil)
+}
+
+// IndexProject adds or updates the current project (and children, if specified) in the secondary index.
+// The indexing task is executed asynchronously in background, so this command returns immediately.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#index
+func (s *ProjectsService) IndexProject(projectName string, input *IndexProjectInput) (*Response, error) {
+	u := fmt.Sprintf("projects/%s/index", url.QueryEscape(projectName))
+
+	return s.client.Call("POST", u, input, nil)
+}
+
+// SetProjectState sets the state of a project to ProjectStateActive, ProjectStateReadOnly or ProjectStateHidden.
+// Only the state is sent, all other settings of the project config are left untouched.
+//
+// Gerrit can't rename projects. Hiding a project is the supported way to retire it
+// without losing its history.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#set-config
+func (s *ProjectsService) SetProjectState(projectName, state string) (*ConfigInfo, *Response, error) {
+	switch state {
+	case ProjectStateActive, ProjectStateReadOnly, ProjectStateHidden:
+	default:
+		return nil, nil, fmt.Errorf("invalid project state %q", state)
+	}
+
+	return s.SetConfig(projectName, &ConfigInput{State: state})
+}
+
+// ArchiveProject makes a project read-only.
+// First the access rights are locked by blocking all write permissions on refs/*
+// for registered users, so the project stays locked even if the state is reverted by accident.
+// Then the project state is set to ProjectStateReadOnly.
+//
+// A BLOCK rule can't be overridden, so nobody, not even administrators, can push to, submit on,
+// create or delete any ref of an archived project. This includes direct pushes to refs/meta/config.
+// Project owners and administrators can still change the access rights via SetAccess;
+// UnarchiveProject undoes ArchiveProject.
+//
+// Existing rules for registered users of the blocked permissions on refs/* are replaced.
+//
+// message is used as commit message for the access change and may be empty.
+func (s *ProjectsService) ArchiveProject(projectName, message string) (*ConfigInfo, *Response, error) {
+	if message == "" {
+		message = "Archive project"
+	}
+
+	input := &ProjectAccessInput{
+		Add:     archiveAccess(PermissionRuleInput{Action: "BLOCK"}),
+		Message: message,
+	}
+	if _, resp, err := s.SetAccess(projectName, input); err != nil {
+		return nil, resp, err
+	}
+
+	return s.SetProjectState(projectName, ProjectStateReadOnly)
+}
+
+// UnarchiveProject reverts ArchiveProject.
+// First the project state is set to ProjectStateActive, as Gerrit refuses access changes
+// on read-only projects. Then exactly the rules added by ArchiveProject are removed.
+// Rules for registered users that ArchiveProject replaced are not restored.
+//
+// message is used as commit message for the access change and may be empty.
+func (s *ProjectsService) UnarchiveProject(projectName, message string) (*ConfigInfo, *Response, error) {
+	if message == "" {
+		message = "Unarchive project"
+	}
+
+	config, resp, err := s.SetProjectState(projectName, ProjectStateActive)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	input := &ProjectAccessInput{
+		Remove:  archiveAccess(PermissionRuleInput{}),
+		Message: message,
+	}
+	_, resp, err = s.SetAccess(projectName, input)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return config, resp, nil
+}
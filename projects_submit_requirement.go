@@ -0,0 +1,224 @@
+package gerrit
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+)
+
+// SubmitRequirementInfo entity describes a submit requirement.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#submit-requirement-info
+type SubmitRequirementInfo struct {
+	Name                         string `json:"name"`
+	Description                  string `json:"description,omitempty"`
+	ProjectName                  string `json:"project_name,omitempty"`
+	ApplicabilityExpression      string `json:"applicability_expression,omitempty"`
+	SubmittabilityExpression     string `json:"submittability_expression"`
+	OverrideExpression           string `json:"override_expression,omitempty"`
+	AllowOverrideInChildProjects bool   `json:"allow_override_in_child_projects"`
+}
+
+// SubmitRequirementInput entity describes a submit requirement.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#submit-requirement-input
+type SubmitRequirementInput struct {
+	Name                     string `json:"name,omitempty"`
+	Description              string `json:"description,omitempty"`
+	ApplicabilityExpression  string `json:"applicability_expression,omitempty"`
+	SubmittabilityExpression string `json:"submittability_expression"`
+	OverrideExpression       string `json:"override_expression,omitempty"`
+	// AllowOverrideInChildProjects defaults to false on the server if not set.
+	AllowOverrideInChildProjects *bool `json:"allow_override_in_child_projects,omitempty"`
+}
+
+// BatchSubmitRequirementInput contains the submit requirements to create, update and delete
+// with ProjectsService.BatchUpdateSubmitRequirements.
+type BatchSubmitRequirementInput struct {
+	// Delete lists the names of the submit requirements to delete.
+	Delete []string
+	// Create lists the submit requirements to create. The name of each submit requirement must be set.
+	Create []SubmitRequirementInput
+	// Update maps the names of the submit requirements to update to their new definition.
+	Update map[string]SubmitRequirementInput
+}
+
+// SubmitRequirementOptions specifies the parameters for ProjectsService.ListSubmitRequirements.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#list-submit-requirements
+type SubmitRequirementOptions struct {
+	// Inherited includes the submit requirements of the parent projects.
+	Inherited bool `url:"inherited,omitempty"`
+}
+
+// Validate checks the input for values the server would reject.
+// It is called by ProjectsService.CreateSubmitRequirement and ProjectsService.UpdateSubmitRequirement
+// before the request is sent.
+func (input *SubmitRequirementInput) Validate() error {
+	if input == nil || input.SubmittabilityExpression == "" {
+		return fmt.Errorf("submittability_expression is required")
+	}
+	return nil
+}
+
+// Validate checks the input for values the server would reject.
+// It is called by ProjectsService.BatchUpdateSubmitRequirements before the first request is sent.
+// A nil input is valid.
+func (input *BatchSubmitRequirementInput) Validate() error {
+	if input == nil {
+		return nil
+	}
+
+	for i := range input.Create {
+		if input.Create[i].Name == "" {
+			return fmt.Errorf("create[%d]: name is required", i)
+		}
+		if err := input.Create[i].Validate(); err != nil {
+			return fmt.Errorf("create %s: %v", input.Create[i].Name, err)
+		}
+	}
+	for _, name := range input.updateNames() {
+		sr := input.Update[name]
+		if err := sr.Validate(); err != nil {
+			return fmt.Errorf("update %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// updateNames returns the names of the submit requirements to update, sorted by name.
+func (input *BatchSubmitRequirementInput) updateNames() []string {
+	names := make([]string, 0, len(input.Update))
+	for name := range input.Update {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ListSubmitRequirements lists the submit requirements that are defined in a project.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#list-submit-requirements
+func (s *ProjectsService) ListSubmitRequirements(projectName string, opt *SubmitRequirementOptions) (*[]SubmitRequirementInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/submit_requirements/", url.QueryEscape(projectName))
+
+	u, err := addOptions(u, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new([]SubmitRequirementInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// GetSubmitRequirement retrieves the definition of a submit requirement that is defined in a project.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#get-submit-requirement
+func (s *ProjectsService) GetSubmitRequirement(projectName, name string) (*SubmitRequirementInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/submit_requirements/%s", url.QueryEscape(projectName), url.QueryEscape(name))
+
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(SubmitRequirementInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// CreateSubmitRequirement creates a new submit requirement definition in a project.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#create-submit-requirement
+func (s *ProjectsService) CreateSubmitRequirement(projectName, name string, input *SubmitRequirementInput) (*SubmitRequirementInfo, *Response, error) {
+	return s.putSubmitRequirement(projectName, name, input)
+}
+
+// UpdateSubmitRequirement updates the definition of a submit requirement that is defined in a project.
+// The definition is replaced as a whole, so all fields of the input must be set.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#update-submit-requirement
+func (s *ProjectsService) UpdateSubmitRequirement(projectName, name string, input *SubmitRequirementInput) (*SubmitRequirementInfo, *Response, error) {
+	return s.putSubmitRequirement(projectName, name, input)
+}
+
+func (s *ProjectsService) putSubmitRequirement(projectName, name string, input *SubmitRequirementInput) (*SubmitRequirementInfo, *Response, error) {
+	if err := input.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	u := fmt.Sprintf("projects/%s/submit_requirements/%s", url.QueryEscape(projectName), url.QueryEscape(name))
+
+	req, err := s.client.NewRequest("PUT", u, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(SubmitRequirementInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// DeleteSubmitRequirement deletes the definition of a submit requirement that is defined in a project.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#delete-submit-requirement
+func (s *ProjectsService) DeleteSubmitRequirement(projectName, name string) (*Response, error) {
+	u := fmt.Sprintf("projects/%s/submit_requirements/%s", url.QueryEscape(projectName), url.QueryEscape(name))
+	return s.client.DeleteRequest(u, nil)
+}
+
+// BatchUpdateSubmitRequirements creates, updates and deletes submit requirements of a project.
+// Gerrit has no batch endpoint for submit requirements, so one request is sent per submit requirement:
+// first the deletions, then the creations and finally the updates.
+// The whole input is validated before the first request is sent,
+// but if a request fails, the preceding requests are not rolled back.
+//
+// The returned Response is the one of the last request.
+func (s *ProjectsService) BatchUpdateSubmitRequirements(projectName string, input *BatchSubmitRequirementInput) (*Response, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	if input == nil {
+		return nil, nil
+	}
+
+	var resp *Response
+	var err error
+	for _, name := range input.Delete {
+		if resp, err = s.DeleteSubmitRequirement(projectName, name); err != nil {
+			return resp, fmt.Errorf("delete %s: %v", name, err)
+		}
+	}
+	for i := range input.Create {
+		sr := &input.Create[i]
+		if _, resp, err = s.CreateSubmitRequirement(projectName, sr.Name, sr); err != nil {
+			return resp, fmt.Errorf("create %s: %v", sr.Name, err)
+		}
+	}
+	for _, name := range input.updateNames() {
+		sr := input.Update[name]
+		if _, resp, err = s.UpdateSubmitRequirement(projectName, name, &sr); err != nil {
+			return resp, fmt.Errorf("update %s: %v", name, err)
+		}
+	}
+
+	return resp, nil
+}
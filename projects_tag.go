@@ -3,15 +3,65 @@ package gerrit
 import (
 	"fmt"
 	"net/url"
+	"strings"
 )
 
 // TagInfo entity contains information about a tag.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#tag-info
 type TagInfo struct {
-	Ref      string        `json:"ref"`
-	Revision string        `json:"revision"`
-	Object   string        `json:"object"`
-	Message  string        `json:"message"`
-	Tagger   GitPersonInfo `json:"tagger"`
+	Ref      string `json:"ref"`
+	Revision string `json:"revision"`
+	// Object, Message and Tagger are only set for annotated tags.
+	Object    string         `json:"object,omitempty"`
+	Message   string         `json:"message,omitempty"`
+	Tagger    *GitPersonInfo `json:"tagger,omitempty"`
+	Created   string         `json:"created,omitempty"`
+	CanDelete bool           `json:"can_delete,omitempty"`
+	WebLinks  []WebLinkInfo  `json:"web_links,omitempty"`
+}
+
+// TagInput entity contains information for creating a tag.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#tag-input
+type TagInput struct {
+	// The name of the tag. The leading refs/tags/ is optional.
+	// If set, it must match the tag name in the URL.
+	Ref string `json:"ref,omitempty"`
+	// The revision to which the tag should point. If not specified, the project’s HEAD will be used.
+	Revision string `json:"revision,omitempty"`
+	// The tag message. When set, the tag will be created as an annotated tag.
+	Message string `json:"message,omitempty"`
+}
+
+// DeleteTagsInput entity contains information about tags that should be deleted.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#delete-tags-input
+type DeleteTagsInput struct {
+	Tags []string `json:"tags"`
+}
+
+// pgpSignatureMarker starts the signature of a signed tag.
+const pgpSignatureMarker = "-----BEGIN PGP SIGNATURE-----"
+
+// IsAnnotated reports if the tag is an annotated tag.
+func (t *TagInfo) IsAnnotated() bool {
+	return t.Object != ""
+}
+
+// IsSigned reports if the tag is a signed tag.
+// Signed tags can't be created via the REST API, but are reported with their signature in the message.
+func (t *TagInfo) IsSigned() bool {
+	return strings.Contains(t.Message, pgpSignatureMarker)
+}
+
+// Commit returns the SHA-1 of the commit the tag points to.
+// For annotated tags this is the tagged object, for lightweight tags the revision.
+func (t *TagInfo) Commit() string {
+	if t.IsAnnotated() {
+		return t.Object
+	}
+	return t.Revision
 }
 
 // ListTags list the tags of a project.
@@ -57,3 +107,53 @@ func (s *ProjectsService) GetTag(projectName, tagName string) (*TagInfo, *Respon
 
 	return v, resp, err
 }
+
+// CreateTag creates a new tag on the project.
+// If a message is set in the input, the tag will be created as an annotated tag with the current user as tagger.
+// Signed tags are not supported.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#create-tag
+func (s *ProjectsService) CreateTag(projectName, tagName string, input *TagInput) (*TagInfo, *Response, error) {
+	u := fmt.Sprintf("projects/%s/tags/%s", url.QueryEscape(projectName), url.QueryEscape(tagName))
+
+	req, err := s.client.NewRequest("PUT", u, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := new(TagInfo)
+	resp, err := s.client.Do(req, v)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return v, resp, err
+}
+
+// DeleteTag deletes a tag.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#delete-tag
+func (s *ProjectsService) DeleteTag(projectName, tagName string) (*Response, error) {
+	u := fmt.Sprintf("projects/%s/tags/%s", url.QueryEscape(projectName), url.QueryEscape(tagName))
+	return s.client.DeleteRequest(u, nil)
+}
+
+// DeleteTags deletes one or more tags.
+// The tags to be deleted must be provided in the request body as a DeleteTagsInput entity.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#delete-tags
+func (s *ProjectsService) DeleteTags(projectName string, input *DeleteTagsInput) (*Response, error) {
+	u := fmt.Sprintf("projects/%s/tags:delete", url.QueryEscape(projectName))
+	return s.client.Call("POST", u, input, nil)
+}
+
+// GetTagCommit resolves a tag to the commit it points to.
+// Annotated tags are peeled, so the tagged commit is returned instead of the tag object.
+func (s *ProjectsService) GetTagCommit(projectName, tagName string) (*CommitInfo, *Response, error) {
+	tag, resp, err := s.GetTag(projectName, tagName)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return s.GetCommit(projectName, tag.Commit())
+}
@@ -1,11 +1,15 @@
 package gerrit_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/andygrunwald/go-gerrit"
 )
@@ -167,9 +171,1469 @@ func TestProjectsService_GetProjectDescription(t *testing.T) {
 	}
 }
 
+func TestProjectsService_SetAccess(t *testing.T) {
+	setup()
+	defer teardown()
+
+	input := &gerrit.ProjectAccessInput{
+		Add: map[string]gerrit.AccessSectionInput{
+			"refs/heads/*": {
+				Permissions: map[string]gerrit.PermissionInput{
+					"submit": {
+						Rules: map[string]gerrit.PermissionRuleInput{
+							"global:Project-Owners": {Action: "ALLOW"},
+						},
+					},
+					"label-Code-Review": {
+						Label: "Code-Review",
+						Rules: map[string]gerrit.PermissionRuleInput{
+							"global:Project-Owners": {Action: "ALLOW", Min: gerrit.Int(-2), Max: gerrit.Int(2)},
+						},
+					},
+				},
+			},
+		},
+		Remove: map[string]gerrit.AccessSectionInput{
+			"refs/heads/stable": {},
+		},
+		Message: "Allow owners to submit",
+	}
+
+	testMux.HandleFunc("/projects/my/project/access", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		if got, want := r.URL.EscapedPath(), "/projects/my%2Fproject/access"; got != want {
+			t.Errorf("Request path %s, want %s", got, want)
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		// Unset attributes must not be sent, otherwise they overwrite the existing permissions.
+		for _, field := range []string{"exclusive", "force", `"min":0`, `"max":0`} {
+			if strings.Contains(string(body), field) {
+				t.Errorf("Request body %s contains %s", body, field)
+			}
+		}
+
+		v := new(gerrit.ProjectAccessInput)
+		json.Unmarshal(body, v)
+
+		if !reflect.DeepEqual(v, input) {
+			t.Errorf("Request body = %+v, want %+v", v, input)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"revision":"61157ed63e14d261b6dca40650472a9b0bd88474","inherits_from":{"id":"All-Projects","name":"All-Projects"},"local":{"refs/heads/*":{"permissions":{"submit":{"rules":{"global:Project-Owners":{"action":"ALLOW"}}}}}},"is_owner":true,"owner_of":["refs/*"],"can_upload":true,"can_add":true,"config_visible":true,"groups":{"global:Project-Owners":{"name":"Project Owners"}}}`)
+	})
+
+	access, _, err := testClient.Projects.SetAccess("my/project", input)
+	if err != nil {
+		t.Errorf("Projects.SetAccess returned error: %v", err)
+	}
+
+	want := &gerrit.ProjectAccessInfo{
+		Revision:     "61157ed63e14d261b6dca40650472a9b0bd88474",
+		InheritsFrom: gerrit.ProjectInfo{ID: "All-Projects", Name: "All-Projects"},
+		Local: map[string]gerrit.AccessSectionInfo{
+			"refs/heads/*": {
+				Permissions: map[string]gerrit.PermissionInfo{
+					"submit": {
+						Rules: map[string]gerrit.PermissionRuleInfo{
+							"global:Project-Owners": {Action: "ALLOW"},
+						},
+					},
+				},
+			},
+		},
+		IsOwner:       true,
+		OwnerOf:       []string{"refs/*"},
+		CanUpload:     true,
+		CanAdd:        true,
+		ConfigVisible: true,
+		Groups: map[string]gerrit.GroupInfo{
+			"global:Project-Owners": {Name: "Project Owners"},
+		},
+	}
+
+	if !reflect.DeepEqual(access, want) {
+		t.Errorf("Projects.SetAccess returned %+v, want %+v", access, want)
+	}
+}
+
+func TestProjectsService_CreateAccessChange(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/access:review", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"id":"go~refs%2Fmeta%2Fconfig~Ibb8a3a8ea4a1fe3a2ef8b1db7b8fbb8fbb8fbb8f","project":"go","branch":"refs/meta/config","status":"NEW","_number":1}`)
+	})
+
+	change, _, err := testClient.Projects.CreateAccessChange("go", &gerrit.ProjectAccessInput{Message: "Review me"})
+	if err != nil {
+		t.Errorf("Projects.CreateAccessChange returned error: %v", err)
+	}
+
+	if change.Number != 1 || change.Branch != "refs/meta/config" {
+		t.Errorf("Projects.CreateAccessChange returned %+v, want change 1 on refs/meta/config", change)
+	}
+}
+
+func TestProjectsService_CheckAccess(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/check.access", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testFormValues(t, r, testValues{
+			"account": "1000098",
+			"ref":     "refs/heads/secret/bla",
+			"perm":    "push",
+		})
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"message":"user Kristen Burns (1000098) cannot see ref refs/heads/secret/bla in project go","status":403}`)
+	})
+
+	access, _, err := testClient.Projects.CheckAccess("go", &gerrit.CheckAccessOptions{
+		Account:    "1000098",
+		Ref:        "refs/heads/secret/bla",
+		Permission: "push",
+	})
+	if err != nil {
+		t.Errorf("Projects.CheckAccess returned error: %v", err)
+	}
+
+	want := &gerrit.AccessCheckInfo{
+		Status:  403,
+		Message: "user Kristen Burns (1000098) cannot see ref refs/heads/secret/bla in project go",
+	}
+
+	if !reflect.DeepEqual(access, want) {
+		t.Errorf("Projects.CheckAccess returned %+v, want %+v", access, want)
+	}
+}
+
+func TestProjectsService_CreateProject_OmitsUnsetOptions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		want := map[string]interface{}{"create_empty_commit": true}
+		if !reflect.DeepEqual(body, want) {
+			t.Errorf("Request body = %+v, want %+v", body, want)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"id":"go","name":"go"}`)
+	})
+
+	_, _, err := testClient.Projects.CreateProject("go", &gerrit.ProjectInput{CreateEmptyCommit: gerrit.Bool(true)})
+	if err != nil {
+		t.Errorf("Projects.CreateProject returned error: %v", err)
+	}
+}
+
+func TestProjectsService_DeleteProject(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/delete-project~delete", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		v := new(gerrit.DeleteOptionsInfo)
+		json.NewDecoder(r.Body).Decode(v)
+		if want := (&gerrit.DeleteOptionsInfo{Force: true, Preserve: true}); !reflect.DeepEqual(v, want) {
+			t.Errorf("Request body = %+v, want %+v", v, want)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	_, err := testClient.Projects.DeleteProject("go", &gerrit.DeleteOptionsInfo{Force: true, Preserve: true})
+	if err != nil {
+		t.Errorf("Projects.DeleteProject returned error: %v", err)
+	}
+}
+
+func TestProjectsService_IndexProject(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/index", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		v := new(gerrit.IndexProjectInput)
+		json.NewDecoder(r.Body).Decode(v)
+		if !v.IndexChildren {
+			t.Error("Request body does not set index_children")
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	_, err := testClient.Projects.IndexProject("go", &gerrit.IndexProjectInput{IndexChildren: true})
+	if err != nil {
+		t.Errorf("Projects.IndexProject returned error: %v", err)
+	}
+}
+
+func TestProjectsService_ArchiveProject(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var calls []string
+	testMux.HandleFunc("/projects/go/access", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		calls = append(calls, "access")
+
+		v := new(gerrit.ProjectAccessInput)
+		json.NewDecoder(r.Body).Decode(v)
+		rule := v.Add["refs/*"].Permissions["push"].Rules["global:Registered-Users"]
+		if rule.Action != "BLOCK" {
+			t.Errorf("Push rule for registered users is %+v, want BLOCK", rule)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"revision":"abc"}`)
+	})
+	testMux.HandleFunc("/projects/go/config", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		calls = append(calls, "config")
+
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if want := map[string]interface{}{"state": "READ_ONLY"}; !reflect.DeepEqual(body, want) {
+			t.Errorf("Request body = %+v, want %+v", body, want)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"state":"READ_ONLY"}`)
+	})
+
+	config, _, err := testClient.Projects.ArchiveProject("go", "")
+	if err != nil {
+		t.Errorf("Projects.ArchiveProject returned error: %v", err)
+	}
+	if config.State != gerrit.ProjectStateReadOnly {
+		t.Errorf("Project state is %q, want %q", config.State, gerrit.ProjectStateReadOnly)
+	}
+	if want := []string{"access", "config"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Calls %v, want %v", calls, want)
+	}
+
+	if _, _, err := testClient.Projects.SetProjectState("go", "ARCHIVED"); err == nil {
+		t.Error("Projects.SetProjectState with an invalid state returned no error")
+	}
+}
+
+func TestProjectsService_UnarchiveProject(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var calls []string
+	testMux.HandleFunc("/projects/go/config", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		calls = append(calls, "config")
+
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if want := map[string]interface{}{"state": "ACTIVE"}; !reflect.DeepEqual(body, want) {
+			t.Errorf("Request body = %+v, want %+v", body, want)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"state":"ACTIVE"}`)
+	})
+	testMux.HandleFunc("/projects/go/access", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		calls = append(calls, "access")
+
+		v := new(gerrit.ProjectAccessInput)
+		json.NewDecoder(r.Body).Decode(v)
+		if len(v.Add) != 0 || len(v.Remove["refs/*"].Permissions) != 8 {
+			t.Errorf("Access input %+v, want removals of the 8 blocked permissions", v)
+		}
+		// Only the rules of registered users are removed, not the whole permission.
+		if want := map[string]gerrit.PermissionRuleInput{"global:Registered-Users": {}}; !reflect.DeepEqual(v.Remove["refs/*"].Permissions["push"].Rules, want) {
+			t.Errorf("Removed push rules %+v, want %+v", v.Remove["refs/*"].Permissions["push"].Rules, want)
+		}
+		if v.Message != "Unarchive project" {
+			t.Errorf("Message is %q, want the default message", v.Message)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"revision":"def"}`)
+	})
+
+	config, _, err := testClient.Projects.UnarchiveProject("go", "")
+	if err != nil {
+		t.Errorf("Projects.UnarchiveProject returned error: %v", err)
+	}
+	if config.State != gerrit.ProjectStateActive {
+		t.Errorf("Project state is %q, want %q", config.State, gerrit.ProjectStateActive)
+	}
+	if want := []string{"config", "access"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Calls %v, want %v", calls, want)
+	}
+}
+
+func TestProjectsService_ListAccessOwners(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/access", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `)]}'`+"\n"+`{"revision":"abc","local":{`+
+			`"refs/*":{"permissions":{"owner":{"rules":{"b2":{"action":"ALLOW"},"a1":{"action":"ALLOW"},"c3":{"action":"BLOCK"}}},"read":{"rules":{"d4":{"action":"ALLOW"}}}}},`+
+			`"refs/heads/stable":{"permissions":{"owner":{"rules":{"ldap:cn=release":{"action":"ALLOW"}}}}}},`+
+			`"groups":{"a1":{"name":"Admins"},"b2":{"name":"Maintainers"},"c3":{"name":"Contractors"},"d4":{"name":"Devs"}}}`)
+	})
+
+	owners, _, err := testClient.Projects.ListAccessOwners("go")
+	if err != nil {
+		t.Fatalf("Projects.ListAccessOwners returned error: %v", err)
+	}
+	want := map[string][]gerrit.GroupInfo{
+		"refs/*":            {{ID: "a1", Name: "Admins"}, {ID: "b2", Name: "Maintainers"}},
+		"refs/heads/stable": {{ID: "ldap:cn=release"}},
+	}
+	if !reflect.DeepEqual(owners, want) {
+		t.Errorf("Projects.ListAccessOwners returned %+v, want %+v", owners, want)
+	}
+}
+
+func TestProjectsService_GetConfig(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/config", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `)]}'`+"\n"+`{"description":"The Go Programming Language","state":"ACTIVE"}`)
+	})
+
+	config, _, err := testClient.Projects.GetConfig("go")
+	if err != nil {
+		t.Fatalf("Projects.GetConfig returned error: %v", err)
+	}
+	if config.State != gerrit.ProjectStateActive {
+		t.Errorf("Project state is %q, want %q", config.State, gerrit.ProjectStateActive)
+	}
+}
+
+func TestProjectsService_SetConfig(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/config", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		fmt.Fprint(w, `)]}'`+"\n"+`{"description":"Go","state":"ACTIVE"}`)
+	})
+
+	config, _, err := testClient.Projects.SetConfig("go", &gerrit.ConfigInput{Description: "Go"})
+	if err != nil {
+		t.Fatalf("Projects.SetConfig returned error: %v", err)
+	}
+	if config.Description != "Go" {
+		t.Errorf("Project description is %q, want %q", config.Description, "Go")
+	}
+}
+
+func TestProjectsService_SetConfig_MaxObjectSizeLimit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/config", func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		// The input takes the plain limit, unlike the MaxObjectSizeLimitInfo of ConfigInfo.
+		if want := map[string]interface{}{"max_object_size_limit": "10m"}; !reflect.DeepEqual(body, want) {
+			t.Errorf("Request body = %+v, want %+v", body, want)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"max_object_size_limit":{"value":"10m","configured_value":"10m"}}`)
+	})
+
+	config, _, err := testClient.Projects.SetConfig("go", &gerrit.ConfigInput{MaxObjectSizeLimit: "10m"})
+	if err != nil {
+		t.Fatalf("Projects.SetConfig returned error: %v", err)
+	}
+	if config.MaxObjectSizeLimit.ConfiguredValue != "10m" {
+		t.Errorf("Configured max object size limit is %q, want %q", config.MaxObjectSizeLimit.ConfiguredValue, "10m")
+	}
+}
+
+func TestProjectsService_SetConfig_InvalidInheritableBoolean(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/config", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Request was sent with an invalid input")
+	})
+
+	_, _, err := testClient.Projects.SetConfig("go", &gerrit.ConfigInput{UseContentMerge: "yes"})
+	if err == nil {
+		t.Error("Projects.SetConfig with an invalid inheritable boolean returned no error")
+	}
+	_, _, err = testClient.Projects.CreateProject("go", &gerrit.ProjectInput{RequireChangeID: "true"})
+	if err == nil {
+		t.Error("Projects.CreateProject with an invalid inheritable boolean returned no error")
+	}
+}
+
+func TestProjectsService_GetEffectiveConfig(t *testing.T) {
+	setup()
+	defer teardown()
+
+	hierarchy := map[string]struct {
+		parent string
+		config string
+	}{
+		"go":           {"Go-Parent", `{"use_content_merge":{"value":true,"configured_value":"INHERIT","inherited_value":true},"require_change_id":{"value":false,"configured_value":"FALSE","inherited_value":true}}`},
+		"Go-Parent":    {"All-Projects", `{"use_content_merge":{"value":true,"configured_value":"TRUE","inherited_value":false},"require_change_id":{"value":true,"configured_value":"INHERIT","inherited_value":true}}`},
+		"All-Projects": {"", `{"use_content_merge":{"value":false,"configured_value":"FALSE"},"require_change_id":{"value":true,"configured_value":"TRUE"}}`},
+	}
+	for name, project := range hierarchy {
+		project := project
+		testMux.HandleFunc("/projects/"+name+"/config", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `)]}'`+"\n"+project.config)
+		})
+		testMux.HandleFunc("/projects/"+name+"/parent", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `)]}'`+"\n"+`%q`, project.parent)
+		})
+	}
+
+	config, _, err := testClient.Projects.GetEffectiveConfig("go")
+	if err != nil {
+		t.Fatalf("Projects.GetEffectiveConfig returned error: %v", err)
+	}
+
+	want := &gerrit.EffectiveConfig{
+		Project:         "go",
+		Parents:         []string{"Go-Parent", "All-Projects"},
+		UseContentMerge: gerrit.EffectiveBoolean{Value: true, Project: "Go-Parent"},
+		RequireChangeID: gerrit.EffectiveBoolean{Value: false, Project: "go"},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("Projects.GetEffectiveConfig returned %+v, want %+v", config, want)
+	}
+}
+
+func TestProjectsService_GetProjectTree(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+
+		switch {
+		case r.FormValue("t") == "true":
+			fmt.Fprint(w, `)]}'`+"\n"+`{"All-Projects":{"id":"All-Projects"},"Public-Projects":{"id":"Public-Projects","parent":"All-Projects"},"go":{"id":"go","parent":"Public-Projects"},"tools":{"id":"tools","parent":"Public-Projects","state":"READ_ONLY"},"lost":{"id":"lost","parent":"Deleted-Parent"}}`)
+		case r.FormValue("type") == "PERMISSIONS":
+			fmt.Fprint(w, `)]}'`+"\n"+`{"All-Projects":{"id":"All-Projects"},"Public-Projects":{"id":"Public-Projects"}}`)
+		default:
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+	})
+
+	tree, _, err := testClient.Projects.GetProjectTree(nil)
+	if err != nil {
+		t.Fatalf("Projects.GetProjectTree returned error: %v", err)
+	}
+
+	checks := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"Roots", tree.Roots(), []string{"All-Projects", "lost"}},
+		{"Orphans", tree.Orphans(), []string{"lost"}},
+		{"PermissionsOnlyParents", tree.PermissionsOnlyParents(), []string{"All-Projects", "Public-Projects"}},
+		{"Ancestors", tree.Ancestors("go"), []string{"Public-Projects", "All-Projects"}},
+		{"Descendants", tree.Descendants("All-Projects"), []string{"Public-Projects", "go", "tools"}},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s returned %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tree.WriteDOT(buf); err != nil {
+		t.Fatal(err)
+	}
+	wantDOT := `digraph projects {
+	rankdir=BT;
+	"All-Projects" [shape=box];
+	"Public-Projects" [shape=box];
+	"go";
+	"lost";
+	"tools" [style=dotted];
+	"Deleted-Parent" [style=dashed];
+	"Public-Projects" -> "All-Projects";
+	"go" -> "Public-Projects";
+	"lost" -> "Deleted-Parent";
+	"tools" -> "Public-Projects";
+}
+`
+	if got := buf.String(); got != wantDOT {
+		t.Errorf("WriteDOT wrote\n%s\nwant\n%s", got, wantDOT)
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `[{"name":"All-Projects","permissions_only":true,"children":[{"name":"Public-Projects","parent":"All-Projects","permissions_only":true,"children":[{"name":"go","parent":"Public-Projects"},{"name":"tools","parent":"Public-Projects","state":"READ_ONLY"}]}]},{"name":"lost","parent":"Deleted-Parent"}]`
+	if string(data) != wantJSON {
+		t.Errorf("JSON encoding is\n%s\nwant\n%s", data, wantJSON)
+	}
+}
+
+func TestProjectTree_Cycles(t *testing.T) {
+	tree := gerrit.NewProjectTree()
+	tree.Add("All-Projects", gerrit.ProjectInfo{})
+	tree.Add("a", gerrit.ProjectInfo{Parent: "c"})
+	tree.Add("b", gerrit.ProjectInfo{Parent: "a"})
+	tree.Add("c", gerrit.ProjectInfo{Parent: "b"})
+	tree.Add("d", gerrit.ProjectInfo{Parent: "b"})
+	tree.Add("self", gerrit.ProjectInfo{Parent: "self"})
+
+	if got, want := tree.Cycles(), [][]string{{"a", "c", "b"}, {"self"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Cycles returned %v, want %v", got, want)
+	}
+	if got, want := tree.Roots(), []string{"All-Projects", "a", "self"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Roots returned %v, want %v", got, want)
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `[{"name":"All-Projects"},` +
+		`{"name":"a","parent":"c","cycle":true,"children":[{"name":"b","parent":"a","cycle":true,"children":[{"name":"c","parent":"b","cycle":true},{"name":"d","parent":"b"}]}]},` +
+		`{"name":"self","parent":"self","cycle":true}]`
+	if string(data) != wantJSON {
+		t.Errorf("JSON encoding is\n%s\nwant\n%s", data, wantJSON)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tree.WriteDOT(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\t\"b\" [color=red];\n") || strings.Contains(buf.String(), "\"d\" [color=red]") {
+		t.Errorf("WriteDOT wrote\n%s\nwant the projects of the cycles in red", buf)
+	}
+}
+
+func TestProjectsService_AddToProjectTree(t *testing.T) {
+	setup()
+	defer teardown()
+
+	projects := map[string]string{
+		"go":              `{"id":"go","name":"go","parent":"Public-Projects"}`,
+		"Public-Projects": `{"id":"Public-Projects","name":"Public-Projects","parent":"All-Projects"}`,
+		"All-Projects":    `{"id":"All-Projects","name":"All-Projects"}`,
+	}
+	for name, body := range projects {
+		body := body
+		testMux.HandleFunc("/projects/"+name, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `)]}'`+"\n"+body)
+		})
+	}
+	testMux.HandleFunc("/projects/go/children/", func(w http.ResponseWriter, r *http.Request) {
+		testFormValues(t, r, testValues{"recursive": "1"})
+		fmt.Fprint(w, `)]}'`+"\n"+`[{"id":"go%2Fx","name":"go/x","parent":"go"},{"id":"go%2Fx%2Fy","name":"go/x/y","parent":"go/x"}]`)
+	})
+
+	tree := gerrit.NewProjectTree()
+	if _, err := testClient.Projects.AddToProjectTree(tree, "go"); err != nil {
+		t.Fatalf("Projects.AddToProjectTree returned error: %v", err)
+	}
+
+	if got, want := tree.Projects(), []string{"All-Projects", "Public-Projects", "go", "go/x", "go/x/y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Projects %v, want %v", got, want)
+	}
+	if got, want := tree.Ancestors("go/x/y"), []string{"go/x", "go", "Public-Projects", "All-Projects"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Ancestors %v, want %v", got, want)
+	}
+	if orphans := tree.Orphans(); len(orphans) != 0 {
+		t.Errorf("Orphans %v, want none", orphans)
+	}
+}
+
+func TestProjectsService_CreateTag(t *testing.T) {
+	setup()
+	defer teardown()
+
+	input := &gerrit.TagInput{
+		Revision: "v1.0-rc1",
+		Message:  "Release 1.0",
+	}
+
+	testMux.HandleFunc("/projects/go/tags/v1.0", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+
+		v := new(gerrit.TagInput)
+		json.NewDecoder(r.Body).Decode(v)
+		if !reflect.DeepEqual(v, input) {
+			t.Errorf("Request body = %+v, want %+v", v, input)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"ref":"refs/tags/v1.0","revision":"a1b2c3","object":"d4e5f6","message":"Release 1.0","tagger":{"name":"John Doe","email":"john@example.com","date":"2024-05-01 10:00:00.000000000","tz":120},"can_delete":true}`)
+	})
+
+	tag, _, err := testClient.Projects.CreateTag("go", "v1.0", input)
+	if err != nil {
+		t.Fatalf("Projects.CreateTag returned error: %v", err)
+	}
+
+	want := &gerrit.TagInfo{
+		Ref:      "refs/tags/v1.0",
+		Revision: "a1b2c3",
+		Object:   "d4e5f6",
+		Message:  "Release 1.0",
+		Tagger: &gerrit.GitPersonInfo{
+			Name:  "John Doe",
+			Email: "john@example.com",
+			Date:  "2024-05-01 10:00:00.000000000",
+			TZ:    120,
+		},
+		CanDelete: true,
+	}
+	if !reflect.DeepEqual(tag, want) {
+		t.Errorf("Projects.CreateTag returned %+v, want %+v", tag, want)
+	}
+	if !tag.IsAnnotated() {
+		t.Error("IsAnnotated = false, want true")
+	}
+	if tag.IsSigned() {
+		t.Error("IsSigned = true, want false")
+	}
+}
+
+func TestProjectsService_DeleteTag(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/tags/v1.0", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	_, err := testClient.Projects.DeleteTag("go", "v1.0")
+	if err != nil {
+		t.Errorf("Projects.DeleteTag returned error: %v", err)
+	}
+}
+
+func TestProjectsService_DeleteTags(t *testing.T) {
+	setup()
+	defer teardown()
+
+	input := &gerrit.DeleteTagsInput{Tags: []string{"v1.0", "refs/tags/v1.1"}}
+
+	testMux.HandleFunc("/projects/go/tags:delete", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		v := new(gerrit.DeleteTagsInput)
+		json.NewDecoder(r.Body).Decode(v)
+		if !reflect.DeepEqual(v, input) {
+			t.Errorf("Request body = %+v, want %+v", v, input)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	_, err := testClient.Projects.DeleteTags("go", input)
+	if err != nil {
+		t.Errorf("Projects.DeleteTags returned error: %v", err)
+	}
+}
+
+func TestProjectsService_GetTagCommit(t *testing.T) {
+	tests := []struct {
+		name   string
+		tag    string
+		commit string
+	}{
+		{"annotated", `{"ref":"refs/tags/v1.0","revision":"a1b2c3","object":"d4e5f6","message":"Release 1.0"}`, "d4e5f6"},
+		{"lightweight", `{"ref":"refs/tags/v1.0","revision":"d4e5f6"}`, "d4e5f6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			testMux.HandleFunc("/projects/go/tags/v1.0", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				fmt.Fprint(w, `)]}'`+"\n"+tt.tag)
+			})
+			testMux.HandleFunc("/projects/go/commits/"+tt.commit, func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				fmt.Fprint(w, `)]}'`+"\n"+`{"commit":"`+tt.commit+`","subject":"Prepare release"}`)
+			})
+
+			commit, _, err := testClient.Projects.GetTagCommit("go", "v1.0")
+			if err != nil {
+				t.Fatalf("Projects.GetTagCommit returned error: %v", err)
+			}
+			if commit.Commit != tt.commit {
+				t.Errorf("Commit = %q, want %q", commit.Commit, tt.commit)
+			}
+		})
+	}
+}
+
+func TestTagInfo_Lightweight(t *testing.T) {
+	tag := new(gerrit.TagInfo)
+	if err := json.Unmarshal([]byte(`{"ref":"refs/tags/v1.0","revision":"a1b2c3"}`), tag); err != nil {
+		t.Fatal(err)
+	}
+	if tag.Tagger != nil || tag.IsAnnotated() {
+		t.Errorf("Lightweight tag has tagger %+v, want none", tag.Tagger)
+	}
+
+	data, err := json.Marshal(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"ref":"refs/tags/v1.0","revision":"a1b2c3"}`; string(data) != want {
+		t.Errorf("JSON encoding is %s, want %s", data, want)
+	}
+}
+
+func TestTagInfo_IsSigned(t *testing.T) {
+	tag := &gerrit.TagInfo{
+		Object:  "d4e5f6",
+		Message: "Release 1.0\n-----BEGIN PGP SIGNATURE-----\n\niQEcBAABAgAGBQJX\n-----END PGP SIGNATURE-----\n",
+	}
+	if !tag.IsSigned() {
+		t.Error("IsSigned = false, want true")
+	}
+}
+
+func TestProjectsService_DeleteBranches(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/branches:delete", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		b, _ := ioutil.ReadAll(r.Body)
+		if got, want := string(b), `{"branches":["stable-1.0","refs/heads/stable-1.1"]}`+"\n"; got != want {
+			t.Errorf("Request body = %s, want %s", got, want)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	input := &gerrit.DeleteBranchesInput{Branches: []string{"stable-1.0", "refs/heads/stable-1.1"}}
+	_, err := testClient.Projects.DeleteBranches("go", input)
+	if err != nil {
+		t.Errorf("Projects.DeleteBranches returned error: %v", err)
+	}
+}
+
+func TestProjectsService_ListAllBranches(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var skips []string
+	testMux.HandleFunc("/projects/go/branches/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if n, m := r.FormValue("n"), r.FormValue("m"); n != "2" || m != "stable" {
+			t.Errorf("Request parameters n=%q m=%q, want n=2 m=stable", n, m)
+		}
+
+		skip := r.FormValue("s")
+		skips = append(skips, skip)
+		switch skip {
+		case "0":
+			fmt.Fprint(w, `)]}'`+"\n"+`[{"ref":"refs/heads/stable-1.0"},{"ref":"refs/heads/stable-1.1"}]`)
+		case "2":
+			// A short page doesn't end the listing.
+			fmt.Fprint(w, `)]}'`+"\n"+`[{"ref":"refs/heads/stable-1.2"}]`)
+		case "3":
+			fmt.Fprint(w, `)]}'`+"\n"+`[]`)
+		default:
+			t.Errorf("unexpected skip %q", skip)
+		}
+	})
+
+	branches, _, err := testClient.Projects.ListAllBranches("go", &gerrit.BranchOptions{Limit: 2, Substring: "stable"})
+	if err != nil {
+		t.Fatalf("Projects.ListAllBranches returned error: %v", err)
+	}
+
+	var refs []string
+	for _, b := range *branches {
+		refs = append(refs, b.Ref)
+	}
+	if want := []string{"refs/heads/stable-1.0", "refs/heads/stable-1.1", "refs/heads/stable-1.2"}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("Projects.ListAllBranches returned %v, want %v", refs, want)
+	}
+	if want := []string{"0", "2", "3"}; !reflect.DeepEqual(skips, want) {
+		t.Errorf("Requested pages %v, want %v", skips, want)
+	}
+}
+
+func TestProjectsService_ListAllBranches_SkipIgnored(t *testing.T) {
+	setup()
+	defer teardown()
+
+	requests := 0
+	testMux.HandleFunc("/projects/go/branches/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `)]}'`+"\n"+`[{"ref":"refs/heads/stable-1.0"},{"ref":"refs/heads/stable-1.1"}]`)
+	})
+
+	if _, _, err := testClient.Projects.ListAllBranches("go", &gerrit.BranchOptions{Limit: 2}); err == nil {
+		t.Error("Projects.ListAllBranches returned no error for a server that ignores the skip parameter")
+	}
+	if requests != 2 {
+		t.Errorf("Requested %d pages, want 2", requests)
+	}
+}
+
+func TestProjectsService_GetReflogWithOptions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/branches/master/reflog", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testFormValues(t, r, testValues{
+			"n":    "5",
+			"from": "20240501_0930",
+			"to":   "20240502_1800",
+		})
+		fmt.Fprint(w, `)]}'`+"\n"+`[{"old_id":"a1b2c3","new_id":"d4e5f6","comment":"merged"}]`)
+	})
+
+	opt := &gerrit.ReflogOptions{
+		Limit: 5,
+		From:  time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC),
+		To:    time.Date(2024, 5, 2, 18, 0, 0, 0, time.UTC),
+	}
+	reflog, _, err := testClient.Projects.GetReflogWithOptions("go", "master", opt)
+	if err != nil {
+		t.Fatalf("Projects.GetReflogWithOptions returned error: %v", err)
+	}
+	if len(*reflog) != 1 || (*reflog)[0].NewID != "d4e5f6" {
+		t.Errorf("Projects.GetReflogWithOptions returned %+v", *reflog)
+	}
+}
+
+func TestProjectsService_GetMergeableInformation(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/branches/master/mergeable", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testFormValues(t, r, testValues{"source": "stable-1.0", "strategy": "recursive"})
+		fmt.Fprint(w, `)]}'`+"\n"+`{"submit_type":"MERGE_IF_NECESSARY","strategy":"recursive","mergeable":false,"conflicts":["go.mod"]}`)
+	})
+
+	opt := &gerrit.BranchMergeableOptions{Source: "stable-1.0", Strategy: "recursive"}
+	info, _, err := testClient.Projects.GetMergeableInformation("go", "master", opt)
+	if err != nil {
+		t.Fatalf("Projects.GetMergeableInformation returned error: %v", err)
+	}
+
+	want := &gerrit.MergeableInfo{
+		SubmitType: "MERGE_IF_NECESSARY",
+		Strategy:   "recursive",
+		Conflicts:  []string{"go.mod"},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("Projects.GetMergeableInformation returned %+v, want %+v", info, want)
+	}
+}
+
+func TestProjectsService_SafeDeleteBranch(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		path    string
+		query   string
+		changes string
+		deleted bool
+	}{
+		{"no open changes", "go", "/projects/go/branches/stable-1.0", `project:go branch:stable-1.0 is:open`, `[]`, true},
+		{"open changes", "go", "/projects/go/branches/stable-1.0", `project:go branch:stable-1.0 is:open`, `[{"_number":4711}]`, false},
+		{"quoted project", `my "go"`, `/projects/my+"go"/branches/stable-1.0`, `(project:{my "go"}) branch:stable-1.0 is:open`, `[]`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup()
+			defer teardown()
+
+			testMux.HandleFunc("/changes/", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				testFormValues(t, r, testValues{"q": tt.query, "n": "1"})
+				fmt.Fprint(w, `)]}'`+"\n"+tt.changes)
+			})
+			deleted := false
+			testMux.HandleFunc(tt.path, func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "DELETE")
+				deleted = true
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			_, err := testClient.Projects.SafeDeleteBranch(tt.project, "stable-1.0")
+			if tt.deleted && err != nil {
+				t.Errorf("Projects.SafeDeleteBranch returned error: %v", err)
+			}
+			if !tt.deleted && err == nil {
+				t.Error("Projects.SafeDeleteBranch returned no error for a branch with open changes")
+			}
+			if deleted != tt.deleted {
+				t.Errorf("deleted = %v, want %v", deleted, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestProjectsService_GetCommitWithOptions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/commits/d4e5f6", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if _, ok := r.URL.Query()["links"]; !ok {
+			t.Error("Request does not set links")
+		}
+		fmt.Fprint(w, `)]}'`+"\n"+`{"commit":"d4e5f6","subject":"Fix build","web_links":[{"name":"gitiles","url":"https://gitiles.example.com/go/+/d4e5f6"}]}`)
+	})
+
+	commit, _, err := testClient.Projects.GetCommitWithOptions("go", "d4e5f6", &gerrit.CommitOptions{Weblinks: true})
+	if err != nil {
+		t.Fatalf("Projects.GetCommitWithOptions returned error: %v", err)
+	}
+	if len(commit.WebLinks) != 1 || commit.WebLinks[0].Name != "gitiles" {
+		t.Errorf("Projects.GetCommitWithOptions returned web links %+v", commit.WebLinks)
+	}
+}
+
+func TestProjectsService_GetCommitContent(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/branches/master/files/README.md/content", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `)]}'`+"\n"+`"SGVsbG8="`)
+	})
+	testMux.HandleFunc("/projects/go/commits/d4e5f6/files/README.md/content", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `)]}'`+"\n"+`"V29ybGQ="`)
+	})
+
+	content, _, err := testClient.Projects.GetCommitContent("go", "master", "README.md")
+	if err != nil {
+		t.Fatalf("Projects.GetCommitContent returned error: %v", err)
+	}
+	if want := "SGVsbG8="; content != want {
+		t.Errorf("Projects.GetCommitContent returned %q, want %q", content, want)
+	}
+
+	content, _, err = testClient.Projects.GetCommitFileContent("go", "d4e5f6", "README.md")
+	if err != nil {
+		t.Fatalf("Projects.GetCommitFileContent returned error: %v", err)
+	}
+	if want := "V29ybGQ="; content != want {
+		t.Errorf("Projects.GetCommitFileContent returned %q, want %q", content, want)
+	}
+}
+
+func TestProjectsService_GetCommitIncludedIn(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/commits/d4e5f6/in", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `)]}'`+"\n"+`{"branches":["master","stable-1.0"],"tags":["v1.0"]}`)
+	})
+
+	in, _, err := testClient.Projects.GetCommitIncludedIn("go", "d4e5f6")
+	if err != nil {
+		t.Fatalf("Projects.GetCommitIncludedIn returned error: %v", err)
+	}
+
+	want := &gerrit.IncludedInInfo{
+		Branches: []string{"master", "stable-1.0"},
+		Tags:     []string{"v1.0"},
+	}
+	if !reflect.DeepEqual(in, want) {
+		t.Errorf("Projects.GetCommitIncludedIn returned %+v, want %+v", in, want)
+	}
+}
+
+func TestProjectsService_ListCommitFiles(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/commits/d4e5f6/files/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testFormValues(t, r, testValues{"parent": "2"})
+		fmt.Fprint(w, `)]}'`+"\n"+`{"/COMMIT_MSG":{"status":"A","lines_inserted":7},"go.mod":{"lines_inserted":1,"lines_deleted":1}}`)
+	})
+
+	files, _, err := testClient.Projects.ListCommitFiles("go", "d4e5f6", &gerrit.CommitFilesOptions{Parent: 2})
+	if err != nil {
+		t.Fatalf("Projects.ListCommitFiles returned error: %v", err)
+	}
+
+	want := &map[string]gerrit.FileInfo{
+		"/COMMIT_MSG": {Status: "A", LinesInserted: 7},
+		"go.mod":      {LinesInserted: 1, LinesDeleted: 1},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Projects.ListCommitFiles returned %+v, want %+v", files, want)
+	}
+}
+
+func TestProjectsService_CherryPickCommit(t *testing.T) {
+	setup()
+	defer teardown()
+
+	input := &gerrit.CherryPickInput{
+		Destination: "stable-1.0",
+		Message:     "Fix build\n\n(cherry picked from commit d4e5f6)",
+		Parent:      1,
+	}
+
+	testMux.HandleFunc("/projects/go/commits/d4e5f6/cherrypick", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		v := new(gerrit.CherryPickInput)
+		json.NewDecoder(r.Body).Decode(v)
+		if !reflect.DeepEqual(v, input) {
+			t.Errorf("Request body = %+v, want %+v", v, input)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"id":"go~stable-1.0~I8473b95934b5732ac55d26311a706c9c2bde9940","project":"go","branch":"stable-1.0","_number":4712}`)
+	})
+
+	change, _, err := testClient.Projects.CherryPickCommit("go", "d4e5f6", input)
+	if err != nil {
+		t.Fatalf("Projects.CherryPickCommit returned error: %v", err)
+	}
+	if change.Number != 4712 || change.Branch != "stable-1.0" {
+		t.Errorf("Projects.CherryPickCommit returned %+v", change)
+	}
+}
+
+func TestProjectsService_ListLabels(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/labels/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testFormValues(t, r, testValues{"inherited": "true"})
+		fmt.Fprint(w, `)]}'`+"\n"+`[{"name":"Code-Review","project_name":"All-Projects","function":"MaxWithBlock","values":{" 0":"No score","+1":"Looks good","-1":"Please fix"},"default_value":0,"copy_condition":"changekind:NO_CHANGE"}]`)
+	})
+
+	labels, _, err := testClient.Projects.ListLabels("go", &gerrit.LabelOptions{Inherited: true})
+	if err != nil {
+		t.Fatalf("Projects.ListLabels returned error: %v", err)
+	}
+
+	want := &[]gerrit.LabelDefinitionInfo{{
+		Name:          "Code-Review",
+		ProjectName:   "All-Projects",
+		Function:      gerrit.LabelFunctionMaxWithBlock,
+		Values:        map[string]string{" 0": "No score", "+1": "Looks good", "-1": "Please fix"},
+		CopyCondition: "changekind:NO_CHANGE",
+	}}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("Projects.ListLabels returned %+v, want %+v", labels, want)
+	}
+}
+
+func TestProjectsService_CreateLabel(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/labels/Verified", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+
+		b, _ := ioutil.ReadAll(r.Body)
+		if got, want := string(b), `{"function":"NoBlock","values":{" 0":"No score","+1":"Verified"},"default_value":0,"commit_message":"Add Verified label"}`+"\n"; got != want {
+			t.Errorf("Request body = %s, want %s", got, want)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `)]}'`+"\n"+`{"name":"Verified","project_name":"go","function":"NoBlock","values":{" 0":"No score","+1":"Verified"},"default_value":0}`)
+	})
+
+	defaultValue := 0
+	input := &gerrit.LabelDefinitionInput{
+		Function:      gerrit.LabelFunctionNoBlock,
+		Values:        map[string]string{" 0": "No score", "+1": "Verified"},
+		DefaultValue:  &defaultValue,
+		CommitMessage: "Add Verified label",
+	}
+	label, _, err := testClient.Projects.CreateLabel("go", "Verified", input)
+	if err != nil {
+		t.Fatalf("Projects.CreateLabel returned error: %v", err)
+	}
+	if label.Name != "Verified" || label.ProjectName != "go" {
+		t.Errorf("Projects.CreateLabel returned %+v", label)
+	}
+}
+
+func TestLabelDefinitionInput_Validate(t *testing.T) {
+	one := 1
+	tests := []struct {
+		name  string
+		input *gerrit.LabelDefinitionInput
+		valid bool
+	}{
+		{"nil", nil, true},
+		{"valid", &gerrit.LabelDefinitionInput{Function: gerrit.LabelFunctionMaxWithBlock, Values: map[string]string{"-1": "Fails", " 0": "No score", "+1": "Verified"}, DefaultValue: &one}, true},
+		{"invalid function", &gerrit.LabelDefinitionInput{Function: "MaxBlock"}, false},
+		{"invalid value", &gerrit.LabelDefinitionInput{Values: map[string]string{"+x": "Verified"}}, false},
+		{"duplicate value", &gerrit.LabelDefinitionInput{Values: map[string]string{"1": "Verified", "+1": "Verified"}}, false},
+		{"missing description", &gerrit.LabelDefinitionInput{Values: map[string]string{"+1": " "}}, false},
+		{"unknown default value", &gerrit.LabelDefinitionInput{Values: map[string]string{" 0": "No score"}, DefaultValue: &one}, false},
+		{"copy condition and unset", &gerrit.LabelDefinitionInput{CopyCondition: "is:ANY", UnsetCopyCondition: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate returned error: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("Validate returned no error")
+			}
+		})
+	}
+}
+
+func TestProjectsService_DeleteLabel(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/labels/Verified", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+
+		v := new(gerrit.DeleteLabelInput)
+		json.NewDecoder(r.Body).Decode(v)
+		if v.CommitMessage != "Remove Verified label" {
+			t.Errorf("Request body = %+v", v)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	_, err := testClient.Projects.DeleteLabel("go", "Verified", &gerrit.DeleteLabelInput{CommitMessage: "Remove Verified label"})
+	if err != nil {
+		t.Errorf("Projects.DeleteLabel returned error: %v", err)
+	}
+}
+
+func TestProjectsService_BatchUpdateLabels(t *testing.T) {
+	setup()
+	defer teardown()
+
+	input := &gerrit.BatchLabelInput{
+		CommitMessage: "Roll out labels",
+		Delete:        []string{"Library-Compliance"},
+		Create: []gerrit.LabelDefinitionInput{
+			{Name: "Verified", Values: map[string]string{" 0": "No score", "+1": "Verified"}},
+		},
+		Update: map[string]gerrit.LabelDefinitionInput{
+			"Code-Review": {CopyCondition: "changekind:TRIVIAL_REBASE"},
+		},
+	}
+
+	testMux.HandleFunc("/projects/go/labels/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		v := new(gerrit.BatchLabelInput)
+		json.NewDecoder(r.Body).Decode(v)
+		if !reflect.DeepEqual(v, input) {
+			t.Errorf("Request body = %+v, want %+v", v, input)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`"Labels updated"`)
+	})
+
+	_, err := testClient.Projects.BatchUpdateLabels("go", input)
+	if err != nil {
+		t.Errorf("Projects.BatchUpdateLabels returned error: %v", err)
+	}
+
+	input.Create[0].Name = ""
+	if _, err := testClient.Projects.BatchUpdateLabels("go", input); err == nil {
+		t.Error("Projects.BatchUpdateLabels returned no error for a label without name")
+	}
+}
+
+func TestProjectsService_CreateLabelsChange(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/labels:review", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fmt.Fprint(w, `)]}'`+"\n"+`{"id":"go~refs/meta/config~I8473b95934b5732ac55d26311a706c9c2bde9940","branch":"refs/meta/config","_number":4713}`)
+	})
+
+	change, _, err := testClient.Projects.CreateLabelsChange("go", &gerrit.BatchLabelInput{Delete: []string{"Verified"}})
+	if err != nil {
+		t.Fatalf("Projects.CreateLabelsChange returned error: %v", err)
+	}
+	if change.Number != 4713 {
+		t.Errorf("Projects.CreateLabelsChange returned %+v", change)
+	}
+}
+
+func TestProjectsService_ListSubmitRequirements(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/submit_requirements/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `)]}'`+"\n"+`[{"name":"Verified","project_name":"go","submittability_expression":"label:Verified=MAX AND -label:Verified=MIN","allow_override_in_child_projects":false}]`)
+	})
+
+	srs, _, err := testClient.Projects.ListSubmitRequirements("go", nil)
+	if err != nil {
+		t.Fatalf("Projects.ListSubmitRequirements returned error: %v", err)
+	}
+
+	want := &[]gerrit.SubmitRequirementInfo{{
+		Name:                     "Verified",
+		ProjectName:              "go",
+		SubmittabilityExpression: "label:Verified=MAX AND -label:Verified=MIN",
+	}}
+	if !reflect.DeepEqual(srs, want) {
+		t.Errorf("Projects.ListSubmitRequirements returned %+v, want %+v", srs, want)
+	}
+}
+
+func TestProjectsService_CreateSubmitRequirement(t *testing.T) {
+	setup()
+	defer teardown()
+
+	input := &gerrit.SubmitRequirementInput{
+		Description:                  "Changes must be verified by CI",
+		SubmittabilityExpression:     "label:Verified=MAX",
+		AllowOverrideInChildProjects: gerrit.Bool(false),
+	}
+
+	testMux.HandleFunc("/projects/go/submit_requirements/Verified", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+
+		v := new(gerrit.SubmitRequirementInput)
+		json.NewDecoder(r.Body).Decode(v)
+		if !reflect.DeepEqual(v, input) {
+			t.Errorf("Request body = %+v, want %+v", v, input)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `)]}'`+"\n"+`{"name":"Verified","project_name":"go","submittability_expression":"label:Verified=MAX"}`)
+	})
+
+	sr, _, err := testClient.Projects.CreateSubmitRequirement("go", "Verified", input)
+	if err != nil {
+		t.Fatalf("Projects.CreateSubmitRequirement returned error: %v", err)
+	}
+	if sr.Name != "Verified" {
+		t.Errorf("Projects.CreateSubmitRequirement returned %+v", sr)
+	}
+
+	if _, _, err := testClient.Projects.CreateSubmitRequirement("go", "Verified", &gerrit.SubmitRequirementInput{}); err == nil {
+		t.Error("Projects.CreateSubmitRequirement returned no error without submittability expression")
+	}
+}
+
+func TestProjectsService_BatchUpdateSubmitRequirements(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var requests []string
+	testMux.HandleFunc("/projects/go/submit_requirements/", func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprint(w, `)]}'`+"\n"+`{}`)
+	})
+
+	input := &gerrit.BatchSubmitRequirementInput{
+		Delete: []string{"Library-Compliance"},
+		Create: []gerrit.SubmitRequirementInput{{Name: "Verified", SubmittabilityExpression: "label:Verified=MAX"}},
+		Update: map[string]gerrit.SubmitRequirementInput{
+			"Code-Review":            {SubmittabilityExpression: "label:Code-Review=MAX,user=non_uploader"},
+			"No-Unresolved-Comments": {SubmittabilityExpression: "-has:unresolved"},
+		},
+	}
+	_, err := testClient.Projects.BatchUpdateSubmitRequirements("go", input)
+	if err != nil {
+		t.Fatalf("Projects.BatchUpdateSubmitRequirements returned error: %v", err)
+	}
+
+	want := []string{
+		"DELETE /projects/go/submit_requirements/Library-Compliance",
+		"PUT /projects/go/submit_requirements/Verified",
+		"PUT /projects/go/submit_requirements/Code-Review",
+		"PUT /projects/go/submit_requirements/No-Unresolved-Comments",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("Requests %v, want %v", requests, want)
+	}
+
+	requests = nil
+	input.Update["Code-Review"] = gerrit.SubmitRequirementInput{}
+	if _, err := testClient.Projects.BatchUpdateSubmitRequirements("go", input); err == nil {
+		t.Error("Projects.BatchUpdateSubmitRequirements returned no error for an invalid update")
+	}
+	if len(requests) != 0 {
+		t.Errorf("Requests %v sent for invalid input", requests)
+	}
+}
+
+func TestDashboardBuilder_URL(t *testing.T) {
+	client, err := gerrit.NewClient("https://review.example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := client.Projects.NewDashboardBuilder("Release 1.0")
+	b.Foreach = gerrit.QueryOperator("project", "go")
+	b.Section("Needs review", gerrit.QueryAnd(gerrit.QueryIsOpen, gerrit.QueryOperator("label", "Code-Review=0"))).
+		Section("Merged", gerrit.QueryIsMerged)
+
+	want := "https://review.example.com/dashboard/?title=Release+1.0&foreach=project%3Ago&Needs+review=is%3Aopen+label%3ACode-Review%3D0&Merged=is%3Amerged"
+	if got := b.URL(); got != want {
+		t.Errorf("URL %s, want %s", got, want)
+	}
+}
+
+func TestDashboardBuilder_WriteConfig(t *testing.T) {
+	client, err := gerrit.NewClient("https://review.example.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := client.Projects.NewDashboardBuilder("Review")
+	b.Description = "Changes of ${project}"
+	b.Foreach = gerrit.QueryOperator("project", "${project}")
+	b.Section("Open", gerrit.QueryAnd(gerrit.QueryIsOpen, gerrit.QueryOperator("owner", "John Doe")))
+	b.Section("Für \"C:\\\"\tTab", gerrit.QueryIsOpen)
+
+	var buf bytes.Buffer
+	if err := b.WriteConfig(&buf); err != nil {
+		t.Fatalf("WriteConfig returned error: %v", err)
+	}
+
+	// Subsection names only escape quotes and backslashes, unlike Go string literals.
+	want := "[dashboard]\n" +
+		"\ttitle = Review\n" +
+		"\tdescription = Changes of ${project}\n" +
+		"\tforeach = project:${project}\n" +
+		"[section \"Open\"]\n" +
+		"\tquery = \"is:open owner:\\\"John Doe\\\"\"\n" +
+		"[section \"Für \\\"C:\\\\\\\"\tTab\"]\n" +
+		"\tquery = is:open\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteConfig wrote\n%s\nwant\n%s", got, want)
+	}
+
+	b.Section("Two\nLines", gerrit.QueryIsOpen)
+	buf.Reset()
+	if err := b.WriteConfig(&buf); err == nil || buf.Len() != 0 {
+		t.Errorf("WriteConfig of a section name with a line break returned %v and wrote %q", err, buf.String())
+	}
+}
+
+func TestDashboardBuilder_Validate(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var queries []string
+	testMux.HandleFunc("/changes/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if n := r.FormValue("n"); n != "1" {
+			t.Errorf("Request parameter n=%q, want 1", n)
+		}
+
+		q := r.FormValue("q")
+		queries = append(queries, q)
+		if q == "project:go labl:Verified" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `Error in operator labl:Verified`)
+			return
+		}
+		fmt.Fprint(w, `)]}'`+"\n"+`[]`)
+	})
+
+	testMux.HandleFunc("/projects/go/HEAD", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `)]}'`+"\n"+`"refs/heads/main"`)
+	})
+
+	b := testClient.Projects.NewDashboardBuilder("Review")
+	b.Foreach = gerrit.QueryOperator("project", "${project}")
+	b.Section("Open", gerrit.QueryIsOpen).
+		Section("Branch", gerrit.QueryOperator("branch", "${branch}"))
+	if _, err := b.Validate("go", "stable-1.0"); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+	if want := []string{"project:go is:open", "project:go branch:stable-1.0"}; !reflect.DeepEqual(queries, want) {
+		t.Errorf("Queries %v, want %v", queries, want)
+	}
+
+	// Without a branch, the branch HEAD points to is used.
+	queries = nil
+	if _, err := b.Validate("go", ""); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+	if want := []string{"project:go is:open", "project:go branch:main"}; !reflect.DeepEqual(queries, want) {
+		t.Errorf("Queries %v, want %v", queries, want)
+	}
+
+	b.Section("Broken", "labl:Verified")
+	if _, err := b.Validate("go", "main"); err == nil {
+		t.Error("Validate returned no error for an invalid query")
+	}
+
+	b = testClient.Projects.NewDashboardBuilder("Empty")
+	if _, err := b.Validate("go", "main"); err == nil {
+		t.Error("Validate returned no error for a dashboard without sections")
+	}
+}
+
+func TestProjectsService_SetDefaultDashboard(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testMux.HandleFunc("/projects/go/dashboards/default", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+
+		v := new(gerrit.DashboardInput)
+		json.NewDecoder(r.Body).Decode(v)
+		if want := (&gerrit.DashboardInput{ID: "main:review", CommitMessage: "Set default dashboard"}); !reflect.DeepEqual(v, want) {
+			t.Errorf("Request body = %+v, want %+v", v, want)
+		}
+
+		fmt.Fprint(w, `)]}'`+"\n"+`{"id":"main:review","project":"go","default":true,"title":"Review","sections":[{"name":"Open","query":"is:open"}]}`)
+	})
+
+	dashboard, _, err := testClient.Projects.SetDefaultDashboard("go", "main:review", "Set default dashboard")
+	if err != nil {
+		t.Fatalf("Projects.SetDefaultDashboard returned error: %v", err)
+	}
+	if !dashboard.Default {
+		t.Errorf("Projects.SetDefaultDashboard returned %+v, want default dashboard", dashboard)
+	}
+}
+
 func ExampleProjectsService_ListProjects() {
 	instance := "http://review.cyanogenmod.org/"
-	client, err := gerrit.NewClient(instance, nil)
+	client, err := gerrit.NewClient(instance, exampleHTTPClient("ExampleProjectsService_ListProjects"))
 	if err != nil {
 		panic(err)
 	}
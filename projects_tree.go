@@ -0,0 +1,455 @@
+package gerrit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// ProjectTreeNode is a project in a ProjectTree.
+type ProjectTreeNode struct {
+	Name        string
+	Parent      string
+	State       string
+	Description string
+	// PermissionsOnly is set for projects that only hold access rights and no code.
+	PermissionsOnly bool
+	// Children are the names of the direct child projects, sorted by name.
+	Children []string
+}
+
+// ProjectTree is the inheritance graph of projects.
+// It can be built with one call via ProjectsService.GetProjectTree
+// or incrementally via ProjectsService.AddToProjectTree and ProjectTree.Add.
+//
+// Projects can reference parents that are not part of the tree,
+// e.g. because the parent is not visible to the caller. Such projects are reported by Orphans.
+type ProjectTree struct {
+	nodes map[string]*ProjectTreeNode
+
+	// pending maps parents that are not part of the tree yet to their children.
+	pending map[string][]string
+}
+
+// NewProjectTree returns an empty ProjectTree.
+func NewProjectTree() *ProjectTree {
+	return &ProjectTree{
+		nodes:   make(map[string]*ProjectTreeNode),
+		pending: make(map[string][]string),
+	}
+}
+
+// Add adds or updates a project.
+// If name is empty, info.Name is used.
+func (t *ProjectTree) Add(name string, info ProjectInfo) {
+	if name == "" {
+		name = info.Name
+	}
+
+	node, ok := t.nodes[name]
+	if !ok {
+		node = &ProjectTreeNode{Name: name, Children: t.pending[name]}
+		delete(t.pending, name)
+		t.nodes[name] = node
+	} else if node.Parent != info.Parent {
+		t.unlink(node)
+	}
+
+	if info.State != "" {
+		node.State = info.State
+	}
+	if info.Description != "" {
+		node.Description = info.Description
+	}
+	if !ok || node.Parent != info.Parent {
+		node.Parent = info.Parent
+		t.link(node)
+	}
+}
+
+// link registers node as child of its parent.
+func (t *ProjectTree) link(node *ProjectTreeNode) {
+	if node.Parent == "" {
+		return
+	}
+	if parent, ok := t.nodes[node.Parent]; ok {
+		parent.Children = insertString(parent.Children, node.Name)
+	} else {
+		t.pending[node.Parent] = insertString(t.pending[node.Parent], node.Name)
+	}
+}
+
+// unlink removes node from the children of its parent.
+func (t *ProjectTree) unlink(node *ProjectTreeNode) {
+	if parent, ok := t.nodes[node.Parent]; ok {
+		parent.Children = removeString(parent.Children, node.Name)
+	} else if node.Parent != "" {
+		t.pending[node.Parent] = removeString(t.pending[node.Parent], node.Name)
+	}
+}
+
+// Project returns the node of a project, or nil if the project is not part of the tree.
+func (t *ProjectTree) Project(name string) *ProjectTreeNode {
+	return t.nodes[name]
+}
+
+// Projects returns the names of all projects, sorted by name.
+func (t *ProjectTree) Projects() []string {
+	names := make([]string, 0, len(t.nodes))
+	for name := range t.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Roots returns the projects without a parent in the tree, sorted by name.
+// This is usually All-Projects, plus all orphans.
+//
+// Projects in a parent cycle have a parent in the tree, but none of them is below a root.
+// So the first project of each cycle (see Cycles) is a root as well.
+func (t *ProjectTree) Roots() []string {
+	var roots []string
+	for _, name := range t.Projects() {
+		if _, ok := t.nodes[t.nodes[name].Parent]; !ok {
+			roots = append(roots, name)
+		}
+	}
+	for _, cycle := range t.Cycles() {
+		roots = append(roots, cycle[0])
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// Cycles returns the projects whose parents form a cycle, e.g. a project that is its own grandparent.
+// Gerrit refuses to create cycles, but they can show up in inconsistent data or
+// in trees that are built incrementally from different points in time.
+//
+// Each cycle starts with its project that is first by name and follows the parents from there.
+// The cycles are sorted by their first project.
+func (t *ProjectTree) Cycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+
+	var cycles [][]string
+	for _, name := range t.Projects() {
+		// Follow the parents until a project is reached that was seen before.
+		var path []string
+		current := name
+		for {
+			if _, ok := t.nodes[current]; !ok || state[current] != unvisited {
+				break
+			}
+			state[current] = visiting
+			path = append(path, current)
+			current = t.nodes[current].Parent
+		}
+
+		// The cycle is only new if the chain ran into a project of this path.
+		if state[current] == visiting {
+			start := 0
+			for path[start] != current {
+				start++
+			}
+			cycle := path[start:]
+			first := 0
+			for i := range cycle {
+				if cycle[i] < cycle[first] {
+					first = i
+				}
+			}
+			cycles = append(cycles, append(append([]string(nil), cycle[first:]...), cycle[:first]...))
+		}
+		for _, p := range path {
+			state[p] = visited
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// inCycle returns the set of all projects that are part of a parent cycle.
+func (t *ProjectTree) inCycle() map[string]bool {
+	set := map[string]bool{}
+	for _, cycle := range t.Cycles() {
+		for _, name := range cycle {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// Orphans returns the projects whose parent is not part of the tree, sorted by name.
+func (t *ProjectTree) Orphans() []string {
+	var orphans []string
+	for _, name := range t.Projects() {
+		parent := t.nodes[name].Parent
+		if _, ok := t.nodes[parent]; parent != "" && !ok {
+			orphans = append(orphans, name)
+		}
+	}
+	return orphans
+}
+
+// PermissionsOnlyParents returns the projects that have children and are permissions-only, sorted by name.
+func (t *ProjectTree) PermissionsOnlyParents() []string {
+	var parents []string
+	for _, name := range t.Projects() {
+		node := t.nodes[name]
+		if node.PermissionsOnly && len(node.Children) > 0 {
+			parents = append(parents, name)
+		}
+	}
+	return parents
+}
+
+// Ancestors returns the parent chain of a project, starting with the direct parent.
+// The chain ends at the first parent that is not part of the tree, which is still included.
+func (t *ProjectTree) Ancestors(name string) []string {
+	var ancestors []string
+	seen := map[string]bool{name: true}
+	for node := t.nodes[name]; node != nil && node.Parent != ""; node = t.nodes[node.Parent] {
+		if seen[node.Parent] {
+			break
+		}
+		seen[node.Parent] = true
+		ancestors = append(ancestors, node.Parent)
+	}
+	return ancestors
+}
+
+// Descendants returns all projects below a project in depth-first order.
+func (t *ProjectTree) Descendants(name string) []string {
+	var descendants []string
+	t.Walk(name, func(node *ProjectTreeNode, depth int) error {
+		if depth > 0 {
+			descendants = append(descendants, node.Name)
+		}
+		return nil
+	})
+	return descendants
+}
+
+// Walk calls fn for a project and all its descendants in depth-first order.
+// depth is 0 for the project itself. If fn returns an error, the walk stops and the error is returned.
+func (t *ProjectTree) Walk(name string, fn func(node *ProjectTreeNode, depth int) error) error {
+	seen := map[string]bool{}
+
+	var walk func(name string, depth int) error
+	walk = func(name string, depth int) error {
+		node, ok := t.nodes[name]
+		if !ok || seen[name] {
+			return nil
+		}
+		seen[name] = true
+
+		if err := fn(node, depth); err != nil {
+			return err
+		}
+		for _, child := range node.Children {
+			if err := walk(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(name, 0)
+}
+
+// WriteDOT writes the tree in the DOT language of Graphviz to w.
+// Edges point from a project to its parent. Permissions-only projects are drawn as boxes,
+// missing parents of orphans are drawn dashed and projects in a parent cycle are drawn red.
+func (t *ProjectTree) WriteDOT(w io.Writer) error {
+	inCycle := t.inCycle()
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph projects {")
+	fmt.Fprintln(b, "\trankdir=BT;")
+	for _, name := range t.Projects() {
+		node := t.nodes[name]
+		attrs := ""
+		switch {
+		case inCycle[name]:
+			attrs = " [color=red]"
+		case node.PermissionsOnly:
+			attrs = " [shape=box]"
+		case node.State != "" && node.State != ProjectStateActive:
+			attrs = " [style=dotted]"
+		}
+		fmt.Fprintf(b, "\t%s%s;\n", strconv.Quote(name), attrs)
+	}
+
+	missing := map[string]bool{}
+	for _, name := range t.Orphans() {
+		missing[t.nodes[name].Parent] = true
+	}
+	for _, name := range sortedStringSet(missing) {
+		fmt.Fprintf(b, "\t%s [style=dashed];\n", strconv.Quote(name))
+	}
+
+	for _, name := range t.Projects() {
+		if parent := t.nodes[name].Parent; parent != "" {
+			fmt.Fprintf(b, "\t%s -> %s;\n", strconv.Quote(name), strconv.Quote(parent))
+		}
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// projectTreeJSON is the nested JSON representation of a project and its descendants.
+type projectTreeJSON struct {
+	Name            string `json:"name"`
+	Parent          string `json:"parent,omitempty"`
+	State           string `json:"state,omitempty"`
+	Description     string `json:"description,omitempty"`
+	PermissionsOnly bool   `json:"permissions_only,omitempty"`
+	// Cycle is set for projects that are part of a parent cycle.
+	Cycle    bool              `json:"cycle,omitempty"`
+	Children []projectTreeJSON `json:"children,omitempty"`
+}
+
+// MarshalJSON encodes the tree as a nested list, starting at the roots.
+// Projects in a parent cycle are marked with "cycle": true.
+func (t *ProjectTree) MarshalJSON() ([]byte, error) {
+	inCycle := t.inCycle()
+	seen := map[string]bool{}
+
+	var build func(name string) projectTreeJSON
+	build = func(name string) projectTreeJSON {
+		seen[name] = true
+		node := t.nodes[name]
+		v := projectTreeJSON{
+			Name:            node.Name,
+			Parent:          node.Parent,
+			State:           node.State,
+			Description:     node.Description,
+			PermissionsOnly: node.PermissionsOnly,
+			Cycle:           inCycle[name],
+		}
+		for _, child := range node.Children {
+			if !seen[child] {
+				v.Children = append(v.Children, build(child))
+			}
+		}
+		return v
+	}
+
+	roots := []projectTreeJSON{}
+	for _, name := range t.Roots() {
+		roots = append(roots, build(name))
+	}
+	return json.Marshal(roots)
+}
+
+// GetProjectTree builds the inheritance graph of all projects accessible by the caller.
+// opt can limit the projects, e.g. by Prefix; Tree and Type are set by GetProjectTree.
+//
+// Two requests are sent: one for the tree and one to find the permissions-only projects.
+// The returned Response is the one of the last request.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-projects.html#list-projects
+func (s *ProjectsService) GetProjectTree(opt *ProjectOptions) (*ProjectTree, *Response, error) {
+	treeOpt := ProjectOptions{}
+	if opt != nil {
+		treeOpt = *opt
+	}
+	treeOpt.Tree = true
+	treeOpt.Branch = ""
+	treeOpt.Type = ""
+
+	projects, resp, err := s.ListProjects(&treeOpt)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	tree := NewProjectTree()
+	for name, info := range *projects {
+		tree.Add(name, info)
+	}
+
+	treeOpt.Tree = false
+	treeOpt.Type = "PERMISSIONS"
+	permissionsOnly, resp, err := s.ListProjects(&treeOpt)
+	if err != nil {
+		return nil, resp, err
+	}
+	for name := range *permissionsOnly {
+		if node := tree.Project(name); node != nil {
+			node.PermissionsOnly = true
+		}
+	}
+
+	return tree, resp, nil
+}
+
+// AddToProjectTree adds a project, its ancestors and its descendants to tree.
+// Ancestors are fetched one by one until a project is reached that is already part of the tree.
+// This allows to build the tree incrementally for the projects of interest only.
+// Permissions-only projects are not detected this way.
+//
+// The returned Response is the one of the last request.
+func (s *ProjectsService) AddToProjectTree(tree *ProjectTree, projectName string) (*Response, error) {
+	var resp *Response
+	for name := projectName; name != ""; {
+		if name != projectName && tree.Project(name) != nil {
+			break
+		}
+
+		info, r, err := s.GetProject(name)
+		resp = r
+		if err != nil {
+			return resp, err
+		}
+		tree.Add(name, *info)
+		name = info.Parent
+	}
+
+	children, resp, err := s.ListChildProjects(projectName, &ChildProjectOptions{Recursive: 1})
+	if err != nil {
+		return resp, err
+	}
+	for _, child := range *children {
+		tree.Add("", child)
+	}
+
+	return resp, nil
+}
+
+// insertString inserts s into the sorted slice list, if it is not part of it yet.
+func insertString(list []string, s string) []string {
+	i := sort.SearchStrings(list, s)
+	if i < len(list) && list[i] == s {
+		return list
+	}
+	list = append(list, "")
+	copy(list[i+1:], list[i:])
+	list[i] = s
+	return list
+}
+
+// removeString removes s from the sorted slice list.
+func removeString(list []string, s string) []string {
+	i := sort.SearchStrings(list, s)
+	if i < len(list) && list[i] == s {
+		return append(list[:i], list[i+1:]...)
+	}
+	return list
+}
+
+func sortedStringSet(set map[string]bool) []string {
+	list := make([]string, 0, len(set))
+	for s := range set {
+		list = append(list, s)
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,92 @@
+package gerrit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// RequestBody is the body of an API request together with its content type.
+//
+// Values that implement RequestBody are passed to Client.NewRequest and Client.Call as they are.
+// All other values are encoded as JSON, so JSONBody is only needed to be explicit.
+// Use RawBody and TextBody for endpoints that don't accept JSON,
+// e.g. SSH keys as text/plain or plugin jars as application/octet-stream.
+type RequestBody interface {
+	// ContentType returns the value of the Content-Type header.
+	ContentType() string
+	// Reader returns the content of the body. It is called once per request.
+	Reader() (io.Reader, error)
+}
+
+// JSONBody returns a RequestBody that encodes v as JSON.
+func JSONBody(v interface{}) RequestBody {
+	return jsonBody{v: v}
+}
+
+type jsonBody struct {
+	v interface{}
+}
+
+func (b jsonBody) ContentType() string {
+	return "application/json"
+}
+
+func (b jsonBody) Reader() (io.Reader, error) {
+	buf := new(bytes.Buffer)
+	if err := json.NewEncoder(buf).Encode(b.v); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// RawBody returns a RequestBody that sends the content of r as is with the given content type.
+//
+// The content is streamed: it is not read before the request is sent, so large uploads are not held in memory.
+// If r is a *bytes.Buffer, *bytes.Reader or *strings.Reader, the Content-Length header is set.
+// Otherwise the content is sent with chunked transfer encoding.
+// If r is an io.Closer, it is closed after the request was sent.
+func RawBody(contentType string, r io.Reader) RequestBody {
+	return rawBody{contentType: contentType, r: r}
+}
+
+// TextBody returns a RequestBody that sends s as text/plain.
+func TextBody(s string) RequestBody {
+	return RawBody("text/plain", strings.NewReader(s))
+}
+
+type rawBody struct {
+	contentType string
+	r           io.Reader
+}
+
+func (b rawBody) ContentType() string {
+	return b.contentType
+}
+
+func (b rawBody) Reader() (io.Reader, error) {
+	return b.r, nil
+}
+
+// DataURL returns data as base64 encoded data URL, e.g. "data:text/plain;base64,SGVsbG8=".
+// Gerrit expects binary content in JSON entities in this format, e.g. binary_content of the FileContentInput entity.
+//
+// Gerrit API docs: https://gerrit-review.googlesource.com/Documentation/rest-api-changes.html#file-content-input
+func DataURL(contentType string, data []byte) string {
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// newRequestBody returns body as RequestBody.
+// Values that don't implement RequestBody are encoded as JSON. A nil body stays nil.
+func newRequestBody(body interface{}) RequestBody {
+	switch b := body.(type) {
+	case nil:
+		return nil
+	case RequestBody:
+		return b
+	default:
+		return JSONBody(b)
+	}
+}